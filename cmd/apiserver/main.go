@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -32,6 +33,7 @@ import (
 
 	"github.com/oam-dev/kubevela/pkg/apiserver/log"
 	"github.com/oam-dev/kubevela/pkg/apiserver/rest"
+	"github.com/oam-dev/kubevela/pkg/velaql/providers/query"
 	"github.com/oam-dev/kubevela/version"
 )
 
@@ -46,7 +48,21 @@ func main() {
 	flag.StringVar(&s.restCfg.LeaderConfig.LockName, "lock-name", "apiserver-lock", "the lease lock resource name")
 	flag.DurationVar(&s.restCfg.LeaderConfig.Duration, "duration", time.Second*5, "the lease lock resource name")
 	flag.DurationVar(&s.restCfg.AddonCacheTime, "addon-cache-duration", time.Minute*10, "how long between two addon cache operation")
+	var velaQLAllowedClusters string
+	flag.StringVar(&velaQLAllowedClusters, "velaql-allowed-clusters", "", "The comma separated clusters the velaql queries can reach, the hub cluster is named local. All the clusters are allowed if it is empty.")
+	var velaQLIngressClassPortAnnotations string
+	flag.StringVar(&velaQLIngressClassPortAnnotations, "velaql-ingress-class-port-annotations", "", "The comma separated <class>=<http annotation>:<https annotation> entries, the annotations record the ports listened by the controller of the ingress class, e.g. traefik=traefik.example.io/http-port:traefik.example.io/https-port.")
 	flag.Parse()
+	if velaQLAllowedClusters != "" {
+		query.AllowedClusters = strings.Split(velaQLAllowedClusters, ",")
+	}
+	if velaQLIngressClassPortAnnotations != "" {
+		annotations, err := query.ParseIngressClassPortAnnotations(velaQLIngressClassPortAnnotations)
+		if err != nil {
+			log.Logger.Fatal(err.Error())
+		}
+		query.IngressClassPortAnnotations = annotations
+	}
 
 	if len(os.Args) > 2 && os.Args[1] == "build-swagger" {
 		func() {
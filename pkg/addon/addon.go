@@ -31,6 +31,7 @@ import (
 
 	"cuelang.org/go/cue"
 	cueyaml "cuelang.org/go/encoding/yaml"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/google/go-github/v32/github"
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
@@ -41,6 +42,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	k8syaml "k8s.io/apimachinery/pkg/runtime/serializer/yaml"
 	types2 "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -419,30 +421,40 @@ func (h *gitHelper) readRepo(relativePath string) (*github.RepositoryContent, []
 }
 
 func genAddonAPISchema(addonRes *UIData) error {
-	param, err := utils2.PrepareParameterCue(addonRes.Name, addonRes.Parameters)
+	schema, err := genParameterSchema(addonRes.Name, addonRes.Parameters)
 	if err != nil {
 		return err
 	}
+	utils2.FixOpenAPISchema("", schema)
+	addonRes.APISchema = schema
+	return nil
+}
+
+// genParameterSchema generates the OpenAPI schema of the parameter in the parameter.cue of the addon
+func genParameterSchema(name, parameters string) (*openapi3.Schema, error) {
+	param, err := utils2.PrepareParameterCue(name, parameters)
+	if err != nil {
+		return nil, err
+	}
 	var r cue.Runtime
 	cueInst, err := r.Compile("-", param)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	data, err := common.GenOpenAPI(cueInst)
 	if err != nil {
-		return err
-	}
-	schema, err := utils2.ConvertOpenAPISchema2SwaggerObject(data)
-	if err != nil {
-		return err
+		return nil, err
 	}
-	utils2.FixOpenAPISchema("", schema)
-	addonRes.APISchema = schema
-	return nil
+	return utils2.ConvertOpenAPISchema2SwaggerObject(data)
 }
 
 // RenderApp render a K8s application
 func RenderApp(ctx context.Context, addon *InstallPackage, config *rest.Config, k8sClient client.Client, args map[string]interface{}) (*v1beta1.Application, error) {
+	return renderApp(ctx, addon, config, k8sClient, args, "")
+}
+
+// renderApp render a K8s application, the resources in the templates are installed into targetNamespace if it's set
+func renderApp(ctx context.Context, addon *InstallPackage, config *rest.Config, k8sClient client.Client, args map[string]interface{}, targetNamespace string) (*v1beta1.Application, error) {
 	if args == nil {
 		args = map[string]interface{}{}
 	}
@@ -463,6 +475,11 @@ func RenderApp(ctx context.Context, addon *InstallPackage, config *rest.Config,
 		}
 	}
 	app.Labels = util.MergeMapOverrideWithDst(app.Labels, map[string]string{oam.LabelAddonName: addon.Name})
+	// the components which aren't moved explicitly, such as the ones rendered from the CUE templates, follow the
+	// namespace of the application
+	if targetNamespace != "" {
+		app.Namespace = targetNamespace
+	}
 	for _, namespace := range addon.NeedNamespace {
 		comp := common2.ApplicationComponent{
 			Type:       "raw",
@@ -477,16 +494,34 @@ func RenderApp(ctx context.Context, addon *InstallPackage, config *rest.Config,
 		if err != nil {
 			return nil, err
 		}
+		if err = setComponentNamespace(comp, targetNamespace); err != nil {
+			return nil, err
+		}
 		app.Spec.Components = append(app.Spec.Components, *comp)
 	}
+	// the secret references are passed to the templates as secretKeyRef values, the literal values are never rendered
+	secretNamespace := targetNamespace
+	if secretNamespace == "" {
+		secretNamespace = types.DefaultKubeVelaNS
+	}
+	if err := checkSecretArgs(ctx, k8sClient, args, secretNamespace); err != nil {
+		return nil, err
+	}
+	renderArgs, err := renderSecretArgs(args)
+	if err != nil {
+		return nil, err
+	}
 	for _, tmpl := range addon.CUETemplates {
-		comp, err := renderCUETemplate(tmpl, addon.Parameters, args)
+		comp, err := renderCUETemplate(tmpl, addon.Parameters, renderArgs, app.Namespace)
 		if err != nil {
 			return nil, ErrRenderCueTmpl
 		}
 		if addon.Name == ObservabilityAddon && strings.HasSuffix(comp.Name, ".cue") {
 			comp.Name = strings.Split(comp.Name, ".cue")[0]
 		}
+		if err = setComponentNamespace(comp, targetNamespace); err != nil {
+			return nil, err
+		}
 		app.Spec.Components = append(app.Spec.Components, *comp)
 	}
 
@@ -544,6 +579,9 @@ func RenderApp(ctx context.Context, addon *InstallPackage, config *rest.Config,
 			if err != nil {
 				return nil, err
 			}
+			if err = setDefinitionNamespace(comp); err != nil {
+				return nil, err
+			}
 			app.Spec.Components = append(app.Spec.Components, *comp)
 		}
 		for _, cueDef := range addon.CUEDefinitions {
@@ -552,11 +590,15 @@ func RenderApp(ctx context.Context, addon *InstallPackage, config *rest.Config,
 			if err != nil {
 				return nil, errors.Wrapf(err, "fail to render definition: %s in cue's format", cueDef.Name)
 			}
-			app.Spec.Components = append(app.Spec.Components, common2.ApplicationComponent{
+			comp := common2.ApplicationComponent{
 				Name:       cueDef.Name,
 				Type:       "raw",
 				Properties: util.Object2RawExtension(&def.Unstructured),
-			})
+			}
+			if err = setDefinitionNamespace(&comp); err != nil {
+				return nil, err
+			}
+			app.Spec.Components = append(app.Spec.Components, comp)
 		}
 		for _, teml := range addon.DefSchemas {
 			u, err := renderSchemaConfigmap(teml)
@@ -728,7 +770,93 @@ func renderNamespace(namespace string) *unstructured.Unstructured {
 	return u
 }
 
+// clusterScopedKinds are the kinds of cluster-scoped resources, they are not moved by the namespace override
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                      true,
+	"CustomResourceDefinition":       true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"PersistentVolume":               true,
+	"StorageClass":                   true,
+	"PriorityClass":                  true,
+	"MutatingWebhookConfiguration":   true,
+	"ValidatingWebhookConfiguration": true,
+	"APIService":                     true,
+}
+
+// setComponentNamespace moves the resource of a raw component into the namespace if it's set, the other components
+// are installed into the namespace of the application
+func setComponentNamespace(comp *common2.ApplicationComponent, namespace string) error {
+	if namespace == "" || comp.Type != "raw" {
+		return nil
+	}
+	if comp.Properties == nil {
+		return nil
+	}
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(comp.Properties.Raw, &obj.Object); err != nil {
+		return err
+	}
+	if clusterScopedKinds[obj.GetKind()] {
+		// a cluster-scoped resource which refers to the namespace of the package, such as the subjects of a
+		// ClusterRoleBinding, will point to the wrong namespace after the other resources are moved
+		if refersToNamespace(obj.Object, types.DefaultKubeVelaNS) {
+			return errors.Wrapf(ErrAddonNamespaceConflict, "%s %s refers to namespace %s", obj.GetKind(), obj.GetName(), types.DefaultKubeVelaNS)
+		}
+		return nil
+	}
+	switch obj.GetNamespace() {
+	case "", types.DefaultKubeVelaNS:
+		obj.SetNamespace(namespace)
+	case namespace:
+	default:
+		return errors.Wrapf(ErrAddonNamespaceConflict, "%s %s hardcodes namespace %s", obj.GetKind(), obj.GetName(), obj.GetNamespace())
+	}
+	comp.Properties = util.Object2RawExtension(obj)
+	return nil
+}
+
+// refersToNamespace checks if any namespace field in the object is the namespace
+func refersToNamespace(obj interface{}, namespace string) bool {
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		for k, v := range o {
+			if ns, ok := v.(string); ok && k == "namespace" && ns == namespace {
+				return true
+			}
+			if refersToNamespace(v, namespace) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range o {
+			if refersToNamespace(v, namespace) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // renderRawComponent will return a component in raw type from string
+// setDefinitionNamespace keeps the definitions without a namespace in the namespace of the package, otherwise they
+// would follow the application into the namespace override
+func setDefinitionNamespace(comp *common2.ApplicationComponent) error {
+	if comp.Properties == nil {
+		return nil
+	}
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(comp.Properties.Raw, &obj.Object); err != nil {
+		return err
+	}
+	if obj.GetNamespace() != "" || clusterScopedKinds[obj.GetKind()] {
+		return nil
+	}
+	obj.SetNamespace(types.DefaultKubeVelaNS)
+	comp.Properties = util.Object2RawExtension(obj)
+	return nil
+}
+
 func renderRawComponent(elem ElementFile) (*common2.ApplicationComponent, error) {
 	baseRawComponent := common2.ApplicationComponent{
 		Type: "raw",
@@ -756,8 +884,126 @@ func renderSchemaConfigmap(elem ElementFile) (*unstructured.Unstructured, error)
 	return util.Object2Unstructured(cm)
 }
 
-// renderCUETemplate will return a component from cue template
-func renderCUETemplate(elem ElementFile, parameters string, args map[string]interface{}) (*common2.ApplicationComponent, error) {
+// secretArgRefKey is the only key of an argument which refers to a key of an existing secret, such as {from: "secretName/key"}
+const secretArgRefKey = "from"
+
+// renderSecretArgs returns a copy of the args in which the secret references are replaced by secretKeyRef values, such
+// as {secretKeyRef: {name: "secretName", key: "key"}}. The values in the secrets are never read, so they don't end up
+// in the addon application, the templates pass the reference to the workloads such as the valueFrom of an env.
+func renderSecretArgs(args map[string]interface{}) (map[string]interface{}, error) {
+	rendered, err := renderSecretArg(args)
+	if err != nil {
+		return nil, err
+	}
+	return rendered.(map[string]interface{}), nil
+}
+
+func renderSecretArg(arg interface{}) (interface{}, error) {
+	switch arg := arg.(type) {
+	case map[string]interface{}:
+		if ref, ok := getSecretArgRef(arg); ok {
+			name, key, err := parseSecretArgRef(ref)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": name, "key": key}}, nil
+		}
+		rendered := make(map[string]interface{}, len(arg))
+		for k, v := range arg {
+			r, err := renderSecretArg(v)
+			if err != nil {
+				return nil, err
+			}
+			rendered[k] = r
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, len(arg))
+		for i, v := range arg {
+			r, err := renderSecretArg(v)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+	default:
+		return arg, nil
+	}
+}
+
+// checkSecretArgs checks the secrets referred by the args exist with the keys in the namespace the addon is installed
+// into, where the workloads read them
+func checkSecretArgs(ctx context.Context, k8sClient client.Client, args map[string]interface{}, namespace string) error {
+	var refs []string
+	collectSecretArgRefs(args, &refs)
+	for _, ref := range refs {
+		name, key, err := parseSecretArgRef(ref)
+		if err != nil {
+			return err
+		}
+		if k8sClient == nil {
+			return errors.Errorf("no client to read the secret %s referred by addon arguments", name)
+		}
+		sec := v1.Secret{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &sec); err != nil {
+			return errors.Wrapf(err, "fail to get the secret %s/%s referred by addon arguments", namespace, name)
+		}
+		if _, ok := sec.Data[key]; !ok {
+			return errors.Errorf("key %s not found in the secret %s/%s referred by addon arguments", key, namespace, name)
+		}
+	}
+	return nil
+}
+
+func collectSecretArgRefs(arg interface{}, refs *[]string) {
+	switch arg := arg.(type) {
+	case map[string]interface{}:
+		if ref, ok := getSecretArgRef(arg); ok {
+			*refs = append(*refs, ref)
+			return
+		}
+		for _, v := range arg {
+			collectSecretArgRefs(v, refs)
+		}
+	case []interface{}:
+		for _, v := range arg {
+			collectSecretArgRefs(v, refs)
+		}
+	}
+}
+
+func getSecretArgRef(arg map[string]interface{}) (string, bool) {
+	if len(arg) != 1 {
+		return "", false
+	}
+	ref, ok := arg[secretArgRefKey].(string)
+	return ref, ok
+}
+
+// parseSecretArgRef splits the reference in the format of secretName/key
+func parseSecretArgRef(ref string) (string, string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.Wrapf(ErrInvalidSecretArgRef, "reference %q", ref)
+	}
+	return parts[0], parts[1], nil
+}
+
+// parseStoredArg reads back an argument stored by RenderArgsSecret, the secret references are stored as JSON objects
+func parseStoredArg(value string) interface{} {
+	arg := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(value), &arg); err == nil {
+		if _, ok := getSecretArgRef(arg); ok {
+			return arg
+		}
+	}
+	return value
+}
+
+// renderCUETemplate will return a component from cue template, the namespace the addon is installed into is passed
+// to the template as context.namespace
+func renderCUETemplate(elem ElementFile, parameters string, args map[string]interface{}, namespace string) (*common2.ApplicationComponent, error) {
 	bt, err := json.Marshal(args)
 	if err != nil {
 		return nil, err
@@ -766,7 +1012,12 @@ func renderCUETemplate(elem ElementFile, parameters string, args map[string]inte
 	if string(bt) != "null" {
 		paramFile = fmt.Sprintf("%s: %s", cuemodel.ParameterFieldName, string(bt))
 	}
-	param := fmt.Sprintf("%s\n%s", paramFile, parameters)
+	ns, err := json.Marshal(namespace)
+	if err != nil {
+		return nil, err
+	}
+	contextFile := fmt.Sprintf("context: %s: %s", cuemodel.ContextNamespace, string(ns))
+	param := fmt.Sprintf("%s\n%s\n%s", paramFile, contextFile, parameters)
 	v, err := value.NewValue(param, nil, "")
 	if err != nil {
 		return nil, err
@@ -798,7 +1049,7 @@ func renderCUETemplate(elem ElementFile, parameters string, args map[string]inte
 const addonAppPrefix = "addon-"
 const addonSecPrefix = "addon-secret-"
 
-// Convert2AppName -
+// Convert2AppName generate the name of the application which installs the addon
 func Convert2AppName(name string) string {
 	return addonAppPrefix + name
 }
@@ -810,6 +1061,17 @@ func RenderArgsSecret(addon *InstallPackage, args map[string]interface{}) *unstr
 		switch v := v.(type) {
 		case bool:
 			data[k] = strconv.FormatBool(v)
+		case map[string]interface{}:
+			// keep the secret references in the form parseStoredArg reads back
+			if _, ok := getSecretArgRef(v); ok {
+				ref, err := json.Marshal(v)
+				if err != nil {
+					return nil
+				}
+				data[k] = string(ref)
+				continue
+			}
+			data[k] = fmt.Sprintf("%v", v)
 		default:
 			data[k] = fmt.Sprintf("%v", v)
 		}
@@ -846,24 +1108,55 @@ type Installer struct {
 	registryMeta map[string]SourceMeta
 	args         map[string]interface{}
 	cache        *Cache
+	// namespace overrides the namespace of the addon resources, the namespace of the package is used if it's empty
+	namespace string
+	// skipDependencyInstall requires the dependencies to be enabled before instead of enabling them from the registry
+	skipDependencyInstall bool
+	// imageRegistry replaces the registry of the images in the addon components, the images are kept if it's empty
+	imageRegistry string
 }
 
-// NewAddonInstaller will create an installer for addon
-func NewAddonInstaller(ctx context.Context, cli client.Client, apply apply.Applicator, config *rest.Config, r *Registry, args map[string]interface{}, cache *Cache) Installer {
+// NewAddonInstaller will create an installer for addon, namespace overrides where the addon resources are installed
+func NewAddonInstaller(ctx context.Context, cli client.Client, apply apply.Applicator, config *rest.Config, r *Registry, args map[string]interface{}, cache *Cache, namespace string) Installer {
 	return Installer{
-		ctx:    ctx,
-		config: config,
-		cli:    cli,
-		apply:  apply,
-		r:      r,
-		args:   args,
-		cache:  cache,
+		ctx:       ctx,
+		config:    config,
+		cli:       cli,
+		apply:     apply,
+		r:         r,
+		args:      args,
+		cache:     cache,
+		namespace: namespace,
 	}
 }
 
+// SkipDependencyInstall makes the installer fail with a MissingPrerequisitesError if any dependency is not enabled,
+// instead of enabling the dependencies from the registry
+func (h *Installer) SkipDependencyInstall() {
+	h.skipDependencyInstall = true
+}
+
+// SetImageRegistry makes the installer replace the registry of the images in the addon components with the registry,
+// such as a private registry mirroring the images. The dependencies enabled by the installer use it too.
+func (h *Installer) SetImageRegistry(registry string) {
+	h.imageRegistry = registry
+}
+
 func (h *Installer) enableAddon(addon *InstallPackage) error {
 	var err error
 	h.addon = addon
+	if err = h.loadNamespaceOverride(); err != nil {
+		return err
+	}
+	if err = ValidateAddonArgs(addon, h.args); err != nil {
+		return err
+	}
+	if err = ValidateImageRegistry(h.imageRegistry); err != nil {
+		return err
+	}
+	if err = h.checkPrerequisites(addon); err != nil {
+		return err
+	}
 	if err = h.installDependency(addon); err != nil {
 		return err
 	}
@@ -878,6 +1171,38 @@ func (h *Installer) enableAddon(addon *InstallPackage) error {
 	return nil
 }
 
+// loadNamespaceOverride keeps the namespace override saved in the args secret if the addon is upgraded or enabled again
+// without a namespace, giving the namespace of the package resets the override. The application of an enabled addon
+// can't be moved, so the addon must be disabled before it's installed into another namespace.
+func (h *Installer) loadNamespaceOverride() error {
+	if h.namespace == "" {
+		sec := v1.Secret{}
+		err := h.cli.Get(h.ctx, client.ObjectKey{Namespace: types.DefaultKubeVelaNS, Name: Convert2SecName(h.addon.Name)}, &sec)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		h.namespace = sec.Annotations[oam.AnnotationAddonNamespace]
+	}
+	if h.namespace == types.DefaultKubeVelaNS {
+		h.namespace = ""
+	}
+	app, err := FetchAddonRelatedApp(h.ctx, h.cli, h.addon.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	namespace := h.namespace
+	if namespace == "" {
+		namespace = types.DefaultKubeVelaNS
+	}
+	if app.Namespace != namespace {
+		return errors.Wrapf(ErrAddonNamespaceConflict, "addon %s is installed into namespace %s, disable it before installing it into namespace %s", h.addon.Name, app.Namespace, namespace)
+	}
+	return nil
+}
+
 func (h *Installer) loadInstallPackage(name string) (*InstallPackage, error) {
 	metas, err := h.getAddonMeta()
 	if err != nil {
@@ -911,26 +1236,114 @@ func (h *Installer) getAddonMeta() (map[string]SourceMeta, error) {
 	return h.registryMeta, nil
 }
 
+// checkPrerequisites checks the dependencies and the CRDs required by the addon before anything is applied,
+// all the missing ones are reported together by a MissingPrerequisitesError
+func (h *Installer) checkPrerequisites(addon *InstallPackage) error {
+	missing := &MissingPrerequisitesError{Addon: addon.Name}
+	for _, dep := range addon.Dependencies {
+		_, err := FetchAddonRelatedApp(h.ctx, h.cli, dep.Name)
+		if err == nil {
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		// the dependency will be installed from the registry if it's found there
+		if h.r != nil && !h.skipDependencyInstall {
+			metas, err := h.getAddonMeta()
+			if err != nil {
+				return errors.Wrap(err, "fail to get addon meta")
+			}
+			if _, ok := metas[dep.Name]; ok {
+				continue
+			}
+		}
+		missing.Dependencies = append(missing.Dependencies, dep.Name)
+	}
+	if len(addon.RequiredCRDs) != 0 {
+		dc, err := discovery.NewDiscoveryClientForConfig(h.config)
+		if err != nil {
+			return err
+		}
+		if missing.CRDs, err = findMissingCRDs(dc, addon.RequiredCRDs); err != nil {
+			return err
+		}
+	}
+	if len(missing.Dependencies) != 0 || len(missing.CRDs) != 0 {
+		return missing
+	}
+	return nil
+}
+
+// findMissingCRDs returns the CRDs which are not served by the cluster, the CRDs are in the format of <plural>.<group>
+func findMissingCRDs(dc discovery.DiscoveryInterface, crds []string) ([]string, error) {
+	groups, err := dc.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, crd := range crds {
+		plural, group := crd, ""
+		if i := strings.Index(crd, "."); i >= 0 {
+			plural, group = crd[:i], crd[i+1:]
+		}
+		served, err := isResourceServed(dc, groups, group, plural)
+		if err != nil {
+			return nil, err
+		}
+		if !served {
+			missing = append(missing, crd)
+		}
+	}
+	return missing, nil
+}
+
+// isResourceServed checks if the resource is served by any version of the group
+func isResourceServed(dc discovery.DiscoveryInterface, groups *metav1.APIGroupList, group, plural string) (bool, error) {
+	for _, g := range groups.Groups {
+		if g.Name != group {
+			continue
+		}
+		for _, version := range g.Versions {
+			resources, err := dc.ServerResourcesForGroupVersion(version.GroupVersion)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return false, err
+			}
+			for _, resource := range resources.APIResources {
+				if resource.Name == plural {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
 // installDependency checks if addon's dependency and install it
 func (h *Installer) installDependency(addon *InstallPackage) error {
-	var app v1beta1.Application
 	for _, dep := range addon.Dependencies {
-		err := h.cli.Get(h.ctx, client.ObjectKey{
-			Namespace: types.DefaultKubeVelaNS,
-			Name:      Convert2AppName(dep.Name),
-		}, &app)
+		_, err := FetchAddonRelatedApp(h.ctx, h.cli, dep.Name)
 		if err == nil {
 			continue
 		}
 		if !apierrors.IsNotFound(err) {
 			return err
 		}
+		// the addon isn't installed from a registry, so the dependency can't be found
+		if h.r == nil || h.skipDependencyInstall {
+			return errors.Errorf("dependent addon %s is not enabled, please enable it first", dep.Name)
+		}
 		depAddon, err := h.loadInstallPackage(dep.Name)
 		if err != nil {
 			return err
 		}
 		depHandler := *h
 		depHandler.args = nil
+		// the dependencies are shared by other addons, so they are installed into their own namespace
+		depHandler.namespace = ""
 		if err = depHandler.enableAddon(depAddon); err != nil {
 			return errors.Wrap(err, "fail to dispatch dependent addon resource")
 		}
@@ -939,16 +1352,20 @@ func (h *Installer) installDependency(addon *InstallPackage) error {
 }
 
 func (h *Installer) dispatchAddonResource(addon *InstallPackage) error {
-	app, err := RenderApp(h.ctx, addon, h.config, h.cli, h.args)
+	app, err := renderApp(h.ctx, addon, h.config, h.cli, h.args, h.namespace)
 	if err != nil {
 		return errors.Wrap(err, "render addon application fail")
 	}
+	if err = rewriteAppImages(app, h.imageRegistry); err != nil {
+		return err
+	}
 
 	appName, err := determineAddonAppName(h.ctx, h.cli, h.addon.Name)
 	if err != nil {
 		return err
 	}
 	app.Name = appName
+	setAddonSourceAnnotations(app, h.r, addon.Version)
 
 	defs, err := RenderDefinitions(h.addon, h.config)
 	if err != nil {
@@ -960,6 +1377,13 @@ func (h *Installer) dispatchAddonResource(addon *InstallPackage) error {
 		return errors.Wrap(err, "render addon definitions' schema fail")
 	}
 
+	// the namespace override isn't a component of the application, so it's kept when the addon is disabled
+	if app.Namespace != types.DefaultKubeVelaNS {
+		if err = h.apply.Apply(h.ctx, renderNamespace(app.Namespace)); err != nil {
+			return errors.Wrapf(err, "fail to create namespace %s", app.Namespace)
+		}
+	}
+
 	err = h.apply.Apply(h.ctx, app)
 	if err != nil {
 		klog.Errorf("fail to create application: %v", err)
@@ -982,8 +1406,12 @@ func (h *Installer) dispatchAddonResource(addon *InstallPackage) error {
 		}
 	}
 
-	if h.args != nil && len(h.args) > 0 {
+	// the args secret records the namespace override too, so it's kept when the addon is upgraded or enabled again
+	if len(h.args) > 0 || h.namespace != "" {
 		sec := RenderArgsSecret(addon, h.args)
+		if h.namespace != "" {
+			sec.SetAnnotations(util.MergeMapOverrideWithDst(sec.GetAnnotations(), map[string]string{oam.AnnotationAddonNamespace: h.namespace}))
+		}
 		addOwner(sec, app)
 		err = h.apply.Apply(h.ctx, sec)
 		if err != nil {
@@ -1025,7 +1453,22 @@ func (h *Installer) continueOrRestartWorkflow() error {
 	return nil
 }
 
+// setAddonSourceAnnotations records the registry and the version of the addon on its application,
+// the registry is empty if the addon is enabled from a local directory
+func setAddonSourceAnnotations(app *v1beta1.Application, r *Registry, version string) {
+	annotations := map[string]string{oam.AnnotationAddonVersion: version}
+	if r != nil {
+		annotations[oam.AnnotationAddonRegistry] = r.Name
+	}
+	app.Annotations = util.MergeMapOverrideWithDst(app.Annotations, annotations)
+}
+
+// addOwner makes the application own the child, the children in another namespace aren't owned since an owner
+// reference can't point to another namespace
 func addOwner(child *unstructured.Unstructured, app *v1beta1.Application) {
+	if child.GetNamespace() != app.Namespace {
+		return
+	}
 	child.SetOwnerReferences(append(child.GetOwnerReferences(),
 		*metav1.NewControllerRef(app, v1beta1.ApplicationKindVersionKind)))
 }
@@ -1044,7 +1487,8 @@ func determineAddonAppName(ctx context.Context, cli client.Client, addonName str
 }
 
 // FetchAddonRelatedApp will fetch the addon related app, this func will use NamespacedName(vela-system, addon-addonName) to get app
-// if not find will try to get 1.1 legacy addon related app by using NamespacedName(vela-system, `addonName`)
+// if not find will try to get 1.1 legacy addon related app by using NamespacedName(vela-system, `addonName`), and then
+// the app installed into a namespace override, which is found by the addon name label
 func FetchAddonRelatedApp(ctx context.Context, cli client.Client, addonName string) (*v1beta1.Application, error) {
 	app := &v1beta1.Application{}
 	if err := cli.Get(ctx, types2.NamespacedName{Namespace: types.DefaultKubeVelaNS, Name: Convert2AppName(addonName)}, app); err != nil {
@@ -1052,9 +1496,23 @@ func FetchAddonRelatedApp(ctx context.Context, cli client.Client, addonName stri
 			return nil, err
 		}
 		// for 1.1 addon app compatibility code
-		if err := cli.Get(ctx, types2.NamespacedName{Namespace: types.DefaultKubeVelaNS, Name: addonName}, app); err != nil {
+		err := cli.Get(ctx, types2.NamespacedName{Namespace: types.DefaultKubeVelaNS, Name: addonName}, app)
+		if err == nil {
+			return app, nil
+		}
+		if !apierrors.IsNotFound(err) {
 			return nil, err
 		}
+		apps := &v1beta1.ApplicationList{}
+		if listErr := cli.List(ctx, apps, client.MatchingLabels{oam.LabelAddonName: addonName}); listErr != nil {
+			return nil, listErr
+		}
+		for i := range apps.Items {
+			if apps.Items[i].Name == Convert2AppName(addonName) {
+				return &apps.Items[i], nil
+			}
+		}
+		return nil, err
 	}
 	return app, nil
 }
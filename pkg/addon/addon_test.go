@@ -20,12 +20,14 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	"github.com/google/go-github/v32/github"
@@ -34,14 +36,19 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/condition"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/oam"
 )
 
 var paths = []string{
@@ -226,6 +233,120 @@ func TestRenderApp(t *testing.T) {
 	assert.Equal(t, len(app.Spec.Components), 2)
 }
 
+func TestSetComponentNamespace(t *testing.T) {
+	rawComp := func(obj string) *common.ApplicationComponent {
+		return &common.ApplicationComponent{Name: "res", Type: "raw", Properties: &runtime.RawExtension{Raw: []byte(obj)}}
+	}
+
+	comp := rawComp(`{"apiVersion":"v1","kind":"ServiceAccount","metadata":{"name":"sa","namespace":"vela-system"}}`)
+	assert.NoError(t, setComponentNamespace(comp, "tenant-a"))
+	assert.Contains(t, string(comp.Properties.Raw), `"namespace":"tenant-a"`)
+
+	comp = rawComp(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"}}`)
+	assert.NoError(t, setComponentNamespace(comp, "tenant-a"))
+	assert.Contains(t, string(comp.Properties.Raw), `"namespace":"tenant-a"`)
+
+	comp = rawComp(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"}}`)
+	assert.NoError(t, setComponentNamespace(comp, ""))
+	assert.NotContains(t, string(comp.Properties.Raw), "namespace")
+
+	comp = rawComp(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm","namespace":"kube-system"}}`)
+	assert.ErrorIs(t, setComponentNamespace(comp, "tenant-a"), ErrAddonNamespaceConflict)
+
+	comp = rawComp(`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRole","metadata":{"name":"role"}}`)
+	assert.NoError(t, setComponentNamespace(comp, "tenant-a"))
+	assert.NotContains(t, string(comp.Properties.Raw), "namespace")
+
+	comp = rawComp(`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRoleBinding","metadata":{"name":"binding"},"subjects":[{"kind":"ServiceAccount","name":"sa","namespace":"vela-system"}]}`)
+	assert.ErrorIs(t, setComponentNamespace(comp, "tenant-a"), ErrAddonNamespaceConflict)
+
+	// the components rendered from the CUE templates follow the namespace of the application
+	comp = &common.ApplicationComponent{Name: "web", Type: "webservice"}
+	assert.NoError(t, setComponentNamespace(comp, "tenant-a"))
+	assert.Nil(t, comp.Properties)
+}
+
+func TestRenderAppWithNamespace(t *testing.T) {
+	addon := baseAddon
+	addon.CUETemplates = []ElementFile{{Name: "controller.cue", Data: `output: {
+	type: "webservice"
+	properties: {
+		image: "controller"
+		env: [{name: "NAMESPACE", value: context.namespace}]
+	}
+}`}}
+	app, err := renderApp(ctx, &addon, nil, nil, map[string]interface{}{}, "tenant-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant-a", app.Namespace)
+	assert.Equal(t, 3, len(app.Spec.Components))
+	assert.Equal(t, "test-ns-namespace", app.Spec.Components[0].Name)
+	assert.Equal(t, "webservice", app.Spec.Components[1].Type)
+	assert.Contains(t, string(app.Spec.Components[1].Properties.Raw), `{"name":"NAMESPACE","value":"tenant-a"}`)
+	// the definitions stay in the namespace of the package
+	assert.Contains(t, string(app.Spec.Components[2].Properties.Raw), `"namespace":"vela-system"`)
+
+	app, err = RenderApp(ctx, &addon, nil, nil, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, types.DefaultKubeVelaNS, app.Namespace)
+	assert.Contains(t, string(app.Spec.Components[1].Properties.Raw), `{"name":"NAMESPACE","value":"vela-system"}`)
+}
+
+func TestLoadNamespaceOverride(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	app := &v1beta1.Application{ObjectMeta: metav1.ObjectMeta{
+		Name:      Convert2AppName("fluxcd"),
+		Namespace: "tenant-a",
+		Labels:    map[string]string{oam.LabelAddonName: "fluxcd"},
+	}}
+	sec := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        Convert2SecName("fluxcd"),
+		Namespace:   types.DefaultKubeVelaNS,
+		Annotations: map[string]string{oam.AnnotationAddonNamespace: "tenant-a"},
+	}}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(app, sec).Build()
+
+	found, err := FetchAddonRelatedApp(context.Background(), k8sClient, "fluxcd")
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant-a", found.Namespace)
+	_, err = FetchAddonRelatedApp(context.Background(), k8sClient, "velaux")
+	assert.True(t, errors.IsNotFound(err))
+
+	// the saved override is kept if no namespace is given
+	h := NewAddonInstaller(context.Background(), k8sClient, nil, nil, nil, nil, nil, "")
+	h.addon = &InstallPackage{Meta: Meta{Name: "fluxcd"}}
+	assert.NoError(t, h.loadNamespaceOverride())
+	assert.Equal(t, "tenant-a", h.namespace)
+
+	// the application can't be moved to another namespace
+	h.namespace = types.DefaultKubeVelaNS
+	assert.ErrorIs(t, h.loadNamespaceOverride(), ErrAddonNamespaceConflict)
+	h.namespace = "tenant-b"
+	assert.ErrorIs(t, h.loadNamespaceOverride(), ErrAddonNamespaceConflict)
+
+	// the args secret is deleted with the application, so the addon can be installed into another namespace then
+	assert.NoError(t, DisableAddon(context.Background(), k8sClient, "fluxcd"))
+	assert.True(t, errors.IsNotFound(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(sec), &corev1.Secret{})))
+	h.namespace = types.DefaultKubeVelaNS
+	assert.NoError(t, h.loadNamespaceOverride())
+	assert.Equal(t, "", h.namespace)
+}
+
+func TestAddOwner(t *testing.T) {
+	app := &v1beta1.Application{ObjectMeta: metav1.ObjectMeta{Name: "addon-fluxcd", Namespace: "tenant-a"}}
+	sec := RenderArgsSecret(&InstallPackage{Meta: Meta{Name: "fluxcd"}}, map[string]interface{}{"replicas": 1})
+	addOwner(sec, app)
+	assert.Empty(t, sec.GetOwnerReferences())
+	app.Namespace = types.DefaultKubeVelaNS
+	addOwner(sec, app)
+	assert.Equal(t, 1, len(sec.GetOwnerReferences()))
+}
+
+func TestConvert2AppName(t *testing.T) {
+	assert.Equal(t, "addon-fluxcd", Convert2AppName("fluxcd"))
+}
+
 func TestRenderDeploy2RuntimeAddon(t *testing.T) {
 	addonDeployToRuntime := baseAddon
 	addonDeployToRuntime.Meta.DeployTo = &DeployTo{
@@ -267,6 +388,18 @@ func TestGetAddonStatus(t *testing.T) {
 			app := &v1beta1.Application{}
 			app.Status.Phase = common.ApplicationDeleting
 			*o = *app
+		case "addon-unhealthy":
+			o := obj.(*v1beta1.Application)
+			app := &v1beta1.Application{}
+			app.Status.Phase = common.ApplicationUnhealthy
+			app.Status.Services = []common.ApplicationComponentStatus{{Name: "web", Healthy: false, Message: "pod crash"}}
+			*o = *app
+		case "addon-render-failed":
+			o := obj.(*v1beta1.Application)
+			app := &v1beta1.Application{}
+			app.Status.Phase = common.ApplicationRendering
+			app.Status.SetConditions(condition.ErrorCondition("Parsed", fmt.Errorf("invalid template")))
+			*o = *app
 		default:
 			o := obj.(*v1beta1.Application)
 			app := &v1beta1.Application{}
@@ -277,27 +410,35 @@ func TestGetAddonStatus(t *testing.T) {
 	})
 
 	cli := test.MockClient{
-		MockGet: getFunc,
+		MockGet:  getFunc,
+		MockList: test.NewMockListFn(nil),
 	}
 
 	cases := []struct {
-		name         string
-		expectStatus string
+		name          string
+		expectStatus  AddonPhase
+		expectMessage string
 	}{
 		{
-			name: "disabled", expectStatus: "disabled",
+			name: "disabled", expectStatus: AddonPhaseDisabled,
 		},
 		{
-			name: "suspend", expectStatus: "suspend",
+			name: "suspend", expectStatus: AddonPhaseSuspend,
 		},
 		{
-			name: "enabled", expectStatus: "enabled",
+			name: "enabled", expectStatus: AddonPhaseEnabled,
 		},
 		{
-			name: "disabling", expectStatus: "disabling",
+			name: "disabling", expectStatus: AddonPhaseDisabling,
 		},
 		{
-			name: "enabling", expectStatus: "enabling",
+			name: "enabling", expectStatus: AddonPhaseEnabling,
+		},
+		{
+			name: "unhealthy", expectStatus: AddonPhaseFailed, expectMessage: "component web is unhealthy: pod crash",
+		},
+		{
+			name: "render-failed", expectStatus: AddonPhaseFailed, expectMessage: "invalid template",
 		},
 	}
 
@@ -305,7 +446,362 @@ func TestGetAddonStatus(t *testing.T) {
 		addonStatus, err := GetAddonStatus(context.Background(), &cli, s.name)
 		assert.NoError(t, err)
 		assert.Equal(t, addonStatus.AddonPhase, s.expectStatus)
+		assert.Equal(t, addonStatus.Message, s.expectMessage)
+	}
+}
+
+func TestListAddonStatuses(t *testing.T) {
+	cli := test.MockClient{
+		MockList: func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+			running := v1beta1.Application{}
+			running.Name = "addon-fluxcd"
+			running.Labels = map[string]string{oam.LabelAddonName: "fluxcd"}
+			running.Status.Phase = common.ApplicationRunning
+			unhealthy := v1beta1.Application{}
+			unhealthy.Name = "addon-velaux"
+			unhealthy.Labels = map[string]string{oam.LabelAddonName: "velaux"}
+			unhealthy.Status.Phase = common.ApplicationUnhealthy
+			unhealthy.Status.Services = []common.ApplicationComponentStatus{{Name: "apiserver", Healthy: false, Message: "pod crash"}}
+			list.(*v1beta1.ApplicationList).Items = []v1beta1.Application{running, unhealthy}
+			return nil
+		},
+	}
+	statuses, err := ListAddonStatuses(context.Background(), &cli)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(statuses))
+	assert.Equal(t, AddonPhaseEnabled, statuses["fluxcd"].AddonPhase)
+	assert.Equal(t, AddonPhaseFailed, statuses["velaux"].AddonPhase)
+	assert.Equal(t, "component apiserver is unhealthy: pod crash", statuses["velaux"].Message)
+}
+
+func TestRenderSecretArgs(t *testing.T) {
+	sec := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: types.DefaultKubeVelaNS},
+		Data:       map[string][]byte{"accessKey": []byte("ak-value")},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(sec).Build()
+	args := map[string]interface{}{
+		"region":    "cn-hangzhou",
+		"accessKey": map[string]interface{}{"from": "cloud-credentials/accessKey"},
+		"nested":    map[string]interface{}{"keys": []interface{}{map[string]interface{}{"from": "cloud-credentials/accessKey"}}},
 	}
+	keyRef := map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "cloud-credentials", "key": "accessKey"}}
+	rendered, err := renderSecretArgs(args)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"region":    "cn-hangzhou",
+		"accessKey": keyRef,
+		"nested":    map[string]interface{}{"keys": []interface{}{keyRef}},
+	}, rendered)
+	// the args are not changed so that the persisted arguments keep the references
+	assert.Equal(t, map[string]interface{}{"from": "cloud-credentials/accessKey"}, args["accessKey"])
+
+	assert.NoError(t, checkSecretArgs(context.Background(), k8sClient, args, types.DefaultKubeVelaNS))
+	// the secrets are read from the namespace the addon is installed into
+	assert.Error(t, checkSecretArgs(context.Background(), k8sClient, args, "tenant-a"))
+	assert.ErrorIs(t, checkSecretArgs(context.Background(), k8sClient, map[string]interface{}{"key": map[string]interface{}{"from": "cloud-credentials"}}, types.DefaultKubeVelaNS), ErrInvalidSecretArgRef)
+	assert.Error(t, checkSecretArgs(context.Background(), k8sClient, map[string]interface{}{"key": map[string]interface{}{"from": "cloud-credentials/secretKey"}}, types.DefaultKubeVelaNS))
+
+	sec2 := RenderArgsSecret(&InstallPackage{Meta: Meta{Name: "cloud"}}, args)
+	data, _, _ := unstructured.NestedStringMap(sec2.Object, "stringData")
+	assert.Equal(t, args["accessKey"], parseStoredArg(data["accessKey"]))
+	assert.Equal(t, "cn-hangzhou", parseStoredArg(data["region"]))
+
+	addon := &InstallPackage{
+		Meta:       Meta{Name: "cloud"},
+		Parameters: `parameter: accessKey: secretKeyRef: {name: string, key: string}`,
+		CUETemplates: []ElementFile{{Name: "controller.cue", Data: `output: {
+	type: "webservice"
+	properties: {
+		image: "controller"
+		env: [{name: "ACCESS_KEY", valueFrom: parameter.accessKey}]
+	}
+}`}},
+	}
+	app, err := RenderApp(ctx, addon, nil, k8sClient, map[string]interface{}{"accessKey": args["accessKey"]})
+	assert.NoError(t, err)
+	appJSON, err := json.Marshal(app)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(appJSON), "ak-value")
+	assert.Contains(t, string(appJSON), `"valueFrom":{"secretKeyRef":{"key":"accessKey","name":"cloud-credentials"}}`)
+}
+
+func TestAddonSourceInStatus(t *testing.T) {
+	app := &v1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "addon-fluxcd", Namespace: types.DefaultKubeVelaNS},
+		Status:     common.AppStatus{Phase: common.ApplicationRunning},
+	}
+	setAddonSourceAnnotations(app, &Registry{Name: "KubeVela"}, "1.1.0")
+	status := getAddonStatusFromApp(context.Background(), nil, "fluxcd", app)
+	assert.Equal(t, AddonPhaseEnabled, status.AddonPhase)
+	assert.Equal(t, "1.1.0", status.InstalledVersion)
+	assert.Equal(t, "KubeVela", status.RegistryName)
+
+	// the addon enabled from a local directory has no registry
+	app.Annotations = nil
+	setAddonSourceAnnotations(app, nil, "1.2.0")
+	status = getAddonStatusFromApp(context.Background(), nil, "fluxcd", app)
+	assert.Equal(t, "1.2.0", status.InstalledVersion)
+	assert.Equal(t, "", status.RegistryName)
+}
+
+func TestFindMissingCRDs(t *testing.T) {
+	dc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "helm.toolkit.fluxcd.io/v2beta1",
+		APIResources: []metav1.APIResource{{Name: "helmreleases", Kind: "HelmRelease"}},
+	}, {
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap"}},
+	}}}}
+	missing, err := findMissingCRDs(dc, []string{"helmreleases.helm.toolkit.fluxcd.io", "helmrepositories.source.toolkit.fluxcd.io", "helmcharts.helm.toolkit.fluxcd.io"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"helmrepositories.source.toolkit.fluxcd.io", "helmcharts.helm.toolkit.fluxcd.io"}, missing)
+}
+
+func TestCheckPrerequisites(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+	enabled := &v1beta1.Application{ObjectMeta: metav1.ObjectMeta{Name: Convert2AppName("fluxcd"), Namespace: types.DefaultKubeVelaNS}}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(enabled).Build()
+	// the addon is enabled from a local directory, so the dependencies can't be installed from a registry
+	h := Installer{ctx: context.Background(), cli: k8sClient}
+	addon := &InstallPackage{Meta: Meta{Name: "velaux", Dependencies: []*Dependency{{Name: "fluxcd"}, {Name: "terraform"}, {Name: "ocm"}}}}
+	err := h.checkPrerequisites(addon)
+	var missing *MissingPrerequisitesError
+	assert.ErrorAs(t, err, &missing)
+	assert.Equal(t, []string{"terraform", "ocm"}, missing.Dependencies)
+	assert.Equal(t, "addon velaux can't be enabled, missing dependent addons [terraform, ocm]", err.Error())
+
+	addon.Dependencies = addon.Dependencies[:1]
+	assert.NoError(t, h.checkPrerequisites(addon))
+
+	// the dependencies found in the registry are installed unless skipDependencyInstall is set
+	addon.Dependencies = []*Dependency{{Name: "fluxcd"}, {Name: "terraform"}}
+	h = Installer{ctx: context.Background(), cli: k8sClient, r: &Registry{}, registryMeta: map[string]SourceMeta{"terraform": {Name: "terraform"}}}
+	assert.NoError(t, h.checkPrerequisites(addon))
+	h.SkipDependencyInstall()
+	err = h.checkPrerequisites(addon)
+	assert.ErrorAs(t, err, &missing)
+	assert.Equal(t, []string{"terraform"}, missing.Dependencies)
+}
+
+func TestValidateAddonArgs(t *testing.T) {
+	addon := &InstallPackage{Meta: Meta{Name: "example"}}
+	assert.NoError(t, ValidateAddonArgs(addon, map[string]interface{}{"image": 1}))
+
+	addon.Parameters = `parameter: {
+	image:    string
+	replicas: *1 | int
+	dbURL?:   string
+	labels?: [string]: string
+	resources?: {
+		cpu: string
+	}
+}`
+	assert.NoError(t, ValidateAddonArgs(addon, map[string]interface{}{
+		"image":    "nginx",
+		"replicas": float64(2),
+		"dbURL":    map[string]interface{}{"from": "db-secret/url"},
+		"labels":   map[string]interface{}{"team": "platform"},
+	}))
+	err := ValidateAddonArgs(addon, map[string]interface{}{
+		"image":     "nginx",
+		"replica":   3,
+		"replicas":  1.5,
+		"labels":    map[string]interface{}{"team": 1},
+		"resources": map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+	})
+	var invalid *InvalidArgsError
+	assert.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{"replica", "resources.memory"}, invalid.UnknownKeys)
+	assert.Equal(t, []ArgTypeMismatch{
+		{Key: "labels.team", Expected: "string", Actual: "number"},
+		{Key: "replicas", Expected: "integer", Actual: "number"},
+	}, invalid.TypeMismatches)
+	assert.Equal(t, "invalid arguments of addon example, unknown keys [replica, resources.memory] and type mismatches "+
+		"[labels.team: expected string but got number, replicas: expected integer but got number]", err.Error())
+}
+
+func TestGetParameterDefaults(t *testing.T) {
+	defaults, err := getParameterDefaults("example", "")
+	assert.NoError(t, err)
+	assert.Empty(t, defaults)
+
+	defaults, err = getParameterDefaults("example", `parameter: {
+	image:    *"nginx" | string
+	replicas: *1 | int
+	dbURL?:   string
+	resources: {
+		cpu:    *"500m" | string
+		memory: string
+	}
+	labels?: [string]: string
+}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"image":     "nginx",
+		"replicas":  float64(1),
+		"resources": map[string]interface{}{"cpu": "500m"},
+	}, defaults)
+}
+
+func TestValidateImageRegistry(t *testing.T) {
+	for _, registry := range []string{"", "registry.example.com", "registry.example.com:5000/mirror", "localhost:5000", "10.0.0.1/a/b-c"} {
+		assert.NoError(t, ValidateImageRegistry(registry), registry)
+	}
+	for _, registry := range []string{"https://registry.example.com", "registry.example.com/", "registry.example.com/Mirror", "-registry", "registry:port"} {
+		assert.ErrorIs(t, ValidateImageRegistry(registry), ErrInvalidImageRegistry, registry)
+	}
+}
+
+func TestRewriteAppImages(t *testing.T) {
+	assert.Equal(t, "mirror.io/nginx:1.21", replaceImageRegistry("nginx:1.21", "mirror.io"))
+	assert.Equal(t, "mirror.io/oamdev/vela-core:v1.2.0", replaceImageRegistry("oamdev/vela-core:v1.2.0", "mirror.io"))
+	assert.Equal(t, "mirror.io/fluxcd/helm-controller:v0.1", replaceImageRegistry("ghcr.io/fluxcd/helm-controller:v0.1", "mirror.io"))
+	assert.Equal(t, "mirror.io/busybox@sha256:abc", replaceImageRegistry("localhost:5000/busybox@sha256:abc", "mirror.io"))
+
+	app := &v1beta1.Application{Spec: v1beta1.ApplicationSpec{Components: []common.ApplicationComponent{{
+		Name:       "web",
+		Type:       "webservice",
+		Properties: &runtime.RawExtension{Raw: []byte(`{"image":"oamdev/web:v1","port":80}`)},
+		Traits: []common.ApplicationTrait{
+			{Type: "sidecar", Properties: &runtime.RawExtension{Raw: []byte(`{"name":"log","image":"quay.io/fluentd:v1"}`)}},
+			{Type: "scaler", Properties: &runtime.RawExtension{Raw: []byte(`{"replicas":2}`)}},
+		},
+	}, {
+		Name: "controller",
+		Type: "raw",
+		Properties: &runtime.RawExtension{Raw: []byte(`{"kind":"Deployment","spec":{"template":{"spec":{` +
+			`"initContainers":[{"name":"init","image":"busybox"}],"containers":[{"name":"main","image":"ghcr.io/fluxcd/source-controller:v0.1"}]}}}}`)},
+	}, {
+		Name: "namespace",
+		Type: "raw",
+	}}}}
+	unchanged := app.Spec.Components[0].Traits[1].Properties
+	assert.NoError(t, rewriteAppImages(app, "registry.example.com/mirror"))
+	assert.JSONEq(t, `{"image":"registry.example.com/mirror/oamdev/web:v1","port":80}`, string(app.Spec.Components[0].Properties.Raw))
+	assert.JSONEq(t, `{"name":"log","image":"registry.example.com/mirror/fluentd:v1"}`, string(app.Spec.Components[0].Traits[0].Properties.Raw))
+	assert.Same(t, unchanged, app.Spec.Components[0].Traits[1].Properties)
+	assert.JSONEq(t, `{"kind":"Deployment","spec":{"template":{"spec":{"initContainers":[{"name":"init","image":"registry.example.com/mirror/busybox"}],`+
+		`"containers":[{"name":"main","image":"registry.example.com/mirror/fluxcd/source-controller:v0.1"}]}}}}`, string(app.Spec.Components[1].Properties.Raw))
+	assert.Nil(t, app.Spec.Components[2].Properties)
+
+	// the images are kept if there is no override
+	assert.NoError(t, rewriteAppImages(app, ""))
+	assert.JSONEq(t, `{"image":"registry.example.com/mirror/oamdev/web:v1","port":80}`, string(app.Spec.Components[0].Properties.Raw))
+}
+
+func TestWatchAddonStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+	app := &v1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: Convert2AppName("fluxcd"), Namespace: types.DefaultKubeVelaNS},
+		Status:     common.AppStatus{Phase: common.ApplicationRunningWorkflow},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(app).Build()
+	ctx, cancel := context.WithCancel(context.Background())
+	statuses, err := WatchAddonStatus(ctx, k8sClient, "fluxcd")
+	assert.NoError(t, err)
+	receive := func() (Status, bool) {
+		select {
+		case status, ok := <-statuses:
+			return status, ok
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the addon status")
+			return Status{}, false
+		}
+	}
+	status, _ := receive()
+	assert.Equal(t, AddonPhaseEnabling, status.AddonPhase)
+
+	// the changes not affecting the phase are not sent
+	assert.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(app), app))
+	app.SetLabels(map[string]string{"team": "platform"})
+	assert.NoError(t, k8sClient.Update(ctx, app))
+	app.Status.Phase = common.ApplicationRunning
+	assert.NoError(t, k8sClient.Update(ctx, app))
+	status, _ = receive()
+	assert.Equal(t, AddonPhaseEnabled, status.AddonPhase)
+
+	assert.NoError(t, k8sClient.Delete(ctx, app))
+	status, _ = receive()
+	assert.Equal(t, AddonPhaseDisabled, status.AddonPhase)
+
+	cancel()
+	_, ok := receive()
+	assert.False(t, ok)
+}
+
+func TestWaitAddonReady(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+	app := &v1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: Convert2AppName("fluxcd"), Namespace: types.DefaultKubeVelaNS},
+		Status:     common.AppStatus{Phase: common.ApplicationRunningWorkflow},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(app).Build()
+	interval := addonReadyPollInterval
+	addonReadyPollInterval = 10 * time.Millisecond
+	defer func() { addonReadyPollInterval = interval }()
+
+	status, err := WaitAddonReady(context.Background(), k8sClient, "fluxcd", 50*time.Millisecond)
+	assert.ErrorIs(t, err, ErrWaitAddonReadyTimeout)
+	assert.Equal(t, AddonPhaseEnabling, status.AddonPhase)
+
+	app.Status.Phase = common.ApplicationRunning
+	assert.NoError(t, k8sClient.Update(context.Background(), app))
+	status, err = WaitAddonReady(context.Background(), k8sClient, "fluxcd", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, AddonPhaseEnabled, status.AddonPhase)
+}
+
+func TestGetRegistryAddonStates(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+	// the legacy addon app is named after the addon
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1beta1.Application{ObjectMeta: metav1.ObjectMeta{Name: Convert2AppName("fluxcd"), Namespace: types.DefaultKubeVelaNS}},
+		&v1beta1.Application{ObjectMeta: metav1.ObjectMeta{Name: "terraform", Namespace: types.DefaultKubeVelaNS}},
+	).Build()
+	addons, err := getRegistryAddonStates(context.Background(), k8sClient, []*UIData{
+		{Meta: Meta{Name: "velaux", Version: "1.2.0", Description: "KubeVela User Experience"}},
+		{Meta: Meta{Name: "terraform", Version: "1.0.6"}},
+		{Meta: Meta{Name: "fluxcd", Version: "1.1.0", Description: "Extended workload to do continuous and progressive delivery"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []RegistryAddon{
+		{Name: "fluxcd", Version: "1.1.0", Description: "Extended workload to do continuous and progressive delivery", State: AddonPhaseEnabled},
+		{Name: "terraform", Version: "1.0.6", State: AddonPhaseEnabled},
+		{Name: "velaux", Version: "1.2.0", Description: "KubeVela User Experience", State: AddonPhaseDisabled},
+	}, addons)
+}
+
+func TestGetAddonConditions(t *testing.T) {
+	now := time.Now()
+	status := common.AppStatus{}
+	status.SetConditions(condition.Condition{
+		Type:               condition.ConditionType(common.RenderCondition.String()),
+		Status:             corev1.ConditionFalse,
+		Reason:             condition.ReasonReconcileError,
+		Message:            "fail to render",
+		LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+	}, condition.Condition{
+		Type:               condition.ConditionType(common.WorkflowCondition.String()),
+		Status:             corev1.ConditionTrue,
+		Reason:             condition.ReasonAvailable,
+		LastTransitionTime: metav1.NewTime(now),
+	})
+	conditions := getAddonConditions(status)
+	assert.Len(t, conditions, 2)
+	assert.Equal(t, common.WorkflowCondition.String(), conditions[0].Type)
+	assert.Equal(t, AddonCondition{
+		Type:               common.RenderCondition.String(),
+		Status:             corev1.ConditionFalse,
+		Reason:             string(condition.ReasonReconcileError),
+		Message:            "fail to render",
+		LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+	}, conditions[1])
+	assert.Nil(t, getAddonConditions(common.AppStatus{}))
 }
 
 func TestGetAddonStatus4Observability(t *testing.T) {
@@ -363,19 +859,19 @@ func TestGetAddonStatus4Observability(t *testing.T) {
 	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(addonApplication, addonSecret).Build()
 	addonStatus, err := GetAddonStatus(context.Background(), k8sClient, ObservabilityAddon)
 	assert.NoError(t, err)
-	assert.Equal(t, addonStatus.AddonPhase, enabling)
+	assert.Equal(t, addonStatus.AddonPhase, AddonPhaseEnabling)
 
 	// Addon is not installed in multiple clusters
 	k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(addonApplication, addonSecret, addonService).Build()
 	addonStatus, err = GetAddonStatus(context.Background(), k8sClient, ObservabilityAddon)
 	assert.NoError(t, err)
-	assert.Equal(t, addonStatus.AddonPhase, enabled)
+	assert.Equal(t, addonStatus.AddonPhase, AddonPhaseEnabled)
 
 	// Addon is installed in multiple clusters
 	assert.NoError(t, k8sClient.Create(ctx, clusterSecret))
 	addonStatus, err = GetAddonStatus(context.Background(), k8sClient, ObservabilityAddon)
 	assert.NoError(t, err)
-	assert.Equal(t, addonStatus.AddonPhase, enabled)
+	assert.Equal(t, addonStatus.AddonPhase, AddonPhaseEnabled)
 }
 
 var baseAddon = InstallPackage{
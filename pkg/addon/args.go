@@ -0,0 +1,179 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ValidateAddonArgs checks the args against the parameter of the addon, an InvalidArgsError listing the unknown keys
+// and the type mismatches is returned. The args are not checked if the addon has no parameter.
+func ValidateAddonArgs(addon *InstallPackage, args map[string]interface{}) error {
+	if addon.Parameters == "" || len(args) == 0 {
+		return nil
+	}
+	schema, err := genParameterSchema(addon.Name, addon.Parameters)
+	if err != nil {
+		return err
+	}
+	invalid := &InvalidArgsError{Addon: addon.Name}
+	checkArg(schema, "", args, invalid)
+	if len(invalid.UnknownKeys) == 0 && len(invalid.TypeMismatches) == 0 {
+		return nil
+	}
+	sort.Strings(invalid.UnknownKeys)
+	sort.Slice(invalid.TypeMismatches, func(i, j int) bool {
+		return invalid.TypeMismatches[i].Key < invalid.TypeMismatches[j].Key
+	})
+	return invalid
+}
+
+// checkArg checks the value at the key path against the schema, the secret references are not checked since they
+// are rendered as secretKeyRef values
+func checkArg(schema *openapi3.Schema, key string, arg interface{}, invalid *InvalidArgsError) {
+	if schema == nil {
+		return
+	}
+	if m, ok := arg.(map[string]interface{}); ok {
+		if _, isRef := getSecretArgRef(m); isRef {
+			return
+		}
+	}
+	switch schema.Type {
+	case "object":
+		m, ok := arg.(map[string]interface{})
+		if !ok {
+			invalid.addTypeMismatch(key, schema.Type, arg)
+			return
+		}
+		for k, v := range m {
+			childKey := joinArgKey(key, k)
+			if prop, ok := schema.Properties[k]; ok && prop != nil {
+				checkArg(prop.Value, childKey, v, invalid)
+				continue
+			}
+			switch {
+			case schema.AdditionalProperties != nil:
+				checkArg(schema.AdditionalProperties.Value, childKey, v, invalid)
+			case len(schema.Properties) == 0,
+				schema.AdditionalPropertiesAllowed != nil && *schema.AdditionalPropertiesAllowed:
+				// an open struct accepts any key
+			default:
+				invalid.UnknownKeys = append(invalid.UnknownKeys, childKey)
+			}
+		}
+	case "array":
+		items, ok := arg.([]interface{})
+		if !ok {
+			invalid.addTypeMismatch(key, schema.Type, arg)
+			return
+		}
+		if schema.Items == nil {
+			return
+		}
+		for i, item := range items {
+			checkArg(schema.Items.Value, fmt.Sprintf("%s[%d]", key, i), item, invalid)
+		}
+	case "string", "boolean", "integer", "number":
+		if !isArgOfType(schema.Type, arg) {
+			invalid.addTypeMismatch(key, schema.Type, arg)
+		}
+	}
+}
+
+func joinArgKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func isArgOfType(schemaType string, arg interface{}) bool {
+	switch v := arg.(type) {
+	case string:
+		return schemaType == "string"
+	case bool:
+		return schemaType == "boolean"
+	case int, int32, int64:
+		return schemaType == "integer" || schemaType == "number"
+	case float64:
+		// the numbers decoded from JSON are float64, the integer ones are accepted as integer
+		return schemaType == "number" || (schemaType == "integer" && v == math.Trunc(v))
+	case float32:
+		return schemaType == "number" || (schemaType == "integer" && float64(v) == math.Trunc(float64(v)))
+	default:
+		return false
+	}
+}
+
+// getArgType returns the type of the arg in the terms of the OpenAPI schema
+func getArgType(arg interface{}) string {
+	switch arg.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int32, int64, float32, float64:
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", arg)
+	}
+}
+
+// getParameterDefaults returns the defaults declared in the parameter of the addon, the nested defaults are returned
+// in their parent objects and the parameters without default are left out
+func getParameterDefaults(name, parameters string) (map[string]interface{}, error) {
+	defaults := map[string]interface{}{}
+	if parameters == "" {
+		return defaults, nil
+	}
+	schema, err := genParameterSchema(name, parameters)
+	if err != nil {
+		return nil, err
+	}
+	collectSchemaDefaults(schema, defaults)
+	return defaults, nil
+}
+
+func collectSchemaDefaults(schema *openapi3.Schema, defaults map[string]interface{}) {
+	for k, prop := range schema.Properties {
+		if prop == nil || prop.Value == nil {
+			continue
+		}
+		if prop.Value.Default != nil {
+			defaults[k] = prop.Value.Default
+			continue
+		}
+		if prop.Value.Type == "object" {
+			nested := map[string]interface{}{}
+			collectSchemaDefaults(prop.Value, nested)
+			if len(nested) > 0 {
+				defaults[k] = nested
+			}
+		}
+	}
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package addon
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/google/go-github/v32/github"
 	"github.com/pkg/errors"
 )
@@ -35,8 +38,76 @@ var (
 
 	// ErrNotExist  means addon not exists
 	ErrNotExist = NewAddonError("addon not exist")
+
+	// ErrInvalidAddonPackage means the directory doesn't have the layout of an addon package
+	ErrInvalidAddonPackage = NewAddonError("invalid addon package")
+
+	// ErrAddonNamespaceConflict means the addon resources can't be installed into the namespace override
+	ErrAddonNamespaceConflict = NewAddonError("addon resources conflict with the namespace override")
+
+	// ErrInvalidSecretArgRef means the argument referring to a secret is not in the format of secretName/key
+	ErrInvalidSecretArgRef = NewAddonError("invalid secret reference in addon arguments, it must be secretName/key")
+
+	// ErrWaitAddonReadyTimeout means the addon is neither enabled nor failed before the timeout
+	ErrWaitAddonReadyTimeout = NewAddonError("timeout waiting for the addon to be ready")
+
+	// ErrInvalidImageRegistry means the image registry override is not a registry host with an optional port and path
+	ErrInvalidImageRegistry = NewAddonError("invalid image registry, it must be a host with an optional port and path such as registry.example.com/mirror")
 )
 
+// MissingPrerequisitesError means the dependencies or the CRDs required by the addon are missing
+type MissingPrerequisitesError struct {
+	Addon string
+	// Dependencies are the dependent addons which are neither enabled nor found in the registry
+	Dependencies []string
+	CRDs         []string
+}
+
+func (e *MissingPrerequisitesError) Error() string {
+	var missing []string
+	if len(e.Dependencies) != 0 {
+		missing = append(missing, fmt.Sprintf("dependent addons [%s]", strings.Join(e.Dependencies, ", ")))
+	}
+	if len(e.CRDs) != 0 {
+		missing = append(missing, fmt.Sprintf("CRDs [%s]", strings.Join(e.CRDs, ", ")))
+	}
+	return fmt.Sprintf("addon %s can't be enabled, missing %s", e.Addon, strings.Join(missing, " and "))
+}
+
+// InvalidArgsError means the arguments of the addon don't match the parameter of the addon
+type InvalidArgsError struct {
+	Addon string
+	// UnknownKeys are the paths of the arguments not defined in the parameter, such as image.tag
+	UnknownKeys    []string
+	TypeMismatches []ArgTypeMismatch
+}
+
+// ArgTypeMismatch is an argument whose value doesn't have the type of the parameter
+type ArgTypeMismatch struct {
+	Key      string
+	Expected string
+	Actual   string
+}
+
+func (e *InvalidArgsError) Error() string {
+	var invalid []string
+	if len(e.UnknownKeys) != 0 {
+		invalid = append(invalid, fmt.Sprintf("unknown keys [%s]", strings.Join(e.UnknownKeys, ", ")))
+	}
+	if len(e.TypeMismatches) != 0 {
+		var mismatches []string
+		for _, m := range e.TypeMismatches {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected %s but got %s", m.Key, m.Expected, m.Actual))
+		}
+		invalid = append(invalid, fmt.Sprintf("type mismatches [%s]", strings.Join(mismatches, ", ")))
+	}
+	return fmt.Sprintf("invalid arguments of addon %s, %s", e.Addon, strings.Join(invalid, " and "))
+}
+
+func (e *InvalidArgsError) addTypeMismatch(key, expected string, arg interface{}) {
+	e.TypeMismatches = append(e.TypeMismatches, ArgTypeMismatch{Key: key, Expected: expected, Actual: getArgType(arg)})
+}
+
 // WrapErrRateLimit return ErrRateLimit if is the situation, or return error directly
 func WrapErrRateLimit(err error) error {
 	errRate := &github.RateLimitError{}
@@ -20,37 +20,60 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
 
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	commontypes "github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/condition"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/multicluster"
+	"github.com/oam-dev/kubevela/pkg/oam"
 	"github.com/oam-dev/kubevela/pkg/utils/apply"
 )
 
+// AddonPhase defines the phase of an addon
+type AddonPhase string
+
 const (
-	// disabled indicates the addon is disabled
-	disabled = "disabled"
-	// enabled indicates the addon is enabled
-	enabled = "enabled"
-	// enabling indicates the addon is enabling
-	enabling = "enabling"
-	// disabling indicates the addon related app is deleting
-	disabling = "disabling"
-	// suspend indicates the addon related app is suspend
-	suspend = "suspend"
+	// AddonPhaseDisabled indicates the addon is disabled
+	AddonPhaseDisabled AddonPhase = "disabled"
+	// AddonPhaseEnabled indicates the addon is enabled
+	AddonPhaseEnabled AddonPhase = "enabled"
+	// AddonPhaseEnabling indicates the addon is enabling
+	AddonPhaseEnabling AddonPhase = "enabling"
+	// AddonPhaseDisabling indicates the addon related app is deleting
+	AddonPhaseDisabling AddonPhase = "disabling"
+	// AddonPhaseSuspend indicates the addon related app is suspend
+	AddonPhaseSuspend AddonPhase = "suspend"
+	// AddonPhaseFailed indicates the addon related app is failed to render or unhealthy, it won't progress by itself
+	AddonPhaseFailed AddonPhase = "failed"
 )
 
 // EnableAddon will enable addon with dependency check, source is where addon from.
-func EnableAddon(ctx context.Context, name string, cli client.Client, apply apply.Applicator, config *rest.Config, r Registry, args map[string]interface{}, cache *Cache) error {
-	h := NewAddonInstaller(ctx, cli, apply, config, &r, args, cache)
+// namespace overrides where the addon application and its resources are installed, the override saved when the addon
+// was enabled before is kept if it's empty.
+// The dependencies which are not enabled are enabled from the registry, unless skipDependencyInstall is set,
+// then a MissingPrerequisitesError listing them is returned. imageRegistry replaces the registry of the images in
+// the addon components if it's set, such as a private registry mirroring the images.
+func EnableAddon(ctx context.Context, name string, cli client.Client, apply apply.Applicator, config *rest.Config, r Registry, args map[string]interface{}, cache *Cache, namespace string, skipDependencyInstall bool, imageRegistry string) error {
+	h := NewAddonInstaller(ctx, cli, apply, config, &r, args, cache, namespace)
+	if skipDependencyInstall {
+		h.SkipDependencyInstall()
+	}
+	h.SetImageRegistry(imageRegistry)
 	pkg, err := h.loadInstallPackage(name)
 	if err != nil {
 		return err
@@ -62,6 +85,33 @@ func EnableAddon(ctx context.Context, name string, cli client.Client, apply appl
 	return nil
 }
 
+// EnableAddonFromPath will enable the addon in a local directory, it's used when the addon registry is not reachable.
+// The dependencies of the addon must be enabled before, since there is no registry to find them.
+func EnableAddonFromPath(ctx context.Context, dir string, cli client.Client, apply apply.Applicator, config *rest.Config, args map[string]interface{}, namespace string) error {
+	r, err := newLocalReader(dir)
+	if err != nil {
+		return err
+	}
+	metas, err := r.ListAddonMeta()
+	if err != nil {
+		return err
+	}
+	meta := metas[r.name]
+	uiData, err := GetUIDataFromReader(r, &meta, UIMetaOptions)
+	if err != nil {
+		return err
+	}
+	if uiData.Name == "" {
+		return errors.Wrapf(ErrInvalidAddonPackage, "the name of the addon is not set in %s", MetadataFileName)
+	}
+	pkg, err := GetInstallPackageFromReader(r, &meta, uiData)
+	if err != nil {
+		return err
+	}
+	h := NewAddonInstaller(ctx, cli, apply, config, nil, args, nil, namespace)
+	return h.enableAddon(pkg)
+}
+
 // DisableAddon will disable addon from cluster.
 func DisableAddon(ctx context.Context, cli client.Client, name string) error {
 	app, err := FetchAddonRelatedApp(ctx, cli, name)
@@ -72,6 +122,11 @@ func DisableAddon(ctx context.Context, cli client.Client, name string) error {
 	if err := cli.Delete(ctx, app); err != nil {
 		return err
 	}
+	// the args secret isn't owned by the application installed into a namespace override, so it's deleted explicitly
+	sec := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: types.DefaultKubeVelaNS, Name: Convert2SecName(name)}}
+	if err := cli.Delete(ctx, sec); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
 	return nil
 }
 
@@ -80,13 +135,215 @@ func GetAddonStatus(ctx context.Context, cli client.Client, name string) (Status
 	app, err := FetchAddonRelatedApp(ctx, cli, name)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			return Status{AddonPhase: disabled, AppStatus: nil}, nil
+			return Status{AddonPhase: AddonPhaseDisabled, AppStatus: nil}, nil
 		}
 		return Status{}, err
 	}
+	return getAddonStatusFromApp(ctx, cli, name, app), nil
+}
+
+// addonReadyPollInterval is the interval to poll the status of the addon in WaitAddonReady
+var addonReadyPollInterval = 2 * time.Second
+
+// WaitAddonReady polls the status of the addon until it is enabled or failed, and returns the last status. If the
+// timeout elapses first, the last status is returned with ErrWaitAddonReadyTimeout.
+func WaitAddonReady(ctx context.Context, cli client.Client, name string, timeout time.Duration) (Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var status Status
+	err := wait.PollImmediateUntil(addonReadyPollInterval, func() (bool, error) {
+		var err error
+		if status, err = GetAddonStatus(ctx, cli, name); err != nil {
+			return false, err
+		}
+		return status.AddonPhase == AddonPhaseEnabled || status.AddonPhase == AddonPhaseFailed, nil
+	}, ctx.Done())
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return status, ErrWaitAddonReadyTimeout
+	}
+	return status, err
+}
+
+// WatchAddonStatus watches the addon related application, the current status of the addon is sent first and then the
+// status is sent whenever its phase, message or version changes. The channel is closed when the context is done or the
+// watch is closed by the server.
+func WatchAddonStatus(ctx context.Context, cli client.WithWatch, name string) (<-chan Status, error) {
+	appName, appNamespace := Convert2AppName(name), types.DefaultKubeVelaNS
+	app, err := FetchAddonRelatedApp(ctx, cli, name)
+	switch {
+	case err == nil:
+		appName, appNamespace = app.Name, app.Namespace
+	case !apierrors.IsNotFound(err):
+		return nil, err
+	}
+	// the watch is started before reading the current status so that no change is missed
+	w, err := cli.Watch(ctx, &v1beta1.ApplicationList{}, client.InNamespace(appNamespace), client.MatchingFields{"metadata.name": appName})
+	if err != nil {
+		return nil, err
+	}
+	current, err := GetAddonStatus(ctx, cli, name)
+	if err != nil {
+		w.Stop()
+		return nil, err
+	}
+	statuses := make(chan Status)
+	go func() {
+		defer close(statuses)
+		defer w.Stop()
+		last := current
+		select {
+		case statuses <- current:
+		case <-ctx.Done():
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+				app, ok := event.Object.(*v1beta1.Application)
+				if !ok || app.Name != appName {
+					continue
+				}
+				var status Status
+				switch event.Type {
+				case watch.Added, watch.Modified:
+					status = getAddonStatusFromApp(ctx, cli, name, app)
+				case watch.Deleted:
+					status = Status{AddonPhase: AddonPhaseDisabled}
+				default:
+					continue
+				}
+				if !isAddonStatusChanged(last, status) {
+					continue
+				}
+				last = status
+				select {
+				case statuses <- status:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return statuses, nil
+}
+
+func isAddonStatusChanged(last, current Status) bool {
+	return last.AddonPhase != current.AddonPhase || last.Message != current.Message ||
+		last.InstalledVersion != current.InstalledVersion || last.RegistryName != current.RegistryName
+}
+
+// ListAddonStatuses lists the status of all the addons installed in the cluster, the key of the result is the addon name
+func ListAddonStatuses(ctx context.Context, cli client.Client) (map[string]Status, error) {
+	apps := &v1beta1.ApplicationList{}
+	// the addons installed into a namespace override have their applications in that namespace
+	if err := cli.List(ctx, apps, client.HasLabels{oam.LabelAddonName}); err != nil {
+		return nil, err
+	}
+	statuses := make(map[string]Status, len(apps.Items))
+	for i := range apps.Items {
+		app := &apps.Items[i]
+		name := app.Labels[oam.LabelAddonName]
+		if name == "" {
+			continue
+		}
+		statuses[name] = getAddonStatusFromApp(ctx, cli, name, app)
+	}
+	return statuses, nil
+}
+
+// RegistryAddon is an addon available in a registry, State is enabled if the addon is enabled in the cluster
+type RegistryAddon struct {
+	Name        string     `json:"name"`
+	Version     string     `json:"version"`
+	Description string     `json:"description"`
+	State       AddonPhase `json:"state"`
+}
+
+// ListRegistryAddons lists the addons in the registry sorted by name, and marks the enabled ones
+func ListRegistryAddons(ctx context.Context, cli client.Client, registry Registry) ([]RegistryAddon, error) {
+	metas, err := registry.ListAddonMeta()
+	if err != nil {
+		return nil, err
+	}
+	uiData, err := registry.ListUIData(metas, CLIMetaOptions)
+	if err != nil {
+		return nil, err
+	}
+	return getRegistryAddonStates(ctx, cli, uiData)
+}
+
+// GetAddonDefaultArgs loads the addon from the registry and returns the defaults declared in its parameter, so that
+// they can be shown or written to a values file before enabling the addon
+func GetAddonDefaultArgs(ctx context.Context, name string, registry Registry) (map[string]interface{}, error) {
+	metas, err := registry.ListAddonMeta()
+	if err != nil {
+		return nil, err
+	}
+	meta, ok := metas[name]
+	if !ok {
+		return nil, ErrNotExist
+	}
+	uiData, err := registry.GetUIData(&meta, ListOptions{GetParameter: true})
+	if err != nil {
+		return nil, err
+	}
+	return getParameterDefaults(name, uiData.Parameters)
+}
+
+func getRegistryAddonStates(ctx context.Context, cli client.Client, uiData []*UIData) ([]RegistryAddon, error) {
+	addons := make([]RegistryAddon, 0, len(uiData))
+	for _, data := range uiData {
+		addon := RegistryAddon{Name: data.Name, Version: data.Version, Description: data.Description, State: AddonPhaseEnabled}
+		if _, err := FetchAddonRelatedApp(ctx, cli, data.Name); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			addon.State = AddonPhaseDisabled
+		}
+		addons = append(addons, addon)
+	}
+	sort.Slice(addons, func(i, j int) bool {
+		return addons[i].Name < addons[j].Name
+	})
+	return addons, nil
+}
+
+// getAddonStatusFromApp computes the status of the addon from its related application
+func getAddonStatusFromApp(ctx context.Context, cli client.Client, name string, app *v1beta1.Application) Status {
+	status := getAddonPhaseFromApp(ctx, cli, name, app)
+	status.InstalledVersion = app.GetAnnotations()[oam.AnnotationAddonVersion]
+	status.RegistryName = app.GetAnnotations()[oam.AnnotationAddonRegistry]
+	status.Conditions = getAddonConditions(app.Status)
+	return status
+}
+
+// getAddonConditions converts the conditions of the application, sorted by the last transition time descending
+func getAddonConditions(status commontypes.AppStatus) []AddonCondition {
+	var conditions []AddonCondition
+	for _, cond := range status.Conditions {
+		conditions = append(conditions, AddonCondition{
+			Type:               string(cond.Type),
+			Status:             cond.Status,
+			Reason:             string(cond.Reason),
+			Message:            cond.Message,
+			LastTransitionTime: cond.LastTransitionTime,
+		})
+	}
+	sort.SliceStable(conditions, func(i, j int) bool {
+		return conditions[j].LastTransitionTime.Before(&conditions[i].LastTransitionTime)
+	})
+	return conditions
+}
 
+// getAddonPhaseFromApp computes the phase of the addon from the status of its related application
+func getAddonPhaseFromApp(ctx context.Context, cli client.Client, name string, app *v1beta1.Application) Status {
 	if app.Status.Workflow != nil && app.Status.Workflow.Suspend {
-		return Status{AddonPhase: suspend, AppStatus: &app.Status}, nil
+		return Status{AddonPhase: AddonPhaseSuspend, AppStatus: &app.Status}
 	}
 	switch app.Status.Phase {
 	case commontypes.ApplicationRunning:
@@ -96,9 +353,9 @@ func GetAddonStatus(ctx context.Context, cli client.Client, name string) (Status
 				sec      v1.Secret
 				domain   string
 			)
-			if err = cli.Get(ctx, client.ObjectKey{Namespace: types.DefaultKubeVelaNS, Name: Convert2SecName(name)}, &sec); err != nil {
+			if err := cli.Get(ctx, client.ObjectKey{Namespace: types.DefaultKubeVelaNS, Name: Convert2SecName(name)}, &sec); err != nil {
 				klog.ErrorS(err, "failed to get observability secret")
-				return Status{AddonPhase: enabling, AppStatus: &app.Status}, nil
+				return Status{AddonPhase: AddonPhaseEnabling, AppStatus: &app.Status}
 			}
 
 			if v, ok := sec.Data[ObservabilityAddonDomainArg]; ok {
@@ -107,7 +364,7 @@ func GetAddonStatus(ctx context.Context, cli client.Client, name string) (Status
 			observability, err := GetObservabilityAccessibilityInfo(ctx, cli, domain)
 			if err != nil {
 				klog.ErrorS(err, "failed to get observability accessibility info")
-				return Status{AddonPhase: enabling, AppStatus: &app.Status}, nil
+				return Status{AddonPhase: AddonPhaseEnabling, AppStatus: &app.Status}
 			}
 
 			for _, o := range observability {
@@ -122,14 +379,50 @@ func GetAddonStatus(ctx context.Context, cli client.Client, name string) (Status
 					"serviceExternalIP": o.ServiceExternalIP,
 				}
 			}
-			return Status{AddonPhase: enabled, AppStatus: &app.Status, Clusters: clusters}, nil
+			return Status{AddonPhase: AddonPhaseEnabled, AppStatus: &app.Status, Clusters: clusters}
 		}
-		return Status{AddonPhase: enabled, AppStatus: &app.Status}, nil
+		return Status{AddonPhase: AddonPhaseEnabled, AppStatus: &app.Status}
 	case commontypes.ApplicationDeleting:
-		return Status{AddonPhase: disabling, AppStatus: &app.Status}, nil
+		return Status{AddonPhase: AddonPhaseDisabling, AppStatus: &app.Status}
+	case commontypes.ApplicationUnhealthy, commontypes.ApplicationWorkflowTerminated:
+		return Status{AddonPhase: AddonPhaseFailed, AppStatus: &app.Status, Message: getAddonFailedMessage(app.Status)}
+	case commontypes.ApplicationRendering:
+		if cond := getFailedCondition(app.Status); cond != nil {
+			return Status{AddonPhase: AddonPhaseFailed, AppStatus: &app.Status, Message: cond.Message}
+		}
+		return Status{AddonPhase: AddonPhaseEnabling, AppStatus: &app.Status}
 	default:
-		return Status{AddonPhase: enabling, AppStatus: &app.Status}, nil
+		return Status{AddonPhase: AddonPhaseEnabling, AppStatus: &app.Status}
+	}
+}
+
+// getFailedCondition returns the first condition of the app which reports a reconcile error
+func getFailedCondition(status commontypes.AppStatus) *condition.Condition {
+	for i, cond := range status.Conditions {
+		if cond.Status == v1.ConditionFalse && cond.Reason == condition.ReasonReconcileError {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// getAddonFailedMessage explains why the addon related app is failed
+func getAddonFailedMessage(status commontypes.AppStatus) string {
+	if cond := getFailedCondition(status); cond != nil {
+		return cond.Message
 	}
+	for _, service := range status.Services {
+		if !service.Healthy {
+			return fmt.Sprintf("component %s is unhealthy: %s", service.Name, service.Message)
+		}
+	}
+	if status.Workflow != nil && status.Workflow.Terminated {
+		if status.Workflow.Message != "" {
+			return status.Workflow.Message
+		}
+		return "the workflow of the addon application is terminated"
+	}
+	return ""
 }
 
 // GetObservabilityAccessibilityInfo will get the accessibility info of addon in local cluster and multiple clusters
@@ -184,8 +477,26 @@ func GetObservabilityAccessibilityInfo(ctx context.Context, k8sClient client.Cli
 
 // Status contain addon phase and related app status
 type Status struct {
-	AddonPhase string
+	AddonPhase AddonPhase
 	AppStatus  *commontypes.AppStatus
+	// Message explains why the addon is failed
+	Message string `json:"message,omitempty"`
 	// the status of multiple clusters
 	Clusters map[string]map[string]interface{} `json:"clusters,omitempty"`
+	// InstalledVersion and RegistryName record where the enabled addon comes from,
+	// they are empty if the addon is disabled or enabled by an older version of KubeVela
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	RegistryName     string `json:"registryName,omitempty"`
+	// Conditions are the last transitions of the addon related application, the most recent one comes first
+	Conditions []AddonCondition `json:"conditions,omitempty"`
+}
+
+// AddonCondition is a condition of the addon related application. The application keeps one condition of each type,
+// so a failure is kept until a condition of the same type replaces it.
+type AddonCondition struct {
+	Type               string             `json:"type"`
+	Status             v1.ConditionStatus `json:"status"`
+	Reason             string             `json:"reason"`
+	Message            string             `json:"message,omitempty"`
+	LastTransitionTime metav1.Time        `json:"lastTransitionTime"`
 }
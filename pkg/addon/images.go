@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+)
+
+// imageRegistryPattern matches a registry host with an optional port and path, such as registry.example.com:5000/mirror
+var imageRegistryPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]+)?(/[a-z0-9]+([._-][a-z0-9]+)*)*$`)
+
+// ValidateImageRegistry checks the image registry override of the addon, the empty registry means no override
+func ValidateImageRegistry(registry string) error {
+	if registry == "" || imageRegistryPattern.MatchString(registry) {
+		return nil
+	}
+	return errors.Wrapf(ErrInvalidImageRegistry, "%q", registry)
+}
+
+// replaceImageRegistry replaces the registry host of the image with the registry override, the repository path and
+// the tag or digest are kept, e.g. ghcr.io/fluxcd/helm-controller:v0.1 becomes <registry>/fluxcd/helm-controller:v0.1
+func replaceImageRegistry(image, registry string) string {
+	if i := strings.Index(image, "/"); i >= 0 {
+		// the first part is a registry host only if it looks like a domain, a host with port or localhost
+		if host := image[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			image = image[i+1:]
+		}
+	}
+	return registry + "/" + image
+}
+
+// rewriteAppImages replaces the registry of the images in the properties of the components and their traits.
+// An image is a string field named image at any level, which covers the raw workloads and the webservice components.
+func rewriteAppImages(app *v1beta1.Application, registry string) error {
+	if registry == "" {
+		return nil
+	}
+	for i := range app.Spec.Components {
+		comp := &app.Spec.Components[i]
+		properties, err := rewriteImagesInProperties(comp.Properties, registry)
+		if err != nil {
+			return errors.Wrapf(err, "fail to rewrite the images of component %s", comp.Name)
+		}
+		comp.Properties = properties
+		for j := range comp.Traits {
+			if comp.Traits[j].Properties, err = rewriteImagesInProperties(comp.Traits[j].Properties, registry); err != nil {
+				return errors.Wrapf(err, "fail to rewrite the images of trait %s of component %s", comp.Traits[j].Type, comp.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func rewriteImagesInProperties(properties *runtime.RawExtension, registry string) (*runtime.RawExtension, error) {
+	if properties == nil || len(properties.Raw) == 0 {
+		return properties, nil
+	}
+	var obj interface{}
+	if err := json.Unmarshal(properties.Raw, &obj); err != nil {
+		return nil, err
+	}
+	if !rewriteImages(obj, registry) {
+		return properties, nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.RawExtension{Raw: data}, nil
+}
+
+// rewriteImages replaces the registry of the images in the object, it reports whether any image is replaced
+func rewriteImages(obj interface{}, registry string) bool {
+	var replaced bool
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		for k, v := range o {
+			if image, ok := v.(string); ok && k == "image" && image != "" {
+				o[k] = replaceImageRegistry(image, registry)
+				replaced = true
+				continue
+			}
+			replaced = rewriteImages(v, registry) || replaced
+		}
+	case []interface{}:
+		for _, v := range o {
+			replaced = rewriteImages(v, registry) || replaced
+		}
+	}
+	return replaced
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+var _ AsyncReader = &localReader{}
+
+// localReader reads an addon package from a local directory, the name of the directory is used as the root path of the addon
+type localReader struct {
+	dir  string
+	name string
+}
+
+// localItem is Item implement for local files
+type localItem struct {
+	path string
+	name string
+}
+
+// GetType from localItem
+func (i localItem) GetType() string {
+	return FileType
+}
+
+// GetPath from localItem
+func (i localItem) GetPath() string {
+	return i.path
+}
+
+// GetName from localItem
+func (i localItem) GetName() string {
+	return i.name
+}
+
+// newLocalReader checks the directory has the layout of an addon package and creates a reader for it
+func newLocalReader(dir string) (*localReader, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidAddonPackage, "fail to read %s: %s", dir, err.Error())
+	}
+	if !info.IsDir() {
+		return nil, errors.Wrapf(ErrInvalidAddonPackage, "%s is not a directory", dir)
+	}
+	if _, err = os.Stat(filepath.Join(absDir, MetadataFileName)); err != nil {
+		return nil, errors.Wrapf(ErrInvalidAddonPackage, "%s doesn't contain %s", dir, MetadataFileName)
+	}
+	return &localReader{dir: absDir, name: filepath.Base(absDir)}, nil
+}
+
+// ListAddonMeta walks the directory and returns the files of the addon
+func (l *localReader) ListAddonMeta() (map[string]SourceMeta, error) {
+	meta := SourceMeta{Name: l.name}
+	err := filepath.Walk(l.dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(l.dir, filePath)
+		if err != nil {
+			return err
+		}
+		meta.Items = append(meta.Items, &localItem{
+			path: path.Join(l.name, filepath.ToSlash(relativePath)),
+			name: info.Name(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to read the addon directory %s", l.dir)
+	}
+	return map[string]SourceMeta{l.name: meta}, nil
+}
+
+// ReadFile reads the file, path is relative to the parent of the addon directory
+func (l *localReader) ReadFile(relativePath string) (content string, err error) {
+	b, err := ioutil.ReadFile(filepath.Join(filepath.Dir(l.dir), filepath.FromSlash(relativePath)))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// RelativePath returns the path of the item, it's relative to the parent of the addon directory
+func (l *localReader) RelativePath(item Item) string {
+	return item.GetPath()
+}
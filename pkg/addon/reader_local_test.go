@@ -0,0 +1,52 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalReader(t *testing.T) {
+	_, err := newLocalReader("./testdata/not-exist")
+	assert.ErrorIs(t, err, ErrInvalidAddonPackage)
+	_, err = newLocalReader("./testdata/example/readme.md")
+	assert.ErrorIs(t, err, ErrInvalidAddonPackage)
+	_, err = newLocalReader("./testdata/example/resources")
+	assert.ErrorIs(t, err, ErrInvalidAddonPackage)
+
+	r, err := newLocalReader("./testdata/example")
+	assert.NoError(t, err)
+	metas, err := r.ListAddonMeta()
+	assert.NoError(t, err)
+	meta, ok := metas["example"]
+	assert.True(t, ok)
+	assert.Equal(t, 7, len(meta.Items))
+
+	uiData, err := GetUIDataFromReader(r, &meta, UIMetaOptions)
+	assert.NoError(t, err)
+	assert.Equal(t, "example", uiData.Name)
+	assert.Equal(t, 1, len(uiData.Definitions))
+	assert.NotEmpty(t, uiData.Detail)
+
+	pkg, err := GetInstallPackageFromReader(r, &meta, uiData)
+	assert.NoError(t, err)
+	assert.NotNil(t, pkg.AppTemplate)
+	assert.Equal(t, 1, len(pkg.YAMLTemplates))
+	assert.Equal(t, 1, len(pkg.CUETemplates))
+}
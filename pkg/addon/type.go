@@ -64,6 +64,9 @@ type Meta struct {
 	Dependencies  []*Dependency `json:"dependencies,omitempty"`
 	NeedNamespace []string      `json:"needNamespace,omitempty"`
 	Invisible     bool          `json:"invisible"`
+	// RequiredCRDs are the names of the CRDs in the format of <plural>.<group>, they must be installed in the cluster
+	// before the addon is enabled since neither the addon nor its dependencies install them
+	RequiredCRDs []string `json:"requiredCRDs,omitempty"`
 }
 
 // DeployTo defines where the addon to deploy to
@@ -243,6 +243,8 @@ type ImageResource struct {
 	Digest string `json:"digest"`
 	// Tag is the image tag
 	Tag string `json:"tag"`
+	// Tags are all the tags pushed in the event, Tag is selected from them by the tag strategy of the trigger
+	Tags []string `json:"tags,omitempty"`
 	// URL is the image url
 	URL string `json:"url"`
 	// CreateTime is the image create time
@@ -313,6 +315,10 @@ type ApplicationTrigger struct {
 	Token         string `json:"token"`
 	Type          string `json:"type"`
 	PayloadType   string `json:"payloadType"`
+	// ComponentName is the component whose image is upgraded by the image registry payloads
+	ComponentName string `json:"componentName,omitempty"`
+	// TagStrategy selects the tag to upgrade to if several tags are pushed at once, it is first by default
+	TagStrategy string `json:"tagStrategy,omitempty"`
 }
 
 const (
@@ -322,6 +328,21 @@ const (
 	PayloadTypeDockerhub = "dockerhub"
 	// PayloadTypeACR is the payload type acr
 	PayloadTypeACR = "acr"
+	// PayloadTypeGitLab is the payload type gitlab container registry
+	PayloadTypeGitLab = "gitlab"
+	// PayloadTypeQuay is the payload type quay.io
+	PayloadTypeQuay = "quay"
+	// PayloadTypeECR is the payload type aws ecr, sent by EventBridge
+	PayloadTypeECR = "ecr"
+	// PayloadTypeImage is the payload type of a generic pipeline which posts the image only
+	PayloadTypeImage = "image"
+
+	// TagStrategyFirst selects the first pushed tag
+	TagStrategyFirst = "first"
+	// TagStrategyLatestSemver selects the greatest semantic version in the pushed tags
+	TagStrategyLatestSemver = "latest-semver"
+	// TagStrategyLongest selects the longest pushed tag, such as v1.2.3 over v1
+	TagStrategyLongest = "longest"
 
 	// ComponentTypeWebservice is the component type webservice
 	ComponentTypeWebservice = "webservice"
@@ -56,6 +56,8 @@ const (
 	AddonPhaseDisabling AddonPhase = "disabling"
 	// AddonPhaseSuspend indicates the addon is suspend
 	AddonPhaseSuspend AddonPhase = "suspend"
+	// AddonPhaseFailed indicates the addon is failed and won't progress
+	AddonPhaseFailed AddonPhase = "failed"
 )
 
 // EmptyResponse empty response, it will used for delete api
@@ -96,6 +98,16 @@ type ListAddonRegistryResponse struct {
 type EnableAddonRequest struct {
 	// Args is the key-value environment variables, e.g. AK/SK credentials.
 	Args map[string]interface{} `json:"args,omitempty"`
+	// Namespace overrides the namespace the addon application and its resources are installed into, the override of the last enabling is kept if it's empty.
+	Namespace string `json:"namespace,omitempty"`
+	// SkipDependencyInstall fails the request if any dependent addon is not enabled, instead of enabling it.
+	SkipDependencyInstall bool `json:"skipDependencyInstall,omitempty"`
+	// WaitReady blocks the request until the addon is enabled or failed, the status at the end of the wait is returned.
+	WaitReady bool `json:"waitReady,omitempty"`
+	// WaitTimeoutSeconds is the longest time to wait if WaitReady is set, it's 300 seconds by default.
+	WaitTimeoutSeconds int `json:"waitTimeoutSeconds,omitempty" validate:"min=0"`
+	// ImageRegistry replaces the registry of the images in the addon components, such as a private registry mirroring the images.
+	ImageRegistry string `json:"imageRegistry,omitempty"`
 }
 
 // ListAddonResponse defines the format for addon list response
@@ -150,8 +162,13 @@ type AddonStatusResponse struct {
 	Args             map[string]string `json:"args"`
 	EnablingProgress *EnablingProgress `json:"enabling_progress,omitempty"`
 	AppStatus        common.AppStatus  `json:"appStatus,omitempty"`
+	// Message explains why the addon is failed
+	Message string `json:"message,omitempty"`
 	// the status of multiple clusters
 	Clusters map[string]map[string]interface{} `json:"clusters,omitempty"`
+	// InstalledVersion and RegistryName record where the enabled addon comes from
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	RegistryName     string `json:"registryName,omitempty"`
 }
 
 // EnablingProgress defines the progress of enabling an addon
@@ -346,8 +363,10 @@ type CreateApplicationTriggerRequest struct {
 	Description   string `json:"description" optional:"true"`
 	WorkflowName  string `json:"workflowName"`
 	Type          string `json:"type" validate:"oneof=webhook"`
-	PayloadType   string `json:"payloadType" validate:"oneof=custom acr"`
+	PayloadType   string `json:"payloadType" validate:"oneof=custom acr gitlab quay ecr image"`
 	ComponentName string `json:"componentName,omitempty" optional:"true"`
+	// TagStrategy selects the tag if a payload pushes several tags at once, it is first by default
+	TagStrategy string `json:"tagStrategy,omitempty" optional:"true" validate:"omitempty,oneof=first latest-semver longest"`
 }
 
 // ApplicationTriggerBase application trigger base model
@@ -360,6 +379,7 @@ type ApplicationTriggerBase struct {
 	PayloadType   string    `json:"payloadType"`
 	Token         string    `json:"token"`
 	ComponentName string    `json:"componentName,omitempty"`
+	TagStrategy   string    `json:"tagStrategy,omitempty"`
 	CreateTime    time.Time `json:"createTime"`
 	UpdateTime    time.Time `json:"updateTime"`
 }
@@ -373,6 +393,28 @@ type ListApplicationTriggerResponse struct {
 type HandleApplicationTriggerWebhookRequest struct {
 	Upgrade  map[string]*model.JSONStruct `json:"upgrade,omitempty"`
 	CodeInfo *model.CodeInfo              `json:"codeInfo,omitempty"`
+	// Env is the env whose component patches are upgraded, the base components are upgraded if it is empty
+	Env string `json:"env,omitempty"`
+	// PatchStrategy is how the upgrade is patched into the properties, it is merge by default
+	PatchStrategy string `json:"patchStrategy,omitempty" validate:"omitempty,oneof=merge replace"`
+}
+
+const (
+	// PatchStrategyMerge merges the patch into the properties recursively
+	PatchStrategyMerge = "merge"
+	// PatchStrategyReplace replaces the whole value of each property in the patch, such as an entire env array or
+	// resources object, the other properties are kept
+	PatchStrategyReplace = "replace"
+)
+
+// HandleApplicationTriggerImageRequest handles application trigger request of a generic pipeline which posts the image only
+type HandleApplicationTriggerImageRequest struct {
+	Image string `json:"image"`
+	// Component is the component to upgrade, the component of the trigger is upgraded if it is empty
+	Component string `json:"component,omitempty"`
+	// ImageField is the path of the image in the component properties joined by dots, it is image by default
+	ImageField string          `json:"imageField,omitempty"`
+	CodeInfo   *model.CodeInfo `json:"codeInfo,omitempty"`
 }
 
 // HandleApplicationTriggerACRRequest handles application trigger ACR request
@@ -398,6 +440,65 @@ type ACRRepository struct {
 	RepoFullName           string `json:"repo_full_name"`
 	RepoOriginType         string `json:"repo_origin_type"`
 	RepoType               string `json:"repo_type"`
+	// InstanceID and InstanceName are only set by the instances of the enterprise edition
+	InstanceID   string `json:"instance_id,omitempty"`
+	InstanceName string `json:"instance_name,omitempty"`
+}
+
+// HandleApplicationTriggerGitLabRequest handles application trigger GitLab container registry request
+type HandleApplicationTriggerGitLabRequest struct {
+	EventName string        `json:"event_name"`
+	Events    []GitLabEvent `json:"events"`
+}
+
+// GitLabEvent is an event of the GitLab container registry
+type GitLabEvent struct {
+	Action  string        `json:"action"`
+	Target  GitLabTarget  `json:"target"`
+	Request GitLabRequest `json:"request"`
+}
+
+// GitLabTarget is the image pushed to the GitLab container registry
+type GitLabTarget struct {
+	MediaType  string `json:"mediaType"`
+	Digest     string `json:"digest"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	URL        string `json:"url"`
+}
+
+// GitLabRequest is the request to the GitLab container registry
+type GitLabRequest struct {
+	Host string `json:"host"`
+}
+
+// HandleApplicationTriggerQuayRequest handles application trigger Quay.io repository push request
+type HandleApplicationTriggerQuayRequest struct {
+	Repository  string   `json:"repository"`
+	Namespace   string   `json:"namespace"`
+	Name        string   `json:"name"`
+	DockerURL   string   `json:"docker_url"`
+	Homepage    string   `json:"homepage"`
+	UpdatedTags []string `json:"updated_tags"`
+}
+
+// HandleApplicationTriggerECRRequest handles application trigger AWS ECR image action request sent by EventBridge
+type HandleApplicationTriggerECRRequest struct {
+	DetailType string    `json:"detail-type"`
+	Source     string    `json:"source"`
+	Account    string    `json:"account"`
+	Region     string    `json:"region"`
+	Time       string    `json:"time"`
+	Detail     ECRDetail `json:"detail"`
+}
+
+// ECRDetail is the detail of the ECR image action
+type ECRDetail struct {
+	Result         string `json:"result"`
+	RepositoryName string `json:"repository-name"`
+	ImageDigest    string `json:"image-digest"`
+	ActionType     string `json:"action-type"`
+	ImageTag       string `json:"image-tag"`
 }
 
 // EnvBinding application env binding
@@ -795,7 +896,13 @@ type ApplicationDeployRequest struct {
 
 // ApplicationDeployResponse application deploy response body
 type ApplicationDeployResponse struct {
+	// ApplicationRevisionBase is the deployed revision, its version is the name to query the revision by
 	ApplicationRevisionBase
+	// PublishVersion is the publish version of the deployed application, the workflow record and the resource trackers
+	// of the deployment are marked with it
+	PublishVersion string `json:"publishVersion,omitempty"`
+	// Attempts is the number of the deploy attempts, it is only set by the deployment triggered by webhook
+	Attempts int `json:"attempts,omitempty"`
 }
 
 // VelaQLViewResponse query response
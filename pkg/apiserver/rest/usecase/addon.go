@@ -45,6 +45,9 @@ import (
 	velaerr "github.com/oam-dev/kubevela/pkg/utils/errors"
 )
 
+// DefaultWaitAddonReadyTimeout is the longest time to wait for the addon to be ready if the timeout is not requested
+const DefaultWaitAddonReadyTimeout = 5 * time.Minute
+
 // AddonHandler handle CRUD and installation of addons
 type AddonHandler interface {
 	GetAddonRegistry(ctx context.Context, name string) (*apis.AddonRegistry, error)
@@ -169,7 +172,7 @@ func (u *defaultAddonHandler) StatusAddon(ctx context.Context, name string) (*ap
 		return nil, bcode.ErrGetAddonApplication
 	}
 
-	if status.AddonPhase == string(apis.AddonPhaseDisabled) {
+	if status.AddonPhase == pkgaddon.AddonPhaseDisabled {
 		return &apis.AddonStatusResponse{
 			AddonBaseStatus: apis.AddonBaseStatus{
 				Name:  name,
@@ -183,8 +186,11 @@ func (u *defaultAddonHandler) StatusAddon(ctx context.Context, name string) (*ap
 			Name:  name,
 			Phase: apis.AddonPhase(status.AddonPhase),
 		},
-		AppStatus: *status.AppStatus,
-		Clusters:  status.Clusters,
+		AppStatus:        *status.AppStatus,
+		Message:          status.Message,
+		Clusters:         status.Clusters,
+		InstalledVersion: status.InstalledVersion,
+		RegistryName:     status.RegistryName,
 	}
 
 	if res.Phase != apis.AddonPhaseEnabled {
@@ -340,24 +346,49 @@ func (u *defaultAddonHandler) ListAddonRegistries(ctx context.Context) ([]*apis.
 
 func (u *defaultAddonHandler) EnableAddon(ctx context.Context, name string, args apis.EnableAddonRequest) error {
 	var err error
+	if err = pkgaddon.ValidateImageRegistry(args.ImageRegistry); err != nil {
+		return bcode.ErrAddonInvalidImageRegistry
+	}
 	registries, err := u.addonRegistryDS.ListRegistries(ctx)
 	if err != nil {
 		return err
 	}
 	for _, r := range registries {
-		err = pkgaddon.EnableAddon(ctx, name, u.kubeClient, u.apply, u.config, r, args.Args, u.addonRegistryCache)
+		err = pkgaddon.EnableAddon(ctx, name, u.kubeClient, u.apply, u.config, r, args.Args, u.addonRegistryCache, args.Namespace, args.SkipDependencyInstall, args.ImageRegistry)
 		if err == nil {
-			return nil
+			return u.waitAddonReady(ctx, name, args)
 		}
 
 		if err != nil && errors.As(err, &pkgaddon.ErrNotExist) {
 			// one registry return addon not exist error, should not break other registry func
 			continue
 		}
+		// the missing dependencies and the invalid arguments are reported instead of being hidden by the not exist error
+		var missing *pkgaddon.MissingPrerequisitesError
+		var invalidArgs *pkgaddon.InvalidArgsError
+		if errors.As(err, &missing) || errors.As(err, &invalidArgs) {
+			return err
+		}
 	}
 	return bcode.ErrAddonNotExist
 }
 
+// waitAddonReady waits for the addon to be enabled or failed if it's requested, the addon still enabling at the
+// timeout is not an error since the caller gets its status anyway
+func (u *defaultAddonHandler) waitAddonReady(ctx context.Context, name string, args apis.EnableAddonRequest) error {
+	if !args.WaitReady {
+		return nil
+	}
+	timeout := DefaultWaitAddonReadyTimeout
+	if args.WaitTimeoutSeconds > 0 {
+		timeout = time.Duration(args.WaitTimeoutSeconds) * time.Second
+	}
+	if _, err := pkgaddon.WaitAddonReady(ctx, u.kubeClient, name, timeout); err != nil && !errors.Is(err, pkgaddon.ErrWaitAddonReadyTimeout) {
+		return err
+	}
+	return nil
+}
+
 func (u *defaultAddonHandler) DisableAddon(ctx context.Context, name string) error {
 	err := pkgaddon.DisableAddon(ctx, u.kubeClient, name)
 	if err != nil {
@@ -369,7 +400,7 @@ func (u *defaultAddonHandler) DisableAddon(ctx context.Context, name string) err
 
 func (u *defaultAddonHandler) ListEnabledAddon(ctx context.Context) ([]*apis.AddonBaseStatus, error) {
 	apps := &v1beta1.ApplicationList{}
-	if err := u.kubeClient.List(ctx, apps, client.InNamespace(types.DefaultKubeVelaNS), client.HasLabels{oam.LabelAddonName}); err != nil {
+	if err := u.kubeClient.List(ctx, apps, client.HasLabels{oam.LabelAddonName}); err != nil {
 		return nil, err
 	}
 	var response []*apis.AddonBaseStatus
@@ -389,15 +420,13 @@ func (u *defaultAddonHandler) ListEnabledAddon(ctx context.Context) ([]*apis.Add
 
 func (u *defaultAddonHandler) UpdateAddon(ctx context.Context, name string, args apis.EnableAddonRequest) error {
 
-	var app v1beta1.Application
 	// check addon application whether exist
-	err := u.kubeClient.Get(context.Background(), client.ObjectKey{
-		Namespace: types.DefaultKubeVelaNS,
-		Name:      pkgaddon.Convert2AppName(name),
-	}, &app)
-	if err != nil {
+	if _, err := pkgaddon.FetchAddonRelatedApp(ctx, u.kubeClient, name); err != nil {
 		return err
 	}
+	if err := pkgaddon.ValidateImageRegistry(args.ImageRegistry); err != nil {
+		return bcode.ErrAddonInvalidImageRegistry
+	}
 
 	registries, err := u.addonRegistryDS.ListRegistries(ctx)
 	if err != nil {
@@ -405,7 +434,7 @@ func (u *defaultAddonHandler) UpdateAddon(ctx context.Context, name string, args
 	}
 
 	for _, r := range registries {
-		err = pkgaddon.EnableAddon(ctx, name, u.kubeClient, u.apply, u.config, r, args.Args, u.addonRegistryCache)
+		err = pkgaddon.EnableAddon(ctx, name, u.kubeClient, u.apply, u.config, r, args.Args, u.addonRegistryCache, args.Namespace, args.SkipDependencyInstall, args.ImageRegistry)
 		if err == nil {
 			return nil
 		}
@@ -45,6 +45,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/apiserver/rest/utils"
 	"github.com/oam-dev/kubevela/pkg/apiserver/rest/utils/bcode"
 	"github.com/oam-dev/kubevela/pkg/oam"
+	"github.com/oam-dev/kubevela/pkg/policy/envbinding"
 	utils2 "github.com/oam-dev/kubevela/pkg/utils"
 	"github.com/oam-dev/kubevela/pkg/utils/apply"
 )
@@ -370,8 +371,12 @@ func (c *applicationUsecaseImpl) CreateApplication(ctx context.Context, req apis
 
 // CreateApplicationTrigger create application trigger
 func (c *applicationUsecaseImpl) CreateApplicationTrigger(ctx context.Context, app *model.Application, req apisv1.CreateApplicationTriggerRequest) (*apisv1.ApplicationTriggerBase, error) {
-	if (req.PayloadType == model.PayloadTypeACR || req.PayloadType == model.PayloadTypeDockerhub) && req.ComponentName == "" {
-		return nil, bcode.ErrApplicationComponetNotExist
+	switch req.PayloadType {
+	// the triggers of the image registries must specify the component to upgrade
+	case model.PayloadTypeACR, model.PayloadTypeDockerhub, model.PayloadTypeGitLab, model.PayloadTypeQuay, model.PayloadTypeECR:
+		if req.ComponentName == "" {
+			return nil, bcode.ErrApplicationComponetNotExist
+		}
 	}
 	trigger := &model.ApplicationTrigger{
 		AppPrimaryKey: app.Name,
@@ -381,6 +386,8 @@ func (c *applicationUsecaseImpl) CreateApplicationTrigger(ctx context.Context, a
 		Description:   req.Description,
 		Type:          req.Type,
 		PayloadType:   req.PayloadType,
+		ComponentName: req.ComponentName,
+		TagStrategy:   req.TagStrategy,
 		Token:         genWebhookToken(),
 	}
 	if err := c.ds.Add(ctx, trigger); err != nil {
@@ -397,6 +404,7 @@ func (c *applicationUsecaseImpl) CreateApplicationTrigger(ctx context.Context, a
 		PayloadType:   req.PayloadType,
 		Token:         trigger.Token,
 		ComponentName: req.ComponentName,
+		TagStrategy:   req.TagStrategy,
 		CreateTime:    trigger.CreateTime,
 		UpdateTime:    trigger.UpdateTime,
 	}, nil
@@ -436,15 +444,17 @@ func (c *applicationUsecaseImpl) ListApplicationTriggers(ctx context.Context, ap
 		trigger, ok := raw.(*model.ApplicationTrigger)
 		if ok {
 			resp = append(resp, &apisv1.ApplicationTriggerBase{
-				WorkflowName: trigger.WorkflowName,
-				Name:         trigger.Name,
-				Alias:        trigger.Alias,
-				Description:  trigger.Description,
-				Type:         trigger.Type,
-				PayloadType:  trigger.PayloadType,
-				Token:        trigger.Token,
-				UpdateTime:   trigger.UpdateTime,
-				CreateTime:   trigger.CreateTime,
+				WorkflowName:  trigger.WorkflowName,
+				Name:          trigger.Name,
+				Alias:         trigger.Alias,
+				Description:   trigger.Description,
+				Type:          trigger.Type,
+				PayloadType:   trigger.PayloadType,
+				Token:         trigger.Token,
+				ComponentName: trigger.ComponentName,
+				TagStrategy:   trigger.TagStrategy,
+				UpdateTime:    trigger.UpdateTime,
+				CreateTime:    trigger.CreateTime,
 			})
 		}
 	}
@@ -749,6 +759,7 @@ func (c *applicationUsecaseImpl) Deploy(ctx context.Context, app *model.Applicat
 
 	return &apisv1.ApplicationDeployResponse{
 		ApplicationRevisionBase: c.converRevisionModelToBase(appRevision),
+		PublishVersion:          oamApp.Annotations[oam.AnnotationPublishVersion],
 	}, nil
 }
 
@@ -1394,6 +1405,37 @@ func (c *applicationUsecaseImpl) createTargetClusterEnv(ctx context.Context, env
 			}
 		}
 	}
+	// merge the component properties patched in the env, such as the ones upgraded by webhook
+	for _, patch := range envBind.ComponentsPatch {
+		if patch.Disable || patch.Properties == nil {
+			continue
+		}
+		merged := false
+		for i := range componentPatchs {
+			if componentPatchs[i].Name != patch.Name {
+				continue
+			}
+			properties, err := envbinding.MergeRawExtension(componentPatchs[i].Properties, patch.Properties.RawExtension())
+			if err != nil {
+				log.Logger.Errorf("merge the patch of component %s in env %s failure %s", patch.Name, envBind.Name, err.Error())
+			} else {
+				componentPatchs[i].Properties = properties
+			}
+			merged = true
+		}
+		if merged {
+			continue
+		}
+		for _, component := range components {
+			if component.Name == patch.Name {
+				componentPatchs = append(componentPatchs, v1alpha1.EnvComponentPatch{
+					Name:       component.Name,
+					Properties: patch.Properties.RawExtension(),
+					Type:       component.Type,
+				})
+			}
+		}
+	}
 
 	return v1alpha1.EnvConfig{
 		Name:      genPolicyEnvName(target.Name),
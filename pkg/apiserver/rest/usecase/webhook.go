@@ -18,18 +18,26 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/emicklei/go-restful/v3"
+	"github.com/hashicorp/go-version"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/oam-dev/kubevela/pkg/apiserver/datastore"
 	"github.com/oam-dev/kubevela/pkg/apiserver/log"
 	"github.com/oam-dev/kubevela/pkg/apiserver/model"
 	apisv1 "github.com/oam-dev/kubevela/pkg/apiserver/rest/apis/v1"
 	"github.com/oam-dev/kubevela/pkg/apiserver/rest/utils/bcode"
+	"github.com/oam-dev/kubevela/pkg/oam/util"
 	"github.com/oam-dev/kubevela/pkg/policy/envbinding"
 )
 
@@ -46,6 +54,14 @@ type webhookUsecaseImpl struct {
 // WebhookHandlers is the webhook handlers
 var WebhookHandlers []string
 
+// deployRetryBackoff bounds the retries of the deployment triggered by webhook
+var deployRetryBackoff = wait.Backoff{
+	Steps:    4,
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
 // NewWebhookUsecase new webhook usecase
 func NewWebhookUsecase(ds datastore.DataStore,
 	applicationUsecase ApplicationUsecase,
@@ -60,6 +76,10 @@ func NewWebhookUsecase(ds datastore.DataStore,
 func registerHandlers() {
 	new(customHandlerImpl).install()
 	new(acrHandlerImpl).install()
+	new(gitlabHandlerImpl).install()
+	new(quayHandlerImpl).install()
+	new(ecrHandlerImpl).install()
+	new(imageHandlerImpl).install()
 }
 
 type webhookHandler interface {
@@ -77,6 +97,26 @@ type acrHandlerImpl struct {
 	w   *webhookUsecaseImpl
 }
 
+type gitlabHandlerImpl struct {
+	req apisv1.HandleApplicationTriggerGitLabRequest
+	w   *webhookUsecaseImpl
+}
+
+type quayHandlerImpl struct {
+	req apisv1.HandleApplicationTriggerQuayRequest
+	w   *webhookUsecaseImpl
+}
+
+type ecrHandlerImpl struct {
+	req apisv1.HandleApplicationTriggerECRRequest
+	w   *webhookUsecaseImpl
+}
+
+type imageHandlerImpl struct {
+	req apisv1.HandleApplicationTriggerImageRequest
+	w   *webhookUsecaseImpl
+}
+
 func (c *webhookUsecaseImpl) newCustomHandler(req *restful.Request) (webhookHandler, error) {
 	var webhookReq apisv1.HandleApplicationTriggerWebhookRequest
 	if err := req.ReadEntity(&webhookReq); err != nil {
@@ -99,6 +139,66 @@ func (c *webhookUsecaseImpl) newACRHandler(req *restful.Request) (webhookHandler
 	}, nil
 }
 
+// gitlabTokenHeader is the header GitLab sends the secret token of the webhook in
+const gitlabTokenHeader = "X-Gitlab-Token"
+
+func (c *webhookUsecaseImpl) newGitLabHandler(req *restful.Request, webhookTrigger *model.ApplicationTrigger) (webhookHandler, error) {
+	// the secret token of the GitLab webhook must be set to the token of the trigger
+	if req.HeaderParameter(gitlabTokenHeader) != webhookTrigger.Token {
+		return nil, bcode.ErrInvalidWebhookToken
+	}
+	var gitlabReq apisv1.HandleApplicationTriggerGitLabRequest
+	if err := req.ReadEntity(&gitlabReq); err != nil {
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+	return &gitlabHandlerImpl{
+		req: gitlabReq,
+		w:   c,
+	}, nil
+}
+
+func (c *webhookUsecaseImpl) newQuayHandler(req *restful.Request) (webhookHandler, error) {
+	var quayReq apisv1.HandleApplicationTriggerQuayRequest
+	if err := req.ReadEntity(&quayReq); err != nil {
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+	return &quayHandlerImpl{
+		req: quayReq,
+		w:   c,
+	}, nil
+}
+
+// ecrImageActionDetailType is the detail type of the ECR image push and delete events
+const ecrImageActionDetailType = "ECR Image Action"
+
+func (c *webhookUsecaseImpl) newECRHandler(req *restful.Request) (webhookHandler, error) {
+	var ecrReq apisv1.HandleApplicationTriggerECRRequest
+	if err := req.ReadEntity(&ecrReq); err != nil {
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+	if ecrReq.DetailType != ecrImageActionDetailType {
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+	return &ecrHandlerImpl{
+		req: ecrReq,
+		w:   c,
+	}, nil
+}
+
+func (c *webhookUsecaseImpl) newImageHandler(req *restful.Request) (webhookHandler, error) {
+	var imageReq apisv1.HandleApplicationTriggerImageRequest
+	if err := req.ReadEntity(&imageReq); err != nil {
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+	if imageReq.Image == "" {
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+	return &imageHandlerImpl{
+		req: imageReq,
+		w:   c,
+	}, nil
+}
+
 func (c *webhookUsecaseImpl) HandleApplicationWebhook(ctx context.Context, token string, req *restful.Request) (*apisv1.ApplicationDeployResponse, error) {
 	webhookTrigger := &model.ApplicationTrigger{
 		Token: token,
@@ -113,6 +213,7 @@ func (c *webhookUsecaseImpl) HandleApplicationWebhook(ctx context.Context, token
 		Name: webhookTrigger.AppPrimaryKey,
 	}
 	if err := c.ds.Get(ctx, app); err != nil {
+		// the token is valid, but the application of the trigger is deleted
 		if errors.Is(err, datastore.ErrRecordNotExist) {
 			return nil, bcode.ErrApplicationNotExist
 		}
@@ -132,6 +233,26 @@ func (c *webhookUsecaseImpl) HandleApplicationWebhook(ctx context.Context, token
 		if err != nil {
 			return nil, err
 		}
+	case model.PayloadTypeGitLab:
+		handler, err = c.newGitLabHandler(req, webhookTrigger)
+		if err != nil {
+			return nil, err
+		}
+	case model.PayloadTypeQuay:
+		handler, err = c.newQuayHandler(req)
+		if err != nil {
+			return nil, err
+		}
+	case model.PayloadTypeECR:
+		handler, err = c.newECRHandler(req)
+		if err != nil {
+			return nil, err
+		}
+	case model.PayloadTypeImage:
+		handler, err = c.newImageHandler(req)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, bcode.ErrInvalidWebhookPayloadType
 	}
@@ -139,8 +260,90 @@ func (c *webhookUsecaseImpl) HandleApplicationWebhook(ctx context.Context, token
 	return handler.handle(ctx, webhookTrigger, app)
 }
 
-func (c *webhookUsecaseImpl) patchComponentProperties(ctx context.Context, component *model.ApplicationComponent, patch *runtime.RawExtension) error {
-	merge, err := envbinding.MergeRawExtension(component.Properties.RawExtension(), patch)
+// isRetryableDeployError reports whether the deploy failure is transient, that is a conflict, a timeout or an
+// unavailable server. The other failures, such as ErrDeployApplyFail, would fail again and aren't retried.
+func isRetryableDeployError(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err)
+}
+
+// deploy deploys the application and retries with backoff if the failure is transient
+func (c *webhookUsecaseImpl) deploy(ctx context.Context, app *model.Application, req apisv1.ApplicationDeployRequest) (*apisv1.ApplicationDeployResponse, error) {
+	var res *apisv1.ApplicationDeployResponse
+	attempts := 0
+	err := retry.OnError(deployRetryBackoff, isRetryableDeployError, func() error {
+		attempts++
+		var err error
+		res, err = c.applicationUsecase.Deploy(ctx, app, req)
+		if err != nil && isRetryableDeployError(err) {
+			log.Logger.Warnf("deploy app %s triggered by webhook failure at attempt %d: %s", app.PrimaryKey(), attempts, err.Error())
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Attempts = attempts
+	return res, nil
+}
+
+// getTriggerComponent returns the component upgraded by the trigger, it's the first component of the app if the trigger doesn't specify one
+func (c *webhookUsecaseImpl) getTriggerComponent(ctx context.Context, webhookTrigger *model.ApplicationTrigger) (*model.ApplicationComponent, error) {
+	if webhookTrigger.ComponentName != "" {
+		component := &model.ApplicationComponent{
+			AppPrimaryKey: webhookTrigger.AppPrimaryKey,
+			Name:          webhookTrigger.ComponentName,
+		}
+		if err := c.ds.Get(ctx, component); err != nil {
+			if errors.Is(err, datastore.ErrRecordNotExist) {
+				return nil, bcode.ErrApplicationComponetNotExist
+			}
+			return nil, err
+		}
+		return component, nil
+	}
+	comps, err := c.ds.List(ctx, &model.ApplicationComponent{AppPrimaryKey: webhookTrigger.AppPrimaryKey}, &datastore.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(comps) == 0 {
+		return nil, bcode.ErrApplicationComponetNotExist
+	}
+	return comps[0].(*model.ApplicationComponent), nil
+}
+
+// patchProperties patches the properties with the strategy, the properties are merged if the strategy is empty
+func patchProperties(base, patch *runtime.RawExtension, strategy string) (*runtime.RawExtension, error) {
+	switch strategy {
+	case "", apisv1.PatchStrategyMerge:
+		return envbinding.MergeRawExtension(base, patch)
+	case apisv1.PatchStrategyReplace:
+		baseProperties, err := util.RawExtension2Map(base)
+		if err != nil {
+			return nil, err
+		}
+		patchProperties, err := util.RawExtension2Map(patch)
+		if err != nil {
+			return nil, err
+		}
+		if baseProperties == nil {
+			baseProperties = map[string]interface{}{}
+		}
+		for k, v := range patchProperties {
+			baseProperties[k] = v
+		}
+		raw, err := json.Marshal(baseProperties)
+		if err != nil {
+			return nil, err
+		}
+		return &runtime.RawExtension{Raw: raw}, nil
+	default:
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+}
+
+func (c *webhookUsecaseImpl) patchComponentProperties(ctx context.Context, component *model.ApplicationComponent, patch *runtime.RawExtension, strategy string) error {
+	merge, err := patchProperties(component.Properties.RawExtension(), patch, strategy)
 	if err != nil {
 		return err
 	}
@@ -155,7 +358,50 @@ func (c *webhookUsecaseImpl) patchComponentProperties(ctx context.Context, compo
 	return nil
 }
 
+// patchEnvComponentProperties patches the properties patch of the component in the env binding with the strategy,
+// the properties patch is still merged into the base properties when the application is rendered
+func patchEnvComponentProperties(envBinding *model.EnvBinding, component string, patch *runtime.RawExtension, strategy string) error {
+	for i, componentPatch := range envBinding.ComponentsPatch {
+		if componentPatch.Name != component {
+			continue
+		}
+		var base *runtime.RawExtension
+		if componentPatch.Properties != nil {
+			base = componentPatch.Properties.RawExtension()
+		}
+		merge, err := patchProperties(base, patch, strategy)
+		if err != nil {
+			return err
+		}
+		prop, err := model.NewJSONStructByStruct(merge)
+		if err != nil {
+			return err
+		}
+		envBinding.ComponentsPatch[i].Properties = prop
+		return nil
+	}
+	prop, err := model.NewJSONStructByStruct(patch)
+	if err != nil {
+		return err
+	}
+	envBinding.ComponentsPatch = append(envBinding.ComponentsPatch, model.ComponentPatch{Name: component, Properties: prop})
+	return nil
+}
+
 func (c *customHandlerImpl) handle(ctx context.Context, webhookTrigger *model.ApplicationTrigger, app *model.Application) (*apisv1.ApplicationDeployResponse, error) {
+	var envBinding *model.EnvBinding
+	if c.req.Env != "" {
+		envBinding = &model.EnvBinding{
+			AppPrimaryKey: app.PrimaryKey(),
+			Name:          c.req.Env,
+		}
+		if err := c.w.ds.Get(ctx, envBinding); err != nil {
+			if errors.Is(err, datastore.ErrRecordNotExist) {
+				return nil, bcode.ErrEnvBindingNotExist
+			}
+			return nil, err
+		}
+	}
 	for comp, properties := range c.req.Upgrade {
 		component := &model.ApplicationComponent{
 			AppPrimaryKey: webhookTrigger.AppPrimaryKey,
@@ -167,11 +413,22 @@ func (c *customHandlerImpl) handle(ctx context.Context, webhookTrigger *model.Ap
 			}
 			return nil, err
 		}
-		if err := c.w.patchComponentProperties(ctx, component, properties.RawExtension()); err != nil {
+		if envBinding != nil {
+			if err := patchEnvComponentProperties(envBinding, comp, properties.RawExtension(), c.req.PatchStrategy); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := c.w.patchComponentProperties(ctx, component, properties.RawExtension(), c.req.PatchStrategy); err != nil {
 			return nil, err
 		}
 	}
-	return c.w.applicationUsecase.Deploy(ctx, app, apisv1.ApplicationDeployRequest{
+	if envBinding != nil {
+		if err := c.w.ds.Put(ctx, envBinding); err != nil {
+			return nil, err
+		}
+	}
+	return c.w.deploy(ctx, app, apisv1.ApplicationDeployRequest{
 		WorkflowName: webhookTrigger.WorkflowName,
 		Note:         "triggered by webhook custom",
 		TriggerType:  apisv1.TriggerTypeWebhook,
@@ -199,14 +456,14 @@ func (c *acrHandlerImpl) handle(ctx context.Context, webhookTrigger *model.Appli
 	// use the first component as the target component
 	component := comps[0].(*model.ApplicationComponent)
 	acrReq := c.req
-	image := fmt.Sprintf("registry.%s.aliyuncs.com/%s:%s", acrReq.Repository.Region, acrReq.Repository.RepoFullName, acrReq.PushData.Tag)
+	image := fmt.Sprintf("%s/%s:%s", getACRRegistryHost(acrReq.Repository), acrReq.Repository.RepoFullName, acrReq.PushData.Tag)
 	if err := c.w.patchComponentProperties(ctx, component, &runtime.RawExtension{
 		Raw: []byte(fmt.Sprintf(`{"image": "%s"}`, image)),
-	}); err != nil {
+	}, apisv1.PatchStrategyMerge); err != nil {
 		return nil, err
 	}
 
-	return c.w.applicationUsecase.Deploy(ctx, app, apisv1.ApplicationDeployRequest{
+	return c.w.deploy(ctx, app, apisv1.ApplicationDeployRequest{
 		WorkflowName: webhookTrigger.WorkflowName,
 		Note:         "triggered by webhook acr",
 		TriggerType:  apisv1.TriggerTypeWebhook,
@@ -231,10 +488,269 @@ func (c *acrHandlerImpl) handle(ctx context.Context, webhookTrigger *model.Appli
 	})
 }
 
+// getACRRegistryHost returns the registry host of the ACR repository. An instance of the enterprise edition has its own
+// domain, the public domain of the region is used by the personal edition or if the instance name is not in the payload.
+func getACRRegistryHost(repo apisv1.ACRRepository) string {
+	if repo.InstanceName != "" {
+		return fmt.Sprintf("%s-registry.%s.cr.aliyuncs.com", repo.InstanceName, repo.Region)
+	}
+	return fmt.Sprintf("registry.%s.aliyuncs.com", repo.Region)
+}
+
 func (c *acrHandlerImpl) install() {
 	WebhookHandlers = append(WebhookHandlers, model.PayloadTypeACR)
 }
 
+func (c *gitlabHandlerImpl) handle(ctx context.Context, webhookTrigger *model.ApplicationTrigger, app *model.Application) (*apisv1.ApplicationDeployResponse, error) {
+	// GitLab also notifies the pull and delete events, only the pushed image upgrades the application
+	if c.req.EventName != "push" {
+		return &apisv1.ApplicationDeployResponse{}, nil
+	}
+	var pushed *apisv1.GitLabEvent
+	for i, event := range c.req.Events {
+		if event.Target.Repository != "" && event.Target.Tag != "" {
+			pushed = &c.req.Events[i]
+			break
+		}
+	}
+	if pushed == nil {
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+
+	component, err := c.w.getTriggerComponent(ctx, webhookTrigger)
+	if err != nil {
+		return nil, err
+	}
+	image := fmt.Sprintf("%s:%s", pushed.Target.Repository, pushed.Target.Tag)
+	if pushed.Request.Host != "" {
+		image = fmt.Sprintf("%s/%s", pushed.Request.Host, image)
+	}
+	if err := c.w.patchComponentProperties(ctx, component, &runtime.RawExtension{
+		Raw: []byte(fmt.Sprintf(`{"image": "%s"}`, image)),
+	}, apisv1.PatchStrategyMerge); err != nil {
+		return nil, err
+	}
+
+	return c.w.deploy(ctx, app, apisv1.ApplicationDeployRequest{
+		WorkflowName: webhookTrigger.WorkflowName,
+		Note:         "triggered by webhook gitlab",
+		TriggerType:  apisv1.TriggerTypeWebhook,
+		Force:        true,
+		ImageInfo: &model.ImageInfo{
+			Type: model.PayloadTypeGitLab,
+			Resource: &model.ImageResource{
+				Digest: pushed.Target.Digest,
+				Tag:    pushed.Target.Tag,
+				URL:    image,
+			},
+			Repository: &model.ImageRepository{
+				Name:     path.Base(pushed.Target.Repository),
+				FullName: pushed.Target.Repository,
+			},
+		},
+	})
+}
+
+func (c *gitlabHandlerImpl) install() {
+	WebhookHandlers = append(WebhookHandlers, model.PayloadTypeGitLab)
+}
+
+func (c *quayHandlerImpl) handle(ctx context.Context, webhookTrigger *model.ApplicationTrigger, app *model.Application) (*apisv1.ApplicationDeployResponse, error) {
+	quayReq := c.req
+	if quayReq.DockerURL == "" || len(quayReq.UpdatedTags) == 0 {
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+	component, err := c.w.getTriggerComponent(ctx, webhookTrigger)
+	if err != nil {
+		return nil, err
+	}
+	tag := selectTag(quayReq.UpdatedTags, webhookTrigger.TagStrategy)
+	image := fmt.Sprintf("%s:%s", quayReq.DockerURL, tag)
+	if err := c.w.patchComponentProperties(ctx, component, &runtime.RawExtension{
+		Raw: []byte(fmt.Sprintf(`{"image": "%s"}`, image)),
+	}, apisv1.PatchStrategyMerge); err != nil {
+		return nil, err
+	}
+
+	return c.w.deploy(ctx, app, apisv1.ApplicationDeployRequest{
+		WorkflowName: webhookTrigger.WorkflowName,
+		Note:         "triggered by webhook quay",
+		TriggerType:  apisv1.TriggerTypeWebhook,
+		Force:        true,
+		ImageInfo: &model.ImageInfo{
+			Type: model.PayloadTypeQuay,
+			Resource: &model.ImageResource{
+				Tag:  tag,
+				Tags: quayReq.UpdatedTags,
+				URL:  image,
+			},
+			Repository: &model.ImageRepository{
+				Name:      quayReq.Name,
+				Namespace: quayReq.Namespace,
+				FullName:  quayReq.Repository,
+			},
+		},
+	})
+}
+
+// selectTag selects the tag to upgrade to from the tags pushed at once by the strategy of the trigger,
+// the first tag is selected if no tag is a semantic version for the latest-semver strategy
+func selectTag(tags []string, strategy string) string {
+	selected := tags[0]
+	switch strategy {
+	case model.TagStrategyLongest:
+		for _, tag := range tags[1:] {
+			if len(tag) > len(selected) {
+				selected = tag
+			}
+		}
+	case model.TagStrategyLatestSemver:
+		var latest *version.Version
+		for _, tag := range tags {
+			v, err := version.NewSemver(tag)
+			if err != nil {
+				continue
+			}
+			if latest == nil || v.GreaterThan(latest) {
+				latest, selected = v, tag
+			}
+		}
+	}
+	return selected
+}
+
+func (c *quayHandlerImpl) install() {
+	WebhookHandlers = append(WebhookHandlers, model.PayloadTypeQuay)
+}
+
+func (c *ecrHandlerImpl) handle(ctx context.Context, webhookTrigger *model.ApplicationTrigger, app *model.Application) (*apisv1.ApplicationDeployResponse, error) {
+	ecrReq := c.req
+	// the deleted images and the failed pushes don't upgrade the application
+	if ecrReq.Detail.ActionType != "PUSH" || ecrReq.Detail.Result != "SUCCESS" {
+		return &apisv1.ApplicationDeployResponse{}, nil
+	}
+	if ecrReq.Account == "" || ecrReq.Region == "" || ecrReq.Detail.RepositoryName == "" || ecrReq.Detail.ImageTag == "" {
+		return nil, bcode.ErrInvalidWebhookPayloadBody
+	}
+	component, err := c.w.getTriggerComponent(ctx, webhookTrigger)
+	if err != nil {
+		return nil, err
+	}
+	image := fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com/%s:%s", ecrReq.Account, ecrReq.Region, ecrReq.Detail.RepositoryName, ecrReq.Detail.ImageTag)
+	if err := c.w.patchComponentProperties(ctx, component, &runtime.RawExtension{
+		Raw: []byte(fmt.Sprintf(`{"image": "%s"}`, image)),
+	}, apisv1.PatchStrategyMerge); err != nil {
+		return nil, err
+	}
+
+	// the push time is left empty if EventBridge sends an invalid time
+	pushedAt, _ := time.Parse(time.RFC3339, ecrReq.Time)
+	return c.w.deploy(ctx, app, apisv1.ApplicationDeployRequest{
+		WorkflowName: webhookTrigger.WorkflowName,
+		Note:         "triggered by webhook ecr",
+		TriggerType:  apisv1.TriggerTypeWebhook,
+		Force:        true,
+		ImageInfo: &model.ImageInfo{
+			Type: model.PayloadTypeECR,
+			Resource: &model.ImageResource{
+				Digest:     ecrReq.Detail.ImageDigest,
+				Tag:        ecrReq.Detail.ImageTag,
+				URL:        image,
+				CreateTime: pushedAt,
+			},
+			Repository: &model.ImageRepository{
+				Name:     ecrReq.Detail.RepositoryName,
+				FullName: ecrReq.Detail.RepositoryName,
+				Region:   ecrReq.Region,
+			},
+		},
+	})
+}
+
+func (c *ecrHandlerImpl) install() {
+	WebhookHandlers = append(WebhookHandlers, model.PayloadTypeECR)
+}
+
+func (c *imageHandlerImpl) handle(ctx context.Context, webhookTrigger *model.ApplicationTrigger, app *model.Application) (*apisv1.ApplicationDeployResponse, error) {
+	imageReq := c.req
+	// the component in the payload takes precedence over the component of the trigger
+	trigger := *webhookTrigger
+	if imageReq.Component != "" {
+		trigger.ComponentName = imageReq.Component
+	}
+	component, err := c.w.getTriggerComponent(ctx, &trigger)
+	if err != nil {
+		return nil, err
+	}
+	imageField := imageReq.ImageField
+	if imageField == "" {
+		imageField = "image"
+	}
+	patch, err := newFieldPatch(imageField, imageReq.Image)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.w.patchComponentProperties(ctx, component, patch, apisv1.PatchStrategyMerge); err != nil {
+		return nil, err
+	}
+
+	repository, tag, digest := parseImage(imageReq.Image)
+	return c.w.deploy(ctx, app, apisv1.ApplicationDeployRequest{
+		WorkflowName: webhookTrigger.WorkflowName,
+		Note:         "triggered by webhook image",
+		TriggerType:  apisv1.TriggerTypeWebhook,
+		Force:        true,
+		CodeInfo:     imageReq.CodeInfo,
+		ImageInfo: &model.ImageInfo{
+			Type: model.PayloadTypeImage,
+			Resource: &model.ImageResource{
+				Digest: digest,
+				Tag:    tag,
+				URL:    imageReq.Image,
+			},
+			Repository: &model.ImageRepository{
+				Name:     path.Base(repository),
+				FullName: repository,
+			},
+		},
+	})
+}
+
+func (c *imageHandlerImpl) install() {
+	WebhookHandlers = append(WebhookHandlers, model.PayloadTypeImage)
+}
+
+// newFieldPatch builds the patch which sets the field to the value, the field is the path of the properties joined by dots
+func newFieldPatch(field string, value interface{}) (*runtime.RawExtension, error) {
+	keys := strings.Split(field, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, bcode.ErrInvalidWebhookPayloadBody
+		}
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = map[string]interface{}{keys[i]: value}
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.RawExtension{Raw: raw}, nil
+}
+
+// parseImage splits the image into the repository, tag and digest, the tag and digest are empty if they are not set
+func parseImage(image string) (repository, tag, digest string) {
+	repository = image
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository, digest = repository[:i], repository[i+1:]
+	}
+	// the colon before the last slash separates the port of the registry host
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+	return repository, tag, digest
+}
+
 func parseTimeString(t string) time.Time {
 	if t == "" {
 		return time.Time{}
@@ -20,11 +20,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/emicklei/go-restful/v3"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/oam-dev/kubevela/pkg/apiserver/datastore"
 	"github.com/oam-dev/kubevela/pkg/apiserver/model"
@@ -136,6 +140,9 @@ var _ = Describe("Test application usecase function", func() {
 		Expect(err).Should(BeNil())
 		res, err := webhookUsecase.HandleApplicationWebhook(context.TODO(), triggers[0].Token, restful.NewRequest(httpreq))
 		Expect(err).Should(BeNil())
+		Expect(res.Attempts).Should(Equal(1))
+		Expect(res.Version).ShouldNot(BeEmpty())
+		Expect(res.PublishVersion).ShouldNot(BeEmpty())
 		comp, err := appUsecase.GetApplicationComponent(context.TODO(), appModel, "component-name-webhook")
 		Expect(err).Should(BeNil())
 		Expect((*comp.Properties)["image"]).Should(Equal("test-image"))
@@ -153,6 +160,184 @@ var _ = Describe("Test application usecase function", func() {
 		Expect(revision.CodeInfo.Branch).Should(Equal("test-branch"))
 		Expect(revision.CodeInfo.User).Should(Equal("test-user"))
 
+		By("Test HandleApplicationWebhook function with custom payload for an env")
+		envReqBody := apisv1.HandleApplicationTriggerWebhookRequest{
+			Upgrade: map[string]*model.JSONStruct{
+				"component-name-webhook": {"image": "env-image"},
+			},
+			Env: "not-exist-env",
+		}
+		body, err = json.Marshal(envReqBody)
+		Expect(err).Should(BeNil())
+		httpreq, err = http.NewRequest("post", "/", bytes.NewBuffer(body))
+		httpreq.Header.Add(restful.HEADER_ContentType, "application/json")
+		Expect(err).Should(BeNil())
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), triggers[0].Token, restful.NewRequest(httpreq))
+		Expect(err).Should(Equal(bcode.ErrEnvBindingNotExist))
+
+		envReqBody.Env = "webhook-dev"
+		body, err = json.Marshal(envReqBody)
+		Expect(err).Should(BeNil())
+		httpreq, err = http.NewRequest("post", "/", bytes.NewBuffer(body))
+		httpreq.Header.Add(restful.HEADER_ContentType, "application/json")
+		Expect(err).Should(BeNil())
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), triggers[0].Token, restful.NewRequest(httpreq))
+		Expect(err).Should(BeNil())
+		comp, err = appUsecase.GetApplicationComponent(context.TODO(), appModel, "component-name-webhook")
+		Expect(err).Should(BeNil())
+		Expect((*comp.Properties)["image"]).Should(Equal("test-image"))
+		envBinding := &model.EnvBinding{AppPrimaryKey: appModel.PrimaryKey(), Name: "webhook-dev"}
+		Expect(webhookUsecase.ds.Get(context.TODO(), envBinding)).Should(BeNil())
+		Expect(len(envBinding.ComponentsPatch)).Should(Equal(1))
+		Expect((*envBinding.ComponentsPatch[0].Properties)["image"]).Should(Equal("env-image"))
+
+		By("Test HandleApplicationWebhook function with GitLab payload")
+		gitlabTrigger, err := appUsecase.CreateApplicationTrigger(context.TODO(), appModel, apisv1.CreateApplicationTriggerRequest{
+			Name:          "test-gitlab",
+			PayloadType:   model.PayloadTypeGitLab,
+			Type:          "webhook",
+			ComponentName: "component-name-webhook",
+		})
+		Expect(err).Should(BeNil())
+		gitlabRequest := func(body apisv1.HandleApplicationTriggerGitLabRequest, token string) *restful.Request {
+			data, err := json.Marshal(body)
+			Expect(err).Should(BeNil())
+			httpreq, err := http.NewRequest("post", "/", bytes.NewBuffer(data))
+			Expect(err).Should(BeNil())
+			httpreq.Header.Add(restful.HEADER_ContentType, "application/json")
+			httpreq.Header.Add("X-Gitlab-Token", token)
+			return restful.NewRequest(httpreq)
+		}
+		gitlabBody := apisv1.HandleApplicationTriggerGitLabRequest{
+			EventName: "push",
+			Events: []apisv1.GitLabEvent{{
+				Action:  "push",
+				Target:  apisv1.GitLabTarget{Repository: "group/project", Tag: "v1.0.0", Digest: "sha256:abc"},
+				Request: apisv1.GitLabRequest{Host: "registry.gitlab.com"},
+			}},
+		}
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), gitlabTrigger.Token, gitlabRequest(gitlabBody, "invalid-token"))
+		Expect(err).Should(Equal(bcode.ErrInvalidWebhookToken))
+		res, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), gitlabTrigger.Token, gitlabRequest(apisv1.HandleApplicationTriggerGitLabRequest{EventName: "delete"}, gitlabTrigger.Token))
+		Expect(err).Should(BeNil())
+		Expect(res.Version).Should(BeEmpty())
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), gitlabTrigger.Token, gitlabRequest(gitlabBody, gitlabTrigger.Token))
+		Expect(err).Should(BeNil())
+		comp, err = appUsecase.GetApplicationComponent(context.TODO(), appModel, "component-name-webhook")
+		Expect(err).Should(BeNil())
+		Expect((*comp.Properties)["image"]).Should(Equal("registry.gitlab.com/group/project:v1.0.0"))
+
+		By("Test HandleApplicationWebhook function with Quay payload")
+		quayTrigger, err := appUsecase.CreateApplicationTrigger(context.TODO(), appModel, apisv1.CreateApplicationTriggerRequest{
+			Name:          "test-quay",
+			PayloadType:   model.PayloadTypeQuay,
+			Type:          "webhook",
+			ComponentName: "component-name-webhook",
+		})
+		Expect(err).Should(BeNil())
+		quayBody := apisv1.HandleApplicationTriggerQuayRequest{
+			Repository: "test-namespace/test-repo",
+			Namespace:  "test-namespace",
+			Name:       "test-repo",
+			DockerURL:  "quay.io/test-namespace/test-repo",
+		}
+		body, err = json.Marshal(quayBody)
+		Expect(err).Should(BeNil())
+		httpreq, err = http.NewRequest("post", "/", bytes.NewBuffer(body))
+		Expect(err).Should(BeNil())
+		httpreq.Header.Add(restful.HEADER_ContentType, "application/json")
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), quayTrigger.Token, restful.NewRequest(httpreq))
+		Expect(err).Should(Equal(bcode.ErrInvalidWebhookPayloadBody))
+		quayBody.UpdatedTags = []string{"v2", "latest"}
+		body, err = json.Marshal(quayBody)
+		Expect(err).Should(BeNil())
+		httpreq, err = http.NewRequest("post", "/", bytes.NewBuffer(body))
+		Expect(err).Should(BeNil())
+		httpreq.Header.Add(restful.HEADER_ContentType, "application/json")
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), quayTrigger.Token, restful.NewRequest(httpreq))
+		Expect(err).Should(BeNil())
+		comp, err = appUsecase.GetApplicationComponent(context.TODO(), appModel, "component-name-webhook")
+		Expect(err).Should(BeNil())
+		Expect((*comp.Properties)["image"]).Should(Equal("quay.io/test-namespace/test-repo:v2"))
+
+		By("Test HandleApplicationWebhook function with ECR payload")
+		ecrTrigger, err := appUsecase.CreateApplicationTrigger(context.TODO(), appModel, apisv1.CreateApplicationTriggerRequest{
+			Name:          "test-ecr",
+			PayloadType:   model.PayloadTypeECR,
+			Type:          "webhook",
+			ComponentName: "component-name-webhook",
+		})
+		Expect(err).Should(BeNil())
+		ecrRequest := func(body apisv1.HandleApplicationTriggerECRRequest) *restful.Request {
+			data, err := json.Marshal(body)
+			Expect(err).Should(BeNil())
+			httpreq, err := http.NewRequest("post", "/", bytes.NewBuffer(data))
+			Expect(err).Should(BeNil())
+			httpreq.Header.Add(restful.HEADER_ContentType, "application/json")
+			return restful.NewRequest(httpreq)
+		}
+		ecrBody := apisv1.HandleApplicationTriggerECRRequest{
+			DetailType: "EC2 Instance State-change Notification",
+			Source:     "aws.ecr",
+			Account:    "123456789012",
+			Region:     "us-west-2",
+			Time:       "2021-11-16T01:54:34Z",
+			Detail: apisv1.ECRDetail{
+				Result:         "SUCCESS",
+				RepositoryName: "test-repo",
+				ImageDigest:    "sha256:abc",
+				ActionType:     "PUSH",
+				ImageTag:       "v3",
+			},
+		}
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), ecrTrigger.Token, ecrRequest(ecrBody))
+		Expect(err).Should(Equal(bcode.ErrInvalidWebhookPayloadBody))
+		ecrBody.DetailType = "ECR Image Action"
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), ecrTrigger.Token, ecrRequest(ecrBody))
+		Expect(err).Should(BeNil())
+		comp, err = appUsecase.GetApplicationComponent(context.TODO(), appModel, "component-name-webhook")
+		Expect(err).Should(BeNil())
+		Expect((*comp.Properties)["image"]).Should(Equal("123456789012.dkr.ecr.us-west-2.amazonaws.com/test-repo:v3"))
+
+		By("Test HandleApplicationWebhook function with image payload")
+		imageTrigger, err := appUsecase.CreateApplicationTrigger(context.TODO(), appModel, apisv1.CreateApplicationTriggerRequest{
+			Name:        "test-image",
+			PayloadType: model.PayloadTypeImage,
+			Type:        "webhook",
+		})
+		Expect(err).Should(BeNil())
+		imageRequest := func(body apisv1.HandleApplicationTriggerImageRequest) *restful.Request {
+			data, err := json.Marshal(body)
+			Expect(err).Should(BeNil())
+			httpreq, err := http.NewRequest("post", "/", bytes.NewBuffer(data))
+			Expect(err).Should(BeNil())
+			httpreq.Header.Add(restful.HEADER_ContentType, "application/json")
+			return restful.NewRequest(httpreq)
+		}
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), imageTrigger.Token, imageRequest(apisv1.HandleApplicationTriggerImageRequest{Component: "component-name-webhook"}))
+		Expect(err).Should(Equal(bcode.ErrInvalidWebhookPayloadBody))
+		res, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), imageTrigger.Token, imageRequest(apisv1.HandleApplicationTriggerImageRequest{
+			Image:     "registry.local:5000/team/app:v4",
+			Component: "component-name-webhook",
+		}))
+		Expect(err).Should(BeNil())
+		Expect(res.Version).ShouldNot(BeEmpty())
+		Expect(res.PublishVersion).ShouldNot(BeEmpty())
+		Expect(res.ImageInfo.Resource.Tag).Should(Equal("v4"))
+		comp, err = appUsecase.GetApplicationComponent(context.TODO(), appModel, "component-name-webhook")
+		Expect(err).Should(BeNil())
+		Expect((*comp.Properties)["image"]).Should(Equal("registry.local:5000/team/app:v4"))
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), imageTrigger.Token, imageRequest(apisv1.HandleApplicationTriggerImageRequest{
+			Image:      "team/sidecar:v1",
+			Component:  "component-name-webhook",
+			ImageField: "sidecar.image",
+		}))
+		Expect(err).Should(BeNil())
+		comp, err = appUsecase.GetApplicationComponent(context.TODO(), appModel, "component-name-webhook")
+		Expect(err).Should(BeNil())
+		Expect((*comp.Properties)["image"]).Should(Equal("registry.local:5000/team/app:v4"))
+		Expect((*comp.Properties)["sidecar"]).Should(Equal(map[string]interface{}{"image": "team/sidecar:v1"}))
+
 		By("Test HandleApplicationWebhook function with ACR payload")
 		_, err = appUsecase.CreateApplicationTrigger(context.TODO(), appModel, apisv1.CreateApplicationTriggerRequest{
 			Name:        "test-acr",
@@ -192,4 +377,109 @@ var _ = Describe("Test application usecase function", func() {
 		Expect(err).Should(BeNil())
 		Expect((*comp.Properties)["image"]).Should(Equal("registry.test-region.aliyuncs.com/test-namespace/test-repo:test-tag"))
 	})
+
+	It("Test HandleApplicationWebhook function with the application deleted", func() {
+		trigger := &model.ApplicationTrigger{
+			AppPrimaryKey: "test-app-deleted",
+			Name:          "trigger-app-deleted",
+			Token:         "token-app-deleted",
+			Type:          "webhook",
+			PayloadType:   model.PayloadTypeCustom,
+		}
+		Expect(webhookUsecase.ds.Add(context.TODO(), trigger)).Should(BeNil())
+		_, err := webhookUsecase.HandleApplicationWebhook(context.TODO(), trigger.Token, nil)
+		Expect(err).Should(Equal(bcode.ErrApplicationNotExist))
+		_, err = webhookUsecase.HandleApplicationWebhook(context.TODO(), "unknown-token", nil)
+		Expect(err).Should(Equal(bcode.ErrInvalidWebhookToken))
+		Expect(err.(*bcode.Bcode).HTTPCode).Should(Equal(int32(401)))
+		Expect(bcode.ErrApplicationNotExist.HTTPCode).Should(Equal(int32(404)))
+	})
+
+	It("Test getACRRegistryHost function", func() {
+		Expect(getACRRegistryHost(apisv1.ACRRepository{Region: "cn-hangzhou"})).Should(Equal("registry.cn-hangzhou.aliyuncs.com"))
+		Expect(getACRRegistryHost(apisv1.ACRRepository{Region: "cn-hangzhou", InstanceID: "cri-abc"})).Should(Equal("registry.cn-hangzhou.aliyuncs.com"))
+		Expect(getACRRegistryHost(apisv1.ACRRepository{Region: "cn-hangzhou", InstanceID: "cri-abc", InstanceName: "kubevela"})).
+			Should(Equal("kubevela-registry.cn-hangzhou.cr.aliyuncs.com"))
+	})
+
+	It("Test selectTag function", func() {
+		tags := []string{"v1.2", "v1.10.0", "latest", "v1.9.3-rc.1"}
+		Expect(selectTag(tags, "")).Should(Equal("v1.2"))
+		Expect(selectTag(tags, model.TagStrategyFirst)).Should(Equal("v1.2"))
+		Expect(selectTag(tags, model.TagStrategyLongest)).Should(Equal("v1.9.3-rc.1"))
+		Expect(selectTag(tags, model.TagStrategyLatestSemver)).Should(Equal("v1.10.0"))
+		Expect(selectTag([]string{"latest", "stable"}, model.TagStrategyLatestSemver)).Should(Equal("latest"))
+	})
+
+	It("Test parseImage function", func() {
+		repository, tag, digest := parseImage("registry.local:5000/team/app:v1@sha256:abc")
+		Expect(repository).Should(Equal("registry.local:5000/team/app"))
+		Expect(tag).Should(Equal("v1"))
+		Expect(digest).Should(Equal("sha256:abc"))
+		repository, tag, digest = parseImage("registry.local:5000/team/app")
+		Expect(repository).Should(Equal("registry.local:5000/team/app"))
+		Expect(tag).Should(BeEmpty())
+		Expect(digest).Should(BeEmpty())
+
+		patch, err := newFieldPatch("values.image.repository", "nginx")
+		Expect(err).Should(BeNil())
+		Expect(string(patch.Raw)).Should(Equal(`{"values":{"image":{"repository":"nginx"}}}`))
+		_, err = newFieldPatch("values..image", "nginx")
+		Expect(err).Should(Equal(bcode.ErrInvalidWebhookPayloadBody))
+	})
+
+	It("Test patchProperties function", func() {
+		base := &runtime.RawExtension{Raw: []byte(`{"image":"nginx","env":[{"name":"A","value":"1"}],"resources":{"cpu":"1","memory":"1Gi"}}`)}
+		patch := &runtime.RawExtension{Raw: []byte(`{"env":[{"name":"B","value":"2"}],"resources":{"cpu":"2"}}`)}
+		merged, err := patchProperties(base, patch, "")
+		Expect(err).Should(BeNil())
+		Expect(string(merged.Raw)).Should(Equal(`{"env":[{"name":"B","value":"2"}],"image":"nginx","resources":{"cpu":"2","memory":"1Gi"}}`))
+		replaced, err := patchProperties(base, patch, apisv1.PatchStrategyReplace)
+		Expect(err).Should(BeNil())
+		Expect(string(replaced.Raw)).Should(Equal(`{"env":[{"name":"B","value":"2"}],"image":"nginx","resources":{"cpu":"2"}}`))
+		_, err = patchProperties(base, patch, "unknown")
+		Expect(err).Should(Equal(bcode.ErrInvalidWebhookPayloadBody))
+	})
+
+	It("Test isRetryableDeployError function", func() {
+		Expect(isRetryableDeployError(apierrors.NewConflict(schema.GroupResource{Resource: "applications"}, "app", fmt.Errorf("conflict")))).Should(BeTrue())
+		Expect(isRetryableDeployError(apierrors.NewServiceUnavailable("unavailable"))).Should(BeTrue())
+		Expect(isRetryableDeployError(apierrors.NewTimeoutError("timeout", 1))).Should(BeTrue())
+		Expect(isRetryableDeployError(bcode.ErrDeployApplyFail)).Should(BeFalse())
+		Expect(isRetryableDeployError(bcode.ErrApplicationComponetNotExist)).Should(BeFalse())
+		Expect(isRetryableDeployError(bcode.ErrDeployConflict)).Should(BeFalse())
+		Expect(isRetryableDeployError(apierrors.NewBadRequest("invalid"))).Should(BeFalse())
+	})
+
+	It("Test deploy retries the transient failures only", func() {
+		app := &model.Application{Name: "retry-app"}
+		failing := &deployFailingUsecase{errs: []error{bcode.ErrDeployApplyFail}}
+		webhook := &webhookUsecaseImpl{applicationUsecase: failing}
+		_, err := webhook.deploy(context.TODO(), app, apisv1.ApplicationDeployRequest{})
+		Expect(err).Should(Equal(bcode.ErrDeployApplyFail))
+		Expect(failing.calls).Should(Equal(1))
+
+		conflict := apierrors.NewConflict(schema.GroupResource{Resource: "applications"}, "retry-app", fmt.Errorf("conflict"))
+		failing = &deployFailingUsecase{errs: []error{conflict, conflict}}
+		webhook = &webhookUsecaseImpl{applicationUsecase: failing}
+		res, err := webhook.deploy(context.TODO(), app, apisv1.ApplicationDeployRequest{})
+		Expect(err).Should(BeNil())
+		Expect(failing.calls).Should(Equal(3))
+		Expect(res.Attempts).Should(Equal(3))
+	})
 })
+
+// deployFailingUsecase fails the deployments with the errors in order, and succeeds after them
+type deployFailingUsecase struct {
+	ApplicationUsecase
+	errs  []error
+	calls int
+}
+
+func (u *deployFailingUsecase) Deploy(ctx context.Context, app *model.Application, req apisv1.ApplicationDeployRequest) (*apisv1.ApplicationDeployResponse, error) {
+	u.calls++
+	if u.calls <= len(u.errs) {
+		return nil, u.errs[u.calls-1]
+	}
+	return &apisv1.ApplicationDeployResponse{}, nil
+}
@@ -79,8 +79,8 @@ var ErrApplicationRefusedDelete = NewBcode(400, 10019, "The application cannot b
 // ErrApplicationEnvRefusedDelete means he application env cannot be deleted because it has been deployed
 var ErrApplicationEnvRefusedDelete = NewBcode(400, 10020, "The application envbinding cannot be deleted because it has been deployed")
 
-// ErrInvalidWebhookToken means the webhook token is invalid
-var ErrInvalidWebhookToken = NewBcode(400, 10021, "Invalid webhook token")
+// ErrInvalidWebhookToken means the webhook token doesn't match any trigger
+var ErrInvalidWebhookToken = NewBcode(401, 10021, "Invalid webhook token")
 
 // ErrInvalidWebhookPayloadType means the webhook payload type is invalid
 var ErrInvalidWebhookPayloadType = NewBcode(400, 10022, "Invalid webhook payload type")
@@ -61,6 +61,9 @@ var (
 
 	// ErrAddonDependencyNotSatisfy means addon's dependencies is not enabled
 	ErrAddonDependencyNotSatisfy = NewBcode(500, 50017, "addon's dependencies is not enabled")
+
+	// ErrAddonInvalidImageRegistry means the image registry override of the addon is invalid
+	ErrAddonInvalidImageRegistry = NewBcode(400, 50018, "invalid image registry, it must be a host with an optional port and path")
 )
 
 // isGithubRateLimit check if error is github rate limit
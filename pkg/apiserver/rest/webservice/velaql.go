@@ -17,14 +17,22 @@
 package webservice
 
 import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 
+	"github.com/oam-dev/kubevela/pkg/apiserver/log"
 	apis "github.com/oam-dev/kubevela/pkg/apiserver/rest/apis/v1"
 	"github.com/oam-dev/kubevela/pkg/apiserver/rest/usecase"
 	"github.com/oam-dev/kubevela/pkg/apiserver/rest/utils/bcode"
 )
 
+// MIMENDJSON is the mime type of newline delimited JSON, the query view is streamed line by line if it is accepted
+const MIMENDJSON = "application/x-ndjson"
+
 type velaQLWebService struct {
 	velaQLUsecase usecase.VelaQLUsecase
 }
@@ -49,6 +57,7 @@ func (v *velaQLWebService) GetWebService() *restful.WebService {
 		Doc("use velaQL to query resource status").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Param(ws.QueryParameter("velaql", "velaql query statement").DataType("string")).
+		Produces(restful.MIME_JSON, restful.MIME_XML, MIMENDJSON).
 		Returns(200, "", apis.VelaQLViewResponse{}).
 		Returns(400, "", bcode.Bcode{}).
 		Writes(apis.VelaQLViewResponse{}))
@@ -65,9 +74,42 @@ func (v *velaQLWebService) queryView(req *restful.Request, res *restful.Response
 		return
 	}
 
+	if strings.Contains(req.HeaderParameter(restful.HEADER_Accept), MIMENDJSON) {
+		if err = writeNDJSON(res, *qlResp); err != nil {
+			log.Logger.Errorf("write the query view as ndjson failure %s", err.Error())
+		}
+		return
+	}
+
 	// Write back response data
 	if err = res.WriteEntity(qlResp); err != nil {
 		bcode.ReturnError(req, res, err)
 		return
 	}
 }
+
+// writeNDJSON writes the items of the view one per line and flushes after each line.
+// If the view is not a single list, the whole view is written as one line.
+func writeNDJSON(res *restful.Response, view apis.VelaQLViewResponse) error {
+	items := []interface{}{map[string]interface{}(view)}
+	if len(view) == 1 {
+		for _, field := range view {
+			if list, ok := field.([]interface{}); ok {
+				items = list
+			}
+		}
+	}
+	res.Header().Set(restful.HEADER_ContentType, MIMENDJSON)
+	res.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(res)
+	flusher, canFlush := res.ResponseWriter.(http.Flusher)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+		if canFlush {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webservice
+
+import (
+	"net/http/httptest"
+
+	"github.com/emicklei/go-restful/v3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apisv1 "github.com/oam-dev/kubevela/pkg/apiserver/rest/apis/v1"
+)
+
+var _ = Describe("Test velaQL webservice", func() {
+	It("Test write the view as ndjson", func() {
+		recorder := httptest.NewRecorder()
+		err := writeNDJSON(restful.NewResponse(recorder), apisv1.VelaQLViewResponse{
+			"resources": []interface{}{
+				map[string]interface{}{"name": "web"},
+				map[string]interface{}{"name": "db"},
+			},
+		})
+		Expect(err).Should(BeNil())
+		Expect(recorder.Header().Get(restful.HEADER_ContentType)).Should(Equal(MIMENDJSON))
+		Expect(recorder.Body.String()).Should(Equal("{\"name\":\"web\"}\n{\"name\":\"db\"}\n"))
+		Expect(recorder.Flushed).Should(BeTrue())
+
+		recorder = httptest.NewRecorder()
+		err = writeNDJSON(restful.NewResponse(recorder), apisv1.VelaQLViewResponse{
+			"status": "running",
+			"pods":   []interface{}{"web-1"},
+		})
+		Expect(err).Should(BeNil())
+		Expect(recorder.Body.String()).Should(Equal("{\"pods\":[\"web-1\"],\"status\":\"running\"}\n"))
+	})
+})
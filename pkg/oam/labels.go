@@ -153,6 +153,15 @@ const (
 	// AnnotationAddonsName records the name of initializer stored in configMap
 	AnnotationAddonsName = "addons.oam.dev/name"
 
+	// AnnotationAddonRegistry records the name of the registry which the addon is installed from
+	AnnotationAddonRegistry = "addons.oam.dev/registry"
+
+	// AnnotationAddonVersion records the version of the installed addon
+	AnnotationAddonVersion = "addons.oam.dev/version"
+
+	// AnnotationAddonNamespace records the namespace override of the addon on its args secret
+	AnnotationAddonNamespace = "addons.oam.dev/namespace"
+
 	// AnnotationLastAppliedConfiguration is kubectl annotations for 3-way merge
 	AnnotationLastAppliedConfiguration = "kubectl.kubernetes.io/last-applied-configuration"
 
@@ -8,14 +8,46 @@
 			cluster?:          string
 			clusterNamespace?: string
 			components?: [...string]
+			annotations?: [string]: string
+			// olderThan and newerThan filter the resources by their age, such as 72h
+			olderThan?: string
+			newerThan?: string
+			// createdBy returns only the resources created by the traits or the workloads
+			createdBy?: "trait" | "workload"
 		}
+		// perKindLimit caps the number of the returned resources of each kind
+		perKindLimit?: int
+		// publishVersion lists the resources deployed by the publish version instead of the latest ones
+		publishVersion?: string
 	}
+	// withClusterCoverage compares the clusters targeted by the placement policies with the clusters of the resources
+	withClusterCoverage?: bool
+	// withNamespaces lists the distinct namespaces of the resources in each cluster
+	withNamespaces?: bool
 	list?: [...{
 		cluster:   string
 		component: string
 		revision:  string
+		// trait is the type of the trait which creates the resource, it is not set for the resources of the workload
+		trait?: string
 		object: {...}
 	}]
+	// counts is the number of all the resources of each kind, it is set only if perKindLimit is set
+	counts?: [string]: int
+	clusterCoverage?: {
+		targeted: [...string]
+		actual: [...string]
+		missing?: [...string]
+		unexpected?: [...string]
+		unresolvedEnvs?: [...string]
+	}
+	namespaces?: [...{
+		cluster:   string
+		namespace: string
+		resources: int
+		// external is true if the namespace is not the one of the application
+		external?: bool
+	}]
 	...
 }
 
@@ -24,6 +56,82 @@
 	#provider: "query"
 	value: {...}
 	cluster: string
+	// phases filters the pods by their status, such as Running, Pending or CrashLoopBackOff
+	phases?: [...string]
+	// includeEvents attaches the most recent events to each pod in the summary
+	includeEvents?: bool
+	// includeEnv attaches the env of the containers to each pod in the summary, the values from secrets are redacted
+	includeEnv?: bool
+	// includeWorkload returns the rollout progress of the Deployment, StatefulSet or DaemonSet in workload
+	includeWorkload?: bool
+	workload?: {
+		kind:             string
+		name:             string
+		desired:          int
+		updated:          int
+		ready:            int
+		available:        int
+		unavailable:      int
+		currentRevision?: string
+		updateRevision?:  string
+		complete:         bool
+	}
+	list?: [...{...}]
+	summary?: {
+		total: int
+		statuses: [string]: int
+		pods: [...{
+			name:              string
+			namespace:         string
+			phase:             string
+			component?:        string
+			revision?:         string
+			status:            string
+			nodeName?:         string
+			zone?:             string
+			region?:           string
+			schedulingPending: bool
+			events?: [...{
+				type:          string
+				reason:        string
+				message:       string
+				count:         int
+				lastTimestamp: string
+			}]
+			containers: [...{
+				name: string
+				requests?: [string]: string
+				limits?: [string]: string
+			}]
+			requests?: [string]: string
+			limits?: [string]: string
+			containerEnv?: [...{
+				name: string
+				env: [...{
+					name:      string
+					value?:    string
+					from?:     string
+					redacted?: bool
+				}]
+			}]
+			unmetConditions?: [...{
+				type:     string
+				reason?:  string
+				message?: string
+			}]
+			terminations?: [...{
+				name:         string
+				reason?:      string
+				message?:     string
+				exitCode:     int
+				signal?:      int
+				restartCount: int
+				current:      bool
+			}]
+		}]
+	}
+	err?:       string
+	errDetail?: #QueryError
 	...
 }
 
@@ -32,6 +140,55 @@
 	#provider: "query"
 	value: {...}
 	cluster: string
+	// limit keeps only the most recent events, the events are sorted by the last seen time if it's set
+	limit?: int
+	// reasons keeps only the events of the reasons, such as FailedScheduling or BackOff
+	reasons?: [...string]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#DescribeResource: {
+	#do:       "describeResource"
+	#provider: "query"
+	value: {
+		apiVersion: string
+		kind:       string
+		name:       string
+		namespace?: string
+		...
+	}
+	cluster: string
+	description?: {
+		metadata: {
+			apiVersion: string
+			kind:       string
+			name:       string
+			namespace?: string
+			labels?: [string]: string
+			annotations?: [string]: string
+			creationTimestamp: string
+			owners?: [...string]
+		}
+		conditions: [...{
+			type:                string
+			status:              string
+			reason?:             string
+			message?:            string
+			lastTransitionTime?: string
+		}]
+		events: [...{
+			type:          string
+			reason:        string
+			message:       string
+			count:         int
+			lastTimestamp: string
+		}]
+		pods?: [...{...}]
+	}
+	err?:       string
+	errDetail?: #QueryError
 	...
 }
 
@@ -41,6 +198,21 @@
 	cluster:   string
 	namespace: string
 	pod:       string
+	// sinceTime is a RFC3339 time, it is used if options.sinceTime is not set
+	sinceTime?: string
+	// sinceLastRestart reads the logs since the current container started, it's ignored if the time is set in options
+	sinceLastRestart?: bool
+	// compress returns the logs gzipped and base64 encoded in logsGzip, the logs are left empty
+	compress?: bool
+	// allContainers reads the logs of all the containers of the pod one after another
+	allContainers?: bool
+	// initContainers reads the logs of the init containers in order, each one after a line such as
+	// ==> init container <name> <==, the init containers which never ran are noted without logs
+	initContainers?: bool
+	// prefix adds the name of the container to every line, such as [container] log
+	prefix?: bool
+	// followSeconds follows the logs for the given seconds and returns what is read, the budget is shared by all the containers
+	followSeconds?: int
 	options: {
 		container:    string
 		previous:     *false | bool
@@ -51,37 +223,770 @@
 		limitBytes:   *null | int
 	}
 	outputs?: {
-		logs: string
-		err?: string
+		logs:       string
+		logsGzip?:  string
+		err?:       string
+		errDetail?: #QueryError
 		info: {
-			fromDate: string
-			toDate:   string
+			fromDate:      string
+			toDate:        string
+			bytesReturned: int
+			// truncatedAtLimit is true if the logs are cut at options.limitBytes
+			truncatedAtLimit?: bool
+			// compressed is true if the logs are returned in logsGzip
+			compressed?: bool
+			// followStopped is true if the reading is stopped by the end of followSeconds
+			followStopped?: bool
 		}
 		...
 	}
+	err?:       string
+	errDetail?: #QueryError
 	...
 }
 
 #CollectServiceEndpoints: {
 	#do:       "collectServiceEndpoints"
 	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		// cluster is where the application lives, it is the hub cluster if not set
+		cluster?: string
+		filter?: {
+			cluster?:          string
+			clusterNamespace?: string
+		}
+	}
+	// waitSeconds polls the endpoints until one of them is ready or the time elapses, it is capped at 60 seconds
+	waitSeconds?: int
+	// healthyOnly skips the services and ingresses of the components which are not healthy
+	healthyOnly?: bool
+	// ready is true if any endpoint is not pending
+	ready?: bool
+	// skippedUnhealthy is the number of the skipped resources if healthyOnly is set
+	skippedUnhealthy?: int
+	// groupByComponent returns the endpoints grouped by the component names in groups besides the list
+	groupByComponent?: bool
+	// probe sends a HTTP request to each HTTP(S) endpoint and returns whether it responds in the probe of the endpoint
+	probe?: bool
+	list?: [...#ServiceEndpoint]
+	groups?: [string]: [...#ServiceEndpoint]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#ServiceEndpoint: {
+	endpoint: {
+		protocol:    string
+		appProtocol: string
+		host?:       string
+		port:        int
+		path?:       string
+		tls?: {
+			secretName:  string
+			commonName?: string
+			notAfter?:   string
+		}
+		// ipFamily is IPv4, IPv6 or hostname
+		ipFamily?: string
+	}
+	ref: {...}
+	component?: string
+	// pending is true if the endpoint is still being provisioned
+	pending?: bool
+	probe?: {
+		reachable:   bool
+		statusCode?: int
+		message?:    string
+	}
+	// backendReady is set for the ingress endpoints, it is true if the backend service has any ready endpoint
+	backendReady?: bool
+	// headless is true for the pods of a headless service, the host is the DNS name of the pod
+	headless?: bool
+	pod?:      string
+}
+
+#CollectResourceDrift: {
+	#do:       "collectResourceDrift"
+	#provider: "query"
+	value: {...}
+	cluster: string
+	drift?: {
+		drifted: bool
+		// applied is null for added fields, live is null for removed fields
+		added?: [...{path: string, applied: null, live: _}]
+		removed?: [...{path: string, applied: _, live: null}]
+		changed?: [...{path: string, applied: _, live: _}]
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectImages: {
+	#do:       "collectImages"
+	#provider: "query"
 	app: {
 		name:      string
 		namespace: string
 		filter?: {
 			cluster?:          string
 			clusterNamespace?: string
+			components?: [...string]
+			annotations?: [string]: string
 		}
 	}
 	list?: [...{
-		endpoint: {
-			protocol:    string
-			appProtocol: string
-			host?:       string
-			port:        int
-			path?:       string
+		image:     string
+		component: string
+		cluster:   string
+		digest?:   string
+	}]
+	...
+}
+
+#ListAppClusters: {
+	#do:       "listAppClusters"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+	}
+	// the empty cluster of the applied resources is listed as local
+	list?: [...{
+		cluster:   string
+		resources: int
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectAppGraph: {
+	#do:       "collectAppGraph"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		filter?: {
+			cluster?:          string
+			clusterNamespace?: string
+			components?: [...string]
+			annotations?: [string]: string
+		}
+	}
+	graph?: {
+		nodes: [...{
+			id:         string
+			cluster:    string
+			component:  string
+			apiVersion: string
+			kind:       string
+			namespace:  string
+			name:       string
+			// healthy, unhealthy or unknown
+			health: string
+		}]
+		edges: [...{
+			from: string
+			to:   string
+			// owner or trait
+			type: string
+		}]
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+// #QueryError is the structured error of a query, code and reason are read from the status of the kubernetes API error
+#CollectResourceProvenance: {
+	#do:       "collectResourceProvenance"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+	}
+	value: {
+		apiVersion: string
+		kind:       string
+		name:       string
+		namespace?: string
+		...
+	}
+	cluster: string
+	provenance?: {
+		component:          string
+		componentRevision?: string
+		appRevision?:       string
+		env?:               string
+		// WORKLOAD or TRAIT
+		resourceType?: string
+		trait?:        string
+		// the apply-component step which applies the component
+		workflowStep?:    string
+		publishVersion?:  string
+		resourceTracker?: string
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectRevisionDiff: {
+	#do:       "collectRevisionDiff"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+	}
+	diff?: {
+		current:   string
+		previous?: string
+		addedComponents: [...string]
+		removedComponents: [...string]
+		modifiedComponents: [...{
+			name:          string
+			previousType?: string
+			type:          string
+			properties: [...{
+				// the path is joined by dots, such as resources.cpu
+				path: string
+				old?: _
+				new?: _
+			}]
+			addedTraits: [...string]
+			removedTraits: [...string]
+			modifiedTraits: [...string]
+		}]
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectServiceBackends: {
+	#do:       "collectServiceBackends"
+	#provider: "query"
+	value: {
+		name:      string
+		namespace: string
+		...
+	}
+	cluster: string
+	backends?: [...{
+		ip:        string
+		port:      int
+		portName?: string
+		protocol?: string
+		pod?:      string
+		nodeName?: string
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#GetResourceYAML: {
+	#do:       "getResourceYAML"
+	#provider: "query"
+	value: {
+		apiVersion: string
+		kind:       string
+		name:       string
+		namespace?: string
+		...
+	}
+	cluster: string
+	// the managed fields and the filtered annotations are stripped
+	manifest?: {
+		yaml: string
+		json: string
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectPVCs: {
+	#do:       "collectPVCs"
+	#provider: "query"
+	value: {
+		apiVersion: string
+		kind:       string
+		name:       string
+		namespace?: string
+		...
+	}
+	cluster: string
+	list?: [...{
+		name:          string
+		phase:         string
+		capacity?:     string
+		volumeName?:   string
+		storageClass?: string
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectWorkflowStatus: {
+	#do:       "collectWorkflowStatus"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+	}
+	workflow?: {
+		// one of initializing, executing, suspended, terminated, failed and succeeded
+		phase:        string
+		mode?:        string
+		appRevision?: string
+		message?:     string
+		suspend:      bool
+		terminated:   bool
+		finished:     bool
+		startTime?:   string
+		steps: [...#WorkflowStepStatus]
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#WorkflowStepStatus: {
+	name:              string
+	type?:             string
+	phase?:            string
+	message?:          string
+	reason?:           string
+	firstExecuteTime?: string
+	lastExecuteTime?:  string
+	subSteps?: [...#WorkflowStepStatus]
+}
+
+#CollectServices: {
+	#do:       "collectServices"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		filter?: {
+			cluster?:          string
+			clusterNamespace?: string
+			components?: [...string]
+		}
+	}
+	list?: [...{
+		name:       string
+		namespace:  string
+		cluster:    string
+		component:  string
+		type:       string
+		clusterIP?: string
+		ports: [...{...}]
+		selector?: [string]: string
+		readyEndpoints: int
+		hasReady:       bool
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectAppWarnings: {
+	#do:       "collectAppWarnings"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		filter?: {
+			cluster?:          string
+			clusterNamespace?: string
+			components?: [...string]
 		}
-		ref: {...}
+	}
+	// limit is the number of the resources with the most warnings to return, it is 10 by default
+	limit?: int
+	list?: [...{
+		cluster:       string
+		component:     string
+		kind:          string
+		namespace?:    string
+		name:          string
+		count:         int
+		lastTimestamp: string
+		events: [...{
+			type:          string
+			reason:        string
+			message:       string
+			count:         int
+			lastTimestamp: string
+		}]
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#GetLastApplied: {
+	#do:       "getLastApplied"
+	#provider: "query"
+	value: {...}
+	cluster: string
+	lastApplied?: {
+		// found is false if the resource has no last applied configuration
+		found:       bool
+		annotation?: string
+		manifest?: {...}
+		yaml?: string
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectConfigRefs: {
+	#do:       "collectConfigRefs"
+	#provider: "query"
+	value: {...}
+	cluster: string
+	// the values of the secrets are never returned, only the keys are
+	list?: [...{
+		kind:      "ConfigMap" | "Secret"
+		name:      string
+		namespace: string
+		sources: [..."envFrom" | "env" | "volume"]
+		referencedKeys?: [...string]
+		exists: bool
+		keys?: [...string]
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectHPA: {
+	#do:       "collectHPA"
+	#provider: "query"
+	value: {...}
+	cluster: string
+	list?: [...{
+		name:            string
+		namespace:       string
+		minReplicas?:    int
+		maxReplicas:     int
+		currentReplicas: int
+		desiredReplicas: int
+		lastScaleTime?:  string
+		// target and current are formatted like kubectl, such as 80% for the utilization
+		metrics: [...{
+			type:     string
+			name:     string
+			target:   string
+			current?: string
+		}]
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectServiceAccount: {
+	#do:       "collectServiceAccount"
+	#provider: "query"
+	value: {...}
+	cluster: string
+	// includeRules reads the rules of the roles bound to the service account
+	includeRules?: bool
+	serviceAccount?: {
+		name:                          string
+		namespace:                     string
+		exists:                        bool
+		automountServiceAccountToken?: bool
+		bindings: [...{
+			kind:       "RoleBinding" | "ClusterRoleBinding"
+			name:       string
+			namespace?: string
+			roleKind:   string
+			roleName:   string
+			rules?: [...{...}]
+		}]
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectPolicies: {
+	#do:       "collectPolicies"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+	}
+	list?: [...{
+		name: string
+		type: string
+		properties?: {...}
+		// envs are only set for the env-binding policies, the components are merged with the patch of each env
+		envs?: [...{
+			name: string
+			placement: {...}
+			decisions?: [...{
+				cluster:   string
+				namespace: string
+			}]
+			components: [...{...}]
+		}]
 	}]
+	err?:       string
+	errDetail?: #QueryError
 	...
 }
+
+#CollectNodeConditions: {
+	#do:       "collectNodeConditions"
+	#provider: "query"
+	cluster:   string
+	list?: [...{
+		name:          string
+		unschedulable: bool
+		// the Ready, MemoryPressure, DiskPressure and PIDPressure conditions, the status is Unknown if not reported
+		conditions: [...{
+			type:                string
+			status:              string
+			reason?:             string
+			message?:            string
+			lastTransitionTime?: string
+		}]
+		capacity?: [string]:    string
+		allocatable?: [string]: string
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectAppReadiness: {
+	#do:       "collectAppReadiness"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+	}
+	readiness?: {
+		// score is the percentage of the ready services from 0 to 100
+		score: int
+		ready: int
+		total: int
+		components: [...{
+			name:     string
+			score:    int
+			ready:    int
+			total:    int
+			message?: string
+		}]
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectRoutingRules: {
+	#do:       "collectRoutingRules"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		filter?: {...}
+	}
+	// one row for each host, path and backend of the Ingresses and the HTTPRoutes, the empty host matches all hosts
+	list?: [...{
+		kind:           "Ingress" | "HTTPRoute"
+		name:           string
+		namespace:      string
+		cluster:        string
+		component?:     string
+		host:           string
+		path:           string
+		pathType?:      string
+		backendService: string
+		backendPort?:   string
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectDistribution: {
+	#do:       "collectDistribution"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		filter?: {...}
+	}
+	// the replicas of the pods keyed by the component and then the cluster
+	distribution?: [string]: [string]: {
+		readyReplicas: int
+		totalReplicas: int
+	}
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectHelmValues: {
+	#do:       "collectHelmValues"
+	#provider: "query"
+	// value is the HelmRelease
+	value: {...}
+	cluster: string
+	// the values of the valuesFrom merged in order and spec.values merged last, the values from the Secrets are redacted
+	values?: {...}
+	sources?: [...{
+		kind:        "ConfigMap" | "Secret"
+		name:        string
+		valuesKey:   string
+		targetPath?: string
+		// missing is true if the optional source is not found
+		missing?:  bool
+		redacted?: bool
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectJobs: {
+	#do:       "collectJobs"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		filter?: {...}
+	}
+	list?: [...{
+		kind:            "Job" | "CronJob"
+		name:            string
+		namespace:       string
+		cluster:         string
+		component?:      string
+		status:          "Complete" | "Failed" | "Running" | "Suspended" | "Scheduled"
+		completions?:    int
+		active:          int
+		succeeded:       int
+		failed:          int
+		startTime?:      string
+		completionTime?: string
+		// schedule, lastScheduleTime and lastSuccessfulTime are only set for the CronJobs
+		schedule?:           string
+		lastScheduleTime?:   string
+		lastSuccessfulTime?: string
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectProbes: {
+	#do:       "collectProbes"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		filter?: {...}
+	}
+	list?: [...{
+		component?: string
+		cluster:    string
+		kind:       string
+		name:       string
+		namespace:  string
+		container:  string
+		liveness?:  #ProbeConfig
+		readiness?: #ProbeConfig
+		startup?:   #ProbeConfig
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#ProbeConfig: {
+	type: "httpGet" | "tcpSocket" | "exec"
+	// scheme, host, path and port are set for the httpGet probes, host and port for the tcpSocket probes
+	scheme?:             string
+	host?:               string
+	path?:               string
+	port?:               string
+	command?:            [...string]
+	initialDelaySeconds: int
+	timeoutSeconds:      int
+	periodSeconds:       int
+	successThreshold:    int
+	failureThreshold:    int
+}
+
+#CollectSecrets: {
+	#do:       "collectSecrets"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		filter?: {...}
+	}
+	// the data of the secrets is never returned, only the names of the keys
+	list?: [...{
+		name:      string
+		namespace: string
+		cluster:   string
+		type?:     string
+		keys?: [...string]
+		// managed is true if the secret is applied by the application
+		managed: bool
+		exists:  bool
+		// referencedBy are the workloads referencing the secret, such as Deployment/web
+		referencedBy?: [...string]
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#CollectApplyStatus: {
+	#do:       "collectApplyStatus"
+	#provider: "query"
+	app: {
+		name:      string
+		namespace: string
+		filter?: {...}
+	}
+	list?: [...{
+		kind:       string
+		name:       string
+		namespace?: string
+		cluster:    string
+		component?: string
+		generation: int
+		// lastAppliedTime is the latest update of the fields out of the status
+		lastAppliedTime?: string
+		// synced is true if status.observedGeneration catches up the generation, it is unset if the resource
+		// doesn't report the observed generation
+		observedGeneration?: int
+		synced?:             bool
+	}]
+	err?:       string
+	errDetail?: #QueryError
+	...
+}
+
+#QueryError: {
+	code:    int
+	reason:  string
+	message: string
+}
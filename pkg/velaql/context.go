@@ -17,6 +17,7 @@
 package velaql
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/pkg/errors"
@@ -26,9 +27,9 @@ import (
 	wfContext "github.com/oam-dev/kubevela/pkg/workflow/context"
 )
 
-// NewViewContext new view context
-func NewViewContext() (wfContext.Context, error) {
-	viewContext := &ViewContext{}
+// NewViewContext new view context, the handlers of the view stop when ctx is cancelled
+func NewViewContext(ctx context.Context) (wfContext.Context, error) {
+	viewContext := &ViewContext{ctx: ctx}
 	var err error
 	viewContext.vars, err = value.NewValue("", nil, "")
 	return viewContext, err
@@ -36,9 +37,18 @@ func NewViewContext() (wfContext.Context, error) {
 
 // ViewContext is view context
 type ViewContext struct {
+	ctx  context.Context
 	vars *value.Value
 }
 
+// GetContext returns the context of the query
+func (c ViewContext) GetContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 // GetComponent Get ComponentManifest from workflow context.
 func (c ViewContext) GetComponent(name string) (*wfContext.ComponentManifest, error) {
 	return nil, errors.New("not support func GetComponent")
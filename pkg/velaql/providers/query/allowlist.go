@@ -0,0 +1,64 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// AllowedClusters are the clusters the queries can reach, the hub cluster is named local. It is read by Install,
+// and all the clusters are allowed if it is empty.
+var AllowedClusters []string
+
+// clusterAllowlist is the set of the allowed clusters, all the clusters are allowed if it is empty
+type clusterAllowlist map[string]bool
+
+func newClusterAllowlist(clusters []string) clusterAllowlist {
+	allowlist := clusterAllowlist{}
+	for _, cluster := range clusters {
+		allowlist[normalizeClusterName(cluster)] = true
+	}
+	return allowlist
+}
+
+func (l clusterAllowlist) allows(cluster string) bool {
+	return len(l) == 0 || l[normalizeClusterName(cluster)]
+}
+
+// check returns a forbidden error if the cluster is not allowed
+func (l clusterAllowlist) check(cluster string) error {
+	if l.allows(cluster) {
+		return nil
+	}
+	return kerrors.NewForbidden(schema.GroupResource{Resource: "clusters"}, normalizeClusterName(cluster),
+		errors.New("the cluster is not in the allowlist of the query provider"))
+}
+
+// checkOption checks the clusters set in the option, the resources in the other clusters are skipped by the collector
+func (l clusterAllowlist) checkOption(opt Option) error {
+	if opt.Cluster != "" {
+		if err := l.check(opt.Cluster); err != nil {
+			return err
+		}
+	}
+	if opt.Filter.Cluster != "" {
+		return l.check(opt.Filter.Cluster)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ApplyStatus is when the resource is applied last and whether its controller has observed the latest spec
+type ApplyStatus struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	Cluster    string `json:"cluster"`
+	Component  string `json:"component,omitempty"`
+	Generation int64  `json:"generation"`
+	// LastAppliedTime is the latest time the fields out of the status are updated, read from the managed fields
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+	// ObservedGeneration and Synced are only set if the resource reports status.observedGeneration
+	ObservedGeneration *int64 `json:"observedGeneration,omitempty"`
+	Synced             *bool  `json:"synced,omitempty"`
+}
+
+// collectApplyStatus reads the apply status of the resources from their metadata and status
+func collectApplyStatus(resources []Resource) []ApplyStatus {
+	statuses := make([]ApplyStatus, 0, len(resources))
+	for _, res := range resources {
+		if res.Object == nil {
+			continue
+		}
+		status := ApplyStatus{
+			Kind:            res.Object.GetKind(),
+			Name:            res.Object.GetName(),
+			Namespace:       res.Object.GetNamespace(),
+			Cluster:         normalizeClusterName(res.Cluster),
+			Component:       res.Component,
+			Generation:      res.Object.GetGeneration(),
+			LastAppliedTime: getLastAppliedTime(res.Object),
+		}
+		if observed, found, err := unstructured.NestedInt64(res.Object.Object, "status", "observedGeneration"); err == nil && found {
+			synced := observed >= status.Generation
+			status.ObservedGeneration, status.Synced = &observed, &synced
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// getLastAppliedTime returns the latest time of the managed fields which update more than the status, so that
+// the status updates of the controllers are not taken as applies
+func getLastAppliedTime(obj *unstructured.Unstructured) *metav1.Time {
+	var last *metav1.Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time == nil || entry.Subresource == "status" || isStatusOnlyFields(entry.FieldsV1) {
+			continue
+		}
+		if last == nil || last.Before(entry.Time) {
+			last = entry.Time
+		}
+	}
+	return last
+}
+
+func isStatusOnlyFields(fields *metav1.FieldsV1) bool {
+	if fields == nil {
+		return false
+	}
+	set := map[string]interface{}{}
+	if err := json.Unmarshal(fields.Raw, &set); err != nil {
+		return false
+	}
+	for key := range set {
+		if key != "f:status" {
+			return false
+		}
+	}
+	return len(set) > 0
+}
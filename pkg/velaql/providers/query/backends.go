@@ -0,0 +1,132 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// ServiceBackend is a ready backend address behind a service
+type ServiceBackend struct {
+	IP       string          `json:"ip"`
+	Port     int32           `json:"port"`
+	PortName string          `json:"portName,omitempty"`
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// Pod is the name of the pod serving the address, it is empty if the address is not backed by a pod
+	Pod      string `json:"pod,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// collectServiceBackends collects the ready backends of the service from its EndpointSlices
+func collectServiceBackends(cli client.Client, cluster, namespace, name string) ([]ServiceBackend, error) {
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	slices := discoveryv1.EndpointSliceList{}
+	if err := cli.List(ctx, &slices, client.InNamespace(namespace), client.MatchingLabels{discoveryv1.LabelServiceName: name}); err != nil {
+		return nil, err
+	}
+	backends := []ServiceBackend{}
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			// a nil ready condition means the state is unknown and it should be interpreted as ready
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			var pod, nodeName string
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+				pod = endpoint.TargetRef.Name
+			}
+			if endpoint.NodeName != nil {
+				nodeName = *endpoint.NodeName
+			}
+			for _, address := range endpoint.Addresses {
+				for _, port := range slice.Ports {
+					backend := ServiceBackend{IP: address, Pod: pod, NodeName: nodeName}
+					if port.Port != nil {
+						backend.Port = *port.Port
+					}
+					if port.Name != nil {
+						backend.PortName = *port.Name
+					}
+					if port.Protocol != nil {
+						backend.Protocol = *port.Protocol
+					}
+					backends = append(backends, backend)
+				}
+			}
+		}
+	}
+	return backends, nil
+}
+
+// AppService is a service created by the application with the selector routing the traffic to the pods
+type AppService struct {
+	Name      string               `json:"name"`
+	Namespace string               `json:"namespace"`
+	Cluster   string               `json:"cluster"`
+	Component string               `json:"component"`
+	Type      corev1.ServiceType   `json:"type"`
+	ClusterIP string               `json:"clusterIP,omitempty"`
+	Ports     []corev1.ServicePort `json:"ports"`
+	// Selector is empty for the services whose endpoints are managed manually, such as the ExternalName services
+	Selector map[string]string `json:"selector,omitempty"`
+	// ReadyEndpoints is the number of the ready backend addresses read from the EndpointSlices
+	ReadyEndpoints int  `json:"readyEndpoints"`
+	HasReady       bool `json:"hasReady"`
+}
+
+// collectServices collects the services in the resources of the application with the readiness of their endpoints
+func collectServices(cli client.Client, resources []Resource) ([]AppService, error) {
+	services := []AppService{}
+	for _, res := range resources {
+		if res.Object.GroupVersionKind() != corev1.SchemeGroupVersion.WithKind(reflect.TypeOf(corev1.Service{}).Name()) {
+			continue
+		}
+		service := corev1.Service{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(res.Object.Object, &service); err != nil {
+			return nil, err
+		}
+		backends, err := collectServiceBackends(cli, res.Cluster, service.Namespace, service.Name)
+		if err != nil {
+			return nil, err
+		}
+		ports := service.Spec.Ports
+		if ports == nil {
+			ports = []corev1.ServicePort{}
+		}
+		services = append(services, AppService{
+			Name:           service.Name,
+			Namespace:      service.Namespace,
+			Cluster:        res.Cluster,
+			Component:      res.Component,
+			Type:           service.Spec.Type,
+			ClusterIP:      service.Spec.ClusterIP,
+			Ports:          ports,
+			Selector:       service.Spec.Selector,
+			ReadyEndpoints: len(backends),
+			HasReady:       len(backends) > 0,
+		})
+	}
+	return services, nil
+}
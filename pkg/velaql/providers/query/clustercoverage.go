@@ -0,0 +1,144 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/policy/envbinding"
+)
+
+// topologyPolicyType is the type of the policies listing the target clusters in the clusters property
+const topologyPolicyType = "topology"
+
+// ClusterCoverage compares the clusters targeted by the placement policies of the application with the clusters
+// its resources are found in
+type ClusterCoverage struct {
+	Targeted []string `json:"targeted"`
+	Actual   []string `json:"actual"`
+	// Missing are the targeted clusters without any resource, Unexpected are the clusters with resources
+	// which are not targeted
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+	// UnresolvedEnvs are the environments of the env-binding policies selecting the clusters by labels which are
+	// not placed yet, their clusters are unknown
+	UnresolvedEnvs []string `json:"unresolvedEnvs,omitempty"`
+}
+
+// collectClusterCoverage reads the target clusters from the env-binding and the topology policies of the application,
+// the hub cluster is the target if there is no such policy
+func collectClusterCoverage(app *v1beta1.Application, resources []Resource) (*ClusterCoverage, error) {
+	coverage := &ClusterCoverage{}
+	targeted := map[string]bool{}
+	var hasPlacement bool
+	for _, policy := range app.Spec.Policies {
+		if policy.Properties == nil {
+			continue
+		}
+		switch policy.Type {
+		case v1alpha1.EnvBindingPolicyType:
+			hasPlacement = true
+			unresolved, err := getEnvBindingClusters(app, policy.Name, targeted)
+			if err != nil {
+				return nil, err
+			}
+			coverage.UnresolvedEnvs = append(coverage.UnresolvedEnvs, unresolved...)
+		case topologyPolicyType:
+			hasPlacement = true
+			properties := struct {
+				Clusters []string `json:"clusters"`
+			}{}
+			if err := json.Unmarshal(policy.Properties.Raw, &properties); err != nil {
+				return nil, errors.Wrapf(err, "invalid properties of policy %s", policy.Name)
+			}
+			for _, cluster := range properties.Clusters {
+				targeted[normalizeClusterName(cluster)] = true
+			}
+		}
+	}
+	if !hasPlacement {
+		targeted[normalizeClusterName("")] = true
+	}
+	actual := map[string]bool{}
+	for _, res := range resources {
+		actual[normalizeClusterName(res.Cluster)] = true
+	}
+	coverage.Targeted, coverage.Actual = sortedClusters(targeted), sortedClusters(actual)
+	for _, cluster := range coverage.Targeted {
+		if !actual[cluster] {
+			coverage.Missing = append(coverage.Missing, cluster)
+		}
+	}
+	for _, cluster := range coverage.Actual {
+		if !targeted[cluster] {
+			coverage.Unexpected = append(coverage.Unexpected, cluster)
+		}
+	}
+	return coverage, nil
+}
+
+// getEnvBindingClusters adds the clusters of the environments of the env-binding policy to the targeted clusters.
+// The placement decisions are used if the environment is placed, otherwise the name in the cluster selector is used,
+// and the environments which can only be resolved by the labels are returned.
+func getEnvBindingClusters(app *v1beta1.Application, policyName string, targeted map[string]bool) ([]string, error) {
+	spec, err := envbinding.GetEnvBindingPolicy(app, policyName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid env-binding policy %s", policyName)
+	}
+	status, err := envbinding.GetEnvBindingPolicyStatus(app, policyName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid status of env-binding policy %s", policyName)
+	}
+	decisions := map[string][]v1alpha1.PlacementDecision{}
+	if status != nil {
+		for _, env := range status.Envs {
+			decisions[env.Env] = env.Placements
+		}
+	}
+	var unresolved []string
+	for _, env := range spec.Envs {
+		switch selector := env.Placement.ClusterSelector; {
+		case len(decisions[env.Name]) > 0:
+			for _, decision := range decisions[env.Name] {
+				targeted[normalizeClusterName(decision.Cluster)] = true
+			}
+		case selector == nil:
+			targeted[normalizeClusterName("")] = true
+		case selector.Name != "":
+			targeted[normalizeClusterName(selector.Name)] = true
+		case len(selector.Labels) > 0:
+			unresolved = append(unresolved, policyName+"/"+env.Name)
+		default:
+			targeted[normalizeClusterName("")] = true
+		}
+	}
+	return unresolved, nil
+}
+
+func sortedClusters(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
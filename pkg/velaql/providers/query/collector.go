@@ -19,7 +19,9 @@ package query
 import (
 	"context"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	kruise "github.com/openkruise/kruise-api/apps/v1alpha1"
@@ -38,6 +40,7 @@ import (
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/cue/definition"
 	"github.com/oam-dev/kubevela/pkg/multicluster"
 	"github.com/oam-dev/kubevela/pkg/oam"
 	oamutil "github.com/oam-dev/kubevela/pkg/oam/util"
@@ -48,6 +51,8 @@ import (
 type AppCollector struct {
 	k8sClient client.Client
 	opt       Option
+	// allowedClusters restricts the clusters the resources are read from, all the clusters are allowed if it is empty
+	allowedClusters clusterAllowlist
 }
 
 // NewAppCollector create a app collector
@@ -60,14 +65,35 @@ func NewAppCollector(cli client.Client, opt Option) *AppCollector {
 
 const velaVersionNumberToUpgradeVelaQL = "v1.2.0-rc.1"
 
+// the values of the createdBy filter
+const (
+	ResourceCreatedByTrait    = "trait"
+	ResourceCreatedByWorkload = "workload"
+)
+
+// DefaultCollectConcurrency is the default number of clusters whose resources are collected in parallel
+const DefaultCollectConcurrency = 8
+
 // CollectResourceFromApp collect resources created by application
 func (c *AppCollector) CollectResourceFromApp() ([]Resource, error) {
+	if err := validateAgeFilter(c.opt.Filter); err != nil {
+		return nil, err
+	}
+	if err := validateCreatedByFilter(c.opt.Filter); err != nil {
+		return nil, err
+	}
+	if err := c.allowedClusters.checkOption(c.opt); err != nil {
+		return nil, err
+	}
 	ctx := context.Background()
 	app := new(v1beta1.Application)
 	appKey := client.ObjectKey{Name: c.opt.Name, Namespace: c.opt.Namespace}
 	if err := c.k8sClient.Get(ctx, appKey, app); err != nil {
 		return nil, err
 	}
+	if c.opt.PublishVersion != "" {
+		return c.FindResourceFromPublishVersion(app)
+	}
 	var currentVersionNumber string
 	if annotations := app.GetAnnotations(); annotations != nil && annotations[oam.AnnotationKubeVelaVersion] != "" {
 		currentVersionNumber = annotations[oam.AnnotationKubeVelaVersion]
@@ -95,9 +121,31 @@ func (c *AppCollector) FindResourceFromResourceTrackerSpec(app *v1beta1.Applicat
 	if err != nil {
 		return nil, err
 	}
+	return c.findResourceFromResourceTrackers(append(historyRTs, rootRT, currentRT))
+}
+
+// FindResourceFromPublishVersion find resources recorded by the versioned ResourceTracker of the publish version
+// in the option, it is the resource set deployed by that version. The objects are read from the clusters, so the
+// ones deleted since are not returned.
+func (c *AppCollector) FindResourceFromPublishVersion(app *v1beta1.Application) ([]Resource, error) {
+	rts := v1beta1.ResourceTrackerList{}
+	if err := c.k8sClient.List(context.Background(), &rts, client.MatchingLabels{
+		oam.LabelAppName:      app.Name,
+		oam.LabelAppNamespace: app.Namespace,
+	}); err != nil {
+		return nil, err
+	}
+	for i, rt := range rts.Items {
+		if rt.Spec.Type == v1beta1.ResourceTrackerTypeVersioned && rt.GetAnnotations()[oam.AnnotationPublishVersion] == c.opt.PublishVersion {
+			return c.findResourceFromResourceTrackers([]*v1beta1.ResourceTracker{&rts.Items[i]})
+		}
+	}
+	return nil, errors.Errorf("publish version %s of application %s not found", c.opt.PublishVersion, app.Name)
+}
 
-	managedResources := make(map[common.ClusterObjectReference]bool, len(app.Spec.Components))
-	for _, rt := range append(historyRTs, rootRT, currentRT) {
+func (c *AppCollector) findResourceFromResourceTrackers(rts []*v1beta1.ResourceTracker) ([]Resource, error) {
+	managedResources := make(map[common.ClusterObjectReference]bool)
+	for _, rt := range rts {
 		if rt != nil {
 			for _, managedResource := range rt.Spec.ManagedResources {
 				if isResourceInTargetCluster(c.opt.Filter, managedResource.ClusterObjectReference) &&
@@ -107,26 +155,32 @@ func (c *AppCollector) FindResourceFromResourceTrackerSpec(app *v1beta1.Applicat
 			}
 		}
 	}
-	resources := make([]Resource, 0, len(managedResources))
+	refs := make([]common.ClusterObjectReference, 0, len(managedResources))
 	for objRef := range managedResources {
-		obj := new(unstructured.Unstructured)
-		obj.SetGroupVersionKind(objRef.GroupVersionKind())
-		obj.SetNamespace(objRef.Namespace)
-		obj.SetName(objRef.Name)
-		if err = c.k8sClient.Get(multicluster.ContextWithClusterName(ctx, objRef.Cluster),
-			client.ObjectKeyFromObject(obj), obj); err != nil {
-			if kerrors.IsNotFound(err) {
-				continue
-			}
-			return nil, err
+		refs = append(refs, objRef)
+	}
+	sortClusterObjectReferences(refs)
+	objs, err := c.getObjectsInClusters(refs)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]Resource, 0, len(managedResources))
+	for i, obj := range objs {
+		if obj == nil || !isResourceMatchAnnotations(c.opt.Filter, obj) || !isResourceInAgeRange(c.opt.Filter, obj, time.Now()) ||
+			!isResourceCreatedBy(c.opt.Filter, obj) {
+			continue
 		}
 		resources = append(resources, Resource{
-			Cluster:   objRef.Cluster,
+			Cluster:   refs[i].Cluster,
 			Revision:  obj.GetLabels()[oam.LabelAppRevision],
 			Component: obj.GetLabels()[oam.LabelAppComponent],
+			Trait:     getResourceTrait(obj),
 			Object:    obj,
 		})
 	}
+	if resources, err = c.collectKustomizationResources(resources); err != nil {
+		return nil, err
+	}
 	if len(resources) == 0 {
 		return nil, errors.Errorf("fail to find resources created by application: %v", c.opt.Name)
 	}
@@ -135,30 +189,114 @@ func (c *AppCollector) FindResourceFromResourceTrackerSpec(app *v1beta1.Applicat
 
 // FindResourceFromAppliedResourcesField find resources from AppliedResources field
 func (c *AppCollector) FindResourceFromAppliedResourcesField(app *v1beta1.Application) ([]Resource, error) {
-	resources := make([]Resource, 0, len(app.Spec.Components))
+	var refs []common.ClusterObjectReference
 	for _, rsrcRef := range app.Status.AppliedResources {
-		if !isResourceInTargetCluster(c.opt.Filter, rsrcRef) {
-			continue
+		if isResourceInTargetCluster(c.opt.Filter, rsrcRef) {
+			refs = append(refs, rsrcRef)
 		}
-		compName, obj, err := getObjectCreatedByComponent(c.k8sClient, rsrcRef.ObjectReference, rsrcRef.Cluster)
-		if err != nil {
-			return nil, err
+	}
+	objs, err := c.getObjectsInClusters(refs)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]Resource, 0, len(app.Spec.Components))
+	for i, obj := range objs {
+		if obj == nil {
+			continue
 		}
-		if len(compName) != 0 && isResourceInTargetComponent(c.opt.Filter, compName) {
+		compName := obj.GetLabels()[oam.LabelAppComponent]
+		if len(compName) != 0 && isResourceInTargetComponent(c.opt.Filter, compName) && isResourceMatchAnnotations(c.opt.Filter, obj) &&
+			isResourceInAgeRange(c.opt.Filter, obj, time.Now()) && isResourceCreatedBy(c.opt.Filter, obj) {
 			resources = append(resources, Resource{
 				Component: compName,
 				Revision:  obj.GetLabels()[oam.LabelAppRevision],
-				Cluster:   rsrcRef.Cluster,
+				Cluster:   refs[i].Cluster,
+				Trait:     getResourceTrait(obj),
 				Object:    obj,
 			})
 		}
 	}
+	if resources, err = c.collectKustomizationResources(resources); err != nil {
+		return nil, err
+	}
 	if len(resources) == 0 {
 		return nil, errors.Errorf("fail to find resources created by application: %v", c.opt.Name)
 	}
 	return resources, nil
 }
 
+// getObjectsInClusters get the objects of the refs, the clusters are visited in parallel by a bounded number of workers
+// and the objects in the same cluster are got one by one. The returned objects have the same order as the refs,
+// and the object is nil if it is not found.
+func (c *AppCollector) getObjectsInClusters(refs []common.ClusterObjectReference) ([]*unstructured.Unstructured, error) {
+	var clusters []string
+	clusterRefs := map[string][]int{}
+	for i, ref := range refs {
+		// the objects in the clusters not allowed are left nil as if they are not found
+		if !c.allowedClusters.allows(ref.Cluster) {
+			continue
+		}
+		if _, ok := clusterRefs[ref.Cluster]; !ok {
+			clusters = append(clusters, ref.Cluster)
+		}
+		clusterRefs[ref.Cluster] = append(clusterRefs[ref.Cluster], i)
+	}
+	concurrency := c.opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultCollectConcurrency
+	}
+	objs := make([]*unstructured.Unstructured, len(refs))
+	errs := make([]error, len(clusters))
+	workers := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(index int, cluster string) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			// every ref index belongs to only one cluster, so the workers never write the same element
+			for _, refIndex := range clusterRefs[cluster] {
+				_, obj, err := getObjectCreatedByComponent(c.k8sClient, refs[refIndex].ObjectReference, cluster)
+				if err != nil {
+					errs[index] = err
+					return
+				}
+				objs[refIndex] = obj
+			}
+		}(i, cluster)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return objs, nil
+}
+
+// sortClusterObjectReferences sorts the refs by cluster, namespace, kind and name
+func sortClusterObjectReferences(refs []common.ClusterObjectReference) {
+	sort.Slice(refs, func(i, j int) bool {
+		a, b := refs[i], refs[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.APIVersion != b.APIVersion {
+			return a.APIVersion < b.APIVersion
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+}
+
 // getObjectCreatedByComponent get k8s obj created by components
 func getObjectCreatedByComponent(cli client.Client, objRef corev1.ObjectReference, cluster string) (string, *unstructured.Unstructured, error) {
 	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
@@ -415,7 +553,7 @@ func isResourceInTargetCluster(opt FilterOption, resource common.ClusterObjectRe
 	if opt.Cluster == "" && opt.ClusterNamespace == "" {
 		return true
 	}
-	if opt.Cluster == resource.Cluster && opt.ClusterNamespace == resource.ObjectReference.Namespace {
+	if normalizeClusterName(opt.Cluster) == normalizeClusterName(resource.Cluster) && opt.ClusterNamespace == resource.ObjectReference.Namespace {
 		return true
 	}
 	return false
@@ -432,3 +570,91 @@ func isResourceInTargetComponent(opt FilterOption, componentName string) bool {
 	}
 	return false
 }
+
+func isResourceMatchAnnotations(opt FilterOption, obj *unstructured.Unstructured) bool {
+	annotations := obj.GetAnnotations()
+	for k, v := range opt.Annotations {
+		if value, ok := annotations[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// limitResourcesPerKind keeps the first limit resources of each kind, the counts record the number of all the resources of each kind
+func limitResourcesPerKind(resources []Resource, limit int) ([]Resource, map[string]int) {
+	counts := map[string]int{}
+	limited := make([]Resource, 0, len(resources))
+	for _, res := range resources {
+		kind := res.Object.GetKind()
+		counts[kind]++
+		if counts[kind] <= limit {
+			limited = append(limited, res)
+		}
+	}
+	return limited, counts
+}
+
+func validateAgeFilter(opt FilterOption) error {
+	if opt.OlderThan != "" {
+		if _, err := time.ParseDuration(opt.OlderThan); err != nil {
+			return errors.Wrapf(err, "invalid olderThan")
+		}
+	}
+	if opt.NewerThan != "" {
+		if _, err := time.ParseDuration(opt.NewerThan); err != nil {
+			return errors.Wrapf(err, "invalid newerThan")
+		}
+	}
+	return nil
+}
+
+// validateCreatedByFilter checks the createdBy of the filter is empty, ResourceCreatedByTrait or ResourceCreatedByWorkload
+func validateCreatedByFilter(opt FilterOption) error {
+	switch opt.CreatedBy {
+	case "", ResourceCreatedByTrait, ResourceCreatedByWorkload:
+		return nil
+	default:
+		return errors.Errorf("invalid createdBy %q, it must be %s or %s", opt.CreatedBy, ResourceCreatedByTrait, ResourceCreatedByWorkload)
+	}
+}
+
+// isResourceCreatedBy checks whether the resource is created by a trait or a workload as the createdBy of the filter
+func isResourceCreatedBy(opt FilterOption, obj *unstructured.Unstructured) bool {
+	switch opt.CreatedBy {
+	case ResourceCreatedByTrait:
+		return getResourceTrait(obj) != ""
+	case ResourceCreatedByWorkload:
+		return getResourceTrait(obj) == ""
+	default:
+		return true
+	}
+}
+
+// getResourceTrait reads the type of the trait which creates the resource from its labels. The auxiliary outputs
+// of the component are labeled with the AuxiliaryWorkload trait type, they are created by the workload.
+func getResourceTrait(obj *unstructured.Unstructured) string {
+	labels := obj.GetLabels()
+	traitType := labels[oam.TraitTypeLabel]
+	if traitType == definition.AuxiliaryWorkload {
+		return ""
+	}
+	if traitType == "" && labels[oam.LabelOAMResourceType] == oam.ResourceTypeTrait {
+		// the trait created by the older versions may only be marked by the resource type
+		return oam.ResourceTypeTrait
+	}
+	return traitType
+}
+
+// isResourceInAgeRange checks the age of the resource against the olderThan and newerThan of the filter,
+// the durations are validated by validateAgeFilter
+func isResourceInAgeRange(opt FilterOption, obj *unstructured.Unstructured, now time.Time) bool {
+	age := now.Sub(obj.GetCreationTimestamp().Time)
+	if olderThan, err := time.ParseDuration(opt.OlderThan); err == nil && age <= olderThan {
+		return false
+	}
+	if newerThan, err := time.ParseDuration(opt.NewerThan); err == nil && age >= newerThan {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+const (
+	// ConfigRefSourceEnvFrom means all the keys are read as the environment variables by envFrom
+	ConfigRefSourceEnvFrom = "envFrom"
+	// ConfigRefSourceEnv means a key is read as an environment variable by valueFrom
+	ConfigRefSourceEnv = "env"
+	// ConfigRefSourceVolume means the object is mounted as a volume, including the projected volumes
+	ConfigRefSourceVolume = "volume"
+)
+
+var (
+	configMapKind = reflect.TypeOf(corev1.ConfigMap{}).Name()
+	secretKind    = reflect.TypeOf(corev1.Secret{}).Name()
+)
+
+// ConfigRef is a ConfigMap or a Secret referenced by the pod template of a workload, the secret data is never included
+type ConfigRef struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Sources are how the object is referenced, such as envFrom, env or volume
+	Sources []string `json:"sources"`
+	// ReferencedKeys are the keys read by valueFrom or selected by the volume items, all the keys are used by envFrom
+	// and the volumes without items
+	ReferencedKeys []string `json:"referencedKeys,omitempty"`
+	// Exists is false if the object is not found, Keys are the keys of the data in the live object
+	Exists bool     `json:"exists"`
+	Keys   []string `json:"keys,omitempty"`
+}
+
+// collectConfigRefs collects the ConfigMaps and Secrets referenced by the pod template of the workload
+func collectConfigRefs(cli client.Client, obj *unstructured.Unstructured, cluster string) ([]ConfigRef, error) {
+	refs := getPodConfigRefs(obj)
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	for i := range refs {
+		keys, err := getConfigKeys(ctx, cli, refs[i].Kind, refs[i].Namespace, refs[i].Name)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		refs[i].Exists, refs[i].Keys = true, keys
+	}
+	return refs, nil
+}
+
+// getPodConfigRefs lists the ConfigMaps and Secrets referenced by the pod template of the workload, the objects
+// are not read
+func getPodConfigRefs(obj *unstructured.Unstructured) []ConfigRef {
+	refs := []ConfigRef{}
+	spec := getPodSpec(obj)
+	if spec == nil {
+		return refs
+	}
+	index := map[string]int{}
+	addRef := func(kind, name, source string, keys ...string) {
+		if name == "" {
+			return
+		}
+		i, ok := index[kind+"/"+name]
+		if !ok {
+			i = len(refs)
+			index[kind+"/"+name] = i
+			refs = append(refs, ConfigRef{Kind: kind, Name: name, Namespace: obj.GetNamespace()})
+		}
+		refs[i].Sources = appendUnique(refs[i].Sources, source)
+		for _, key := range keys {
+			refs[i].ReferencedKeys = appendUnique(refs[i].ReferencedKeys, key)
+		}
+	}
+	for _, container := range append(spec.InitContainers, spec.Containers...) {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				addRef(configMapKind, envFrom.ConfigMapRef.Name, ConfigRefSourceEnvFrom)
+			}
+			if envFrom.SecretRef != nil {
+				addRef(secretKind, envFrom.SecretRef.Name, ConfigRefSourceEnvFrom)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				addRef(configMapKind, ref.Name, ConfigRefSourceEnv, ref.Key)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				addRef(secretKind, ref.Name, ConfigRefSourceEnv, ref.Key)
+			}
+		}
+	}
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil {
+			addRef(configMapKind, volume.ConfigMap.Name, ConfigRefSourceVolume, getKeyToPathKeys(volume.ConfigMap.Items)...)
+		}
+		if volume.Secret != nil {
+			addRef(secretKind, volume.Secret.SecretName, ConfigRefSourceVolume, getKeyToPathKeys(volume.Secret.Items)...)
+		}
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.ConfigMap != nil {
+				addRef(configMapKind, source.ConfigMap.Name, ConfigRefSourceVolume, getKeyToPathKeys(source.ConfigMap.Items)...)
+			}
+			if source.Secret != nil {
+				addRef(secretKind, source.Secret.Name, ConfigRefSourceVolume, getKeyToPathKeys(source.Secret.Items)...)
+			}
+		}
+	}
+	return refs
+}
+
+// getConfigKeys reads the sorted keys of the ConfigMap or the Secret, the values are dropped right away
+func getConfigKeys(ctx context.Context, cli client.Client, kind, namespace, name string) ([]string, error) {
+	var keys []string
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	switch kind {
+	case configMapKind:
+		cm := corev1.ConfigMap{}
+		if err := cli.Get(ctx, key, &cm); err != nil {
+			return nil, err
+		}
+		for k := range cm.Data {
+			keys = append(keys, k)
+		}
+		for k := range cm.BinaryData {
+			keys = append(keys, k)
+		}
+	case secretKind:
+		secret := corev1.Secret{}
+		if err := cli.Get(ctx, key, &secret); err != nil {
+			return nil, err
+		}
+		for k := range secret.Data {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func getKeyToPathKeys(items []corev1.KeyToPath) []string {
+	var keys []string
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	return keys
+}
+
+func appendUnique(list []string, item string) []string {
+	for _, s := range list {
+		if s == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
@@ -0,0 +1,127 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// the defaults of the probe timings and thresholds applied by the kube-apiserver
+const (
+	defaultProbeTimeoutSeconds   = 1
+	defaultProbePeriodSeconds    = 10
+	defaultProbeSuccessThreshold = 1
+	defaultProbeFailureThreshold = 3
+)
+
+// the types of the probe handlers
+const (
+	ProbeTypeHTTPGet   = "httpGet"
+	ProbeTypeTCPSocket = "tcpSocket"
+	ProbeTypeExec      = "exec"
+)
+
+// ContainerProbes is the probes of a container in the pod template of a workload
+type ContainerProbes struct {
+	Component string       `json:"component,omitempty"`
+	Cluster   string       `json:"cluster"`
+	Kind      string       `json:"kind"`
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace"`
+	Container string       `json:"container"`
+	Liveness  *ProbeConfig `json:"liveness,omitempty"`
+	Readiness *ProbeConfig `json:"readiness,omitempty"`
+	Startup   *ProbeConfig `json:"startup,omitempty"`
+}
+
+// ProbeConfig is the effective config of a probe, the unset timings and thresholds are filled with the defaults
+type ProbeConfig struct {
+	Type string `json:"type"`
+	// Scheme, Host, Path and Port are set for the httpGet probe, Host and Port for the tcpSocket probe
+	Scheme              string   `json:"scheme,omitempty"`
+	Host                string   `json:"host,omitempty"`
+	Path                string   `json:"path,omitempty"`
+	Port                string   `json:"port,omitempty"`
+	Command             []string `json:"command,omitempty"`
+	InitialDelaySeconds int32    `json:"initialDelaySeconds"`
+	TimeoutSeconds      int32    `json:"timeoutSeconds"`
+	PeriodSeconds       int32    `json:"periodSeconds"`
+	SuccessThreshold    int32    `json:"successThreshold"`
+	FailureThreshold    int32    `json:"failureThreshold"`
+}
+
+// collectProbes lists the probes of the containers in the pod templates of the workloads in the resources,
+// the containers without any probe are skipped. The init containers are not listed since they can't have probes.
+func collectProbes(resources []Resource) []ContainerProbes {
+	probes := []ContainerProbes{}
+	for _, res := range resources {
+		spec := getPodSpec(res.Object)
+		if spec == nil {
+			continue
+		}
+		for _, container := range spec.Containers {
+			p := ContainerProbes{
+				Component: res.Component,
+				Cluster:   normalizeClusterName(res.Cluster),
+				Kind:      res.Object.GetKind(),
+				Name:      res.Object.GetName(),
+				Namespace: res.Object.GetNamespace(),
+				Container: container.Name,
+				Liveness:  newProbeConfig(container.LivenessProbe),
+				Readiness: newProbeConfig(container.ReadinessProbe),
+				Startup:   newProbeConfig(container.StartupProbe),
+			}
+			if p.Liveness != nil || p.Readiness != nil || p.Startup != nil {
+				probes = append(probes, p)
+			}
+		}
+	}
+	return probes
+}
+
+func newProbeConfig(probe *corev1.Probe) *ProbeConfig {
+	if probe == nil {
+		return nil
+	}
+	config := &ProbeConfig{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		TimeoutSeconds:      withDefault(probe.TimeoutSeconds, defaultProbeTimeoutSeconds),
+		PeriodSeconds:       withDefault(probe.PeriodSeconds, defaultProbePeriodSeconds),
+		SuccessThreshold:    withDefault(probe.SuccessThreshold, defaultProbeSuccessThreshold),
+		FailureThreshold:    withDefault(probe.FailureThreshold, defaultProbeFailureThreshold),
+	}
+	switch {
+	case probe.HTTPGet != nil:
+		config.Type, config.Host, config.Path = ProbeTypeHTTPGet, probe.HTTPGet.Host, probe.HTTPGet.Path
+		config.Port, config.Scheme = probe.HTTPGet.Port.String(), string(probe.HTTPGet.Scheme)
+		if config.Scheme == "" {
+			config.Scheme = string(corev1.URISchemeHTTP)
+		}
+	case probe.TCPSocket != nil:
+		config.Type, config.Host, config.Port = ProbeTypeTCPSocket, probe.TCPSocket.Host, probe.TCPSocket.Port.String()
+	case probe.Exec != nil:
+		config.Type, config.Command = ProbeTypeExec, probe.Exec.Command
+	}
+	return config
+}
+
+func withDefault(n, defaultValue int32) int32 {
+	if n == 0 {
+		return defaultValue
+	}
+	return n
+}
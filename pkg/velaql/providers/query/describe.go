@@ -0,0 +1,127 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// DefaultDescribeEventsLimit is the number of the most recent events in the description of a resource
+const DefaultDescribeEventsLimit = 10
+
+// ResourceDescription describes a resource like kubectl describe
+type ResourceDescription struct {
+	Metadata   ResourceMetadata    `json:"metadata"`
+	Conditions []ResourceCondition `json:"conditions"`
+	Events     []ResourceEvent     `json:"events"`
+	// Pods are the pods of the workload, it's empty if the resource is not a workload
+	Pods []PodSummary `json:"pods,omitempty"`
+}
+
+// ResourceMetadata is the metadata of the described resource
+type ResourceMetadata struct {
+	APIVersion        string            `json:"apiVersion"`
+	Kind              string            `json:"kind"`
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	CreationTimestamp metav1.Time       `json:"creationTimestamp"`
+	// Owners are the owners of the resource in the format of kind/name
+	Owners []string `json:"owners,omitempty"`
+}
+
+// ResourceCondition is a condition in the status of the resource
+type ResourceCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// describeResource fetches the resource and describes it with its conditions, recent events and pods
+func describeResource(cli client.Client, ref *unstructured.Unstructured, cluster string) (*ResourceDescription, error) {
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	obj := new(unstructured.Unstructured)
+	obj.SetGroupVersionKind(ref.GroupVersionKind())
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: ref.GetNamespace(), Name: ref.GetName()}, obj); err != nil {
+		return nil, err
+	}
+	description := &ResourceDescription{
+		Metadata: ResourceMetadata{
+			APIVersion:        obj.GetAPIVersion(),
+			Kind:              obj.GetKind(),
+			Name:              obj.GetName(),
+			Namespace:         obj.GetNamespace(),
+			Labels:            obj.GetLabels(),
+			Annotations:       obj.GetAnnotations(),
+			CreationTimestamp: obj.GetCreationTimestamp(),
+		},
+		Conditions: []ResourceCondition{},
+		Events:     []ResourceEvent{},
+	}
+	for _, owner := range obj.GetOwnerReferences() {
+		description.Metadata.Owners = append(description.Metadata.Owners, fmt.Sprintf("%s/%s", owner.Kind, owner.Name))
+	}
+	if conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions"); err == nil && found {
+		bs, err := json.Marshal(conditions)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(bs, &description.Conditions); err != nil {
+			return nil, err
+		}
+	}
+
+	eventList := corev1.EventList{}
+	if err := cli.List(ctx, &eventList, client.InNamespace(obj.GetNamespace()), client.MatchingFieldsSelector{
+		Selector: getEventFieldSelector(obj),
+	}); err != nil {
+		return nil, err
+	}
+	sortEventsByTime(eventList.Items)
+	for i, event := range eventList.Items {
+		if i >= DefaultDescribeEventsLimit {
+			break
+		}
+		description.Events = append(description.Events, newResourceEvent(event))
+	}
+
+	pods, err := NewPodCollector(obj.GroupVersionKind())(cli, obj, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) > 0 {
+		summary, err := summarizePods(cli, pods, cluster)
+		if err != nil {
+			return nil, err
+		}
+		setPodsOwner(summary, obj)
+		description.Pods = summary.Pods
+	}
+	return description, nil
+}
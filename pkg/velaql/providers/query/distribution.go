@@ -0,0 +1,102 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReplicaCount is the number of the pods of a component in a cluster
+type ReplicaCount struct {
+	ReadyReplicas int `json:"readyReplicas"`
+	TotalReplicas int `json:"totalReplicas"`
+}
+
+// collectDistribution counts the pods of the workloads in the resources by component and cluster. A workload without
+// pods is counted as 0/0, the resources of no component or which don't create pods are skipped.
+func collectDistribution(cli client.Client, resources []Resource) (map[string]map[string]ReplicaCount, error) {
+	distribution := map[string]map[string]ReplicaCount{}
+	// a pod is counted once even if it is collected from both the HelmRelease and its workload
+	counted := map[string]map[types.NamespacedName]bool{}
+	for _, res := range resources {
+		gvk := res.Object.GroupVersionKind()
+		if res.Component == "" || !hasPodCollector(gvk) {
+			continue
+		}
+		cluster := normalizeClusterName(res.Cluster)
+		collector := NewPodCollector(gvk)
+		if isHelmRelease(gvk) {
+			collector = helmReleasePodCollector
+		}
+		objs, err := collector(cli, res.Object, res.Cluster)
+		if err != nil {
+			return nil, err
+		}
+		if distribution[res.Component] == nil {
+			distribution[res.Component] = map[string]ReplicaCount{}
+		}
+		if counted[cluster] == nil {
+			counted[cluster] = map[types.NamespacedName]bool{}
+		}
+		count := distribution[res.Component][cluster]
+		for _, obj := range objs {
+			key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+			if counted[cluster][key] {
+				continue
+			}
+			counted[cluster][key] = true
+			pod := corev1.Pod{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pod); err != nil {
+				return nil, err
+			}
+			count.TotalReplicas++
+			if isPodReady(pod) {
+				count.ReadyReplicas++
+			}
+		}
+		distribution[res.Component][cluster] = count
+	}
+	return distribution, nil
+}
+
+func hasPodCollector(gvk schema.GroupVersionKind) bool {
+	if isHelmRelease(gvk) {
+		return true
+	}
+	if _, ok := podCollectorMap[gvk]; ok {
+		return true
+	}
+	for _, workload := range standardWorkloads {
+		if gvk == workload {
+			return true
+		}
+	}
+	return false
+}
+
+func isPodReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	apis "github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// ResourceDrift records the difference between the configuration applied by KubeVela and the live object
+type ResourceDrift struct {
+	Drifted bool         `json:"drifted"`
+	Added   []DriftField `json:"added,omitempty"`
+	Removed []DriftField `json:"removed,omitempty"`
+	Changed []DriftField `json:"changed,omitempty"`
+}
+
+// DriftField is a field path whose value differs between the applied configuration and the live object.
+// Applied is null for added fields and Live is null for removed fields.
+type DriftField struct {
+	Path    string      `json:"path"`
+	Applied interface{} `json:"applied"`
+	Live    interface{} `json:"live"`
+}
+
+// metadataMapPaths are the free-form maps whose extra keys on the live object are reported as added.
+// Other extra fields on the live object are usually defaulted by the apiserver, so they are not drift.
+var metadataMapPaths = []string{"metadata.labels", "metadata.annotations"}
+
+// serverManagedMetadata are the metadata fields set by the apiserver, they are never compared
+var serverManagedMetadata = []string{"creationTimestamp", "resourceVersion", "uid", "generation", "managedFields", "selfLink"}
+
+// computeResourceDrift compares the live object with the last applied configuration recorded in its annotations
+func computeResourceDrift(live *unstructured.Unstructured) (*ResourceDrift, error) {
+	lastApplied, ok := live.GetAnnotations()[oam.AnnotationLastAppliedConfig]
+	if !ok || lastApplied == "" {
+		return nil, errors.Errorf("fail to find the last applied configuration of %s %s", live.GroupVersionKind().String(), klog.KObj(live))
+	}
+	applied := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(lastApplied), &applied); err != nil {
+		return nil, errors.Wrap(err, "invalid last applied configuration")
+	}
+	// round trip the live object through json so that numbers are compared in the same representation
+	liveData, err := live.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	current := map[string]interface{}{}
+	if err = json.Unmarshal(liveData, &current); err != nil {
+		return nil, err
+	}
+	removeUncomparableFields(applied)
+	removeUncomparableFields(current)
+
+	drift := &ResourceDrift{}
+	diffFields(nil, applied, current, drift)
+	drift.Drifted = len(drift.Added) != 0 || len(drift.Removed) != 0 || len(drift.Changed) != 0
+	return drift, nil
+}
+
+// removeUncomparableFields drops the status, the server managed metadata and the filtered annotations
+func removeUncomparableFields(obj map[string]interface{}) {
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		for _, key := range serverManagedMetadata {
+			delete(metadata, key)
+		}
+	}
+	annotations, found, err := unstructured.NestedMap(obj, "metadata", "annotations")
+	if err != nil || !found {
+		return
+	}
+	for _, key := range apis.DefaultFilterAnnots {
+		delete(annotations, key)
+	}
+	delete(annotations, oam.AnnotationLastAppliedConfig)
+	_ = unstructured.SetNestedMap(obj, annotations, "metadata", "annotations")
+}
+
+func diffFields(path []string, applied, live map[string]interface{}, drift *ResourceDrift) {
+	for _, key := range sortedKeys(applied) {
+		fieldPath := append(append([]string{}, path...), key)
+		liveValue, ok := live[key]
+		if !ok && applied[key] == nil {
+			// a null in the applied configuration, such as a typed empty creationTimestamp, means unset
+			continue
+		}
+		if !ok {
+			drift.Removed = append(drift.Removed, DriftField{Path: strings.Join(fieldPath, "."), Applied: applied[key]})
+			continue
+		}
+		diffValue(fieldPath, applied[key], liveValue, drift)
+	}
+	currentPath := strings.Join(path, ".")
+	for _, p := range metadataMapPaths {
+		if p != currentPath {
+			continue
+		}
+		for _, key := range sortedKeys(live) {
+			if _, ok := applied[key]; !ok {
+				drift.Added = append(drift.Added, DriftField{Path: currentPath + "." + key, Live: live[key]})
+			}
+		}
+	}
+}
+
+// diffValue compares maps key by key and lists element by element, extra keys on live maps are not drift
+func diffValue(path []string, applied, live interface{}, drift *ResourceDrift) {
+	switch appliedValue := applied.(type) {
+	case map[string]interface{}:
+		if liveMap, ok := live.(map[string]interface{}); ok {
+			diffFields(path, appliedValue, liveMap, drift)
+			return
+		}
+	case []interface{}:
+		if liveList, ok := live.([]interface{}); ok {
+			diffList(path, appliedValue, liveList, drift)
+			return
+		}
+	}
+	if !reflect.DeepEqual(applied, live) {
+		drift.Changed = append(drift.Changed, DriftField{Path: strings.Join(path, "."), Applied: applied, Live: live})
+	}
+}
+
+func diffList(path []string, applied, live []interface{}, drift *ResourceDrift) {
+	for i := range applied {
+		elemPath := append(append([]string{}, path...), strconv.Itoa(i))
+		if i >= len(live) {
+			drift.Removed = append(drift.Removed, DriftField{Path: strings.Join(elemPath, "."), Applied: applied[i]})
+			continue
+		}
+		diffValue(elemPath, applied[i], live[i], drift)
+	}
+	for i := len(applied); i < len(live); i++ {
+		elemPath := append(append([]string{}, path...), strconv.Itoa(i))
+		drift.Added = append(drift.Added, DriftField{Path: strings.Join(elemPath, "."), Live: live[i]})
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,51 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/oam-dev/kubevela/pkg/cue/model/value"
+)
+
+// QueryError is the structured error of a query, the code and the reason are read from the status of the kubernetes API error
+type QueryError struct {
+	Code    int32               `json:"code"`
+	Reason  metav1.StatusReason `json:"reason"`
+	Message string              `json:"message"`
+}
+
+// NewQueryError converts the error to a structured error, an error which is not returned by the kubernetes API is an internal error
+func NewQueryError(err error) QueryError {
+	var status kerrors.APIStatus
+	if errors.As(err, &status) && status.Status().Reason != metav1.StatusReasonUnknown {
+		return QueryError{Code: status.Status().Code, Reason: status.Status().Reason, Message: err.Error()}
+	}
+	return QueryError{Code: http.StatusInternalServerError, Reason: metav1.StatusReasonInternalError, Message: err.Error()}
+}
+
+// fillQueryError fills the error message under err for backward compatibility, and the structured error under errDetail
+func fillQueryError(v *value.Value, err error) error {
+	if fillErr := v.FillObject(err.Error(), "err"); fillErr != nil {
+		return fillErr
+	}
+	return v.FillObject(NewQueryError(err), "errDetail")
+}
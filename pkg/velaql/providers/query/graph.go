@@ -0,0 +1,131 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"fmt"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+const (
+	// HealthStatusHealthy means the workload or trait of the resource is healthy
+	HealthStatusHealthy = "healthy"
+	// HealthStatusUnhealthy means the workload or trait of the resource is unhealthy
+	HealthStatusUnhealthy = "unhealthy"
+	// HealthStatusUnknown means the health of the resource is not reported by the application
+	HealthStatusUnknown = "unknown"
+
+	// EdgeTypeOwner is the edge from the owner to the resource it owns
+	EdgeTypeOwner = "owner"
+	// EdgeTypeTrait is the edge from the workload to the trait resource of the same component
+	EdgeTypeTrait = "trait"
+)
+
+// AppGraph is the topology of the resources created by the application
+type AppGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a resource in the application graph
+type GraphNode struct {
+	ID         string `json:"id"`
+	Cluster    string `json:"cluster"`
+	Component  string `json:"component"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Health     string `json:"health"`
+}
+
+// GraphEdge is a relationship between two resources in the application graph
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// buildAppGraph builds the graph of the resources, the health is read from the status of the application
+func buildAppGraph(resources []Resource, status common.AppStatus) *AppGraph {
+	graph := &AppGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	uidToID := map[string]string{}
+	workloads := map[string]string{}
+	for _, res := range resources {
+		obj := res.Object
+		id := fmt.Sprintf("%s/%s/%s/%s/%s", res.Cluster, obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:         id,
+			Cluster:    res.Cluster,
+			Component:  res.Component,
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			Health:     getResourceHealth(res, status),
+		})
+		// the uid is unique only in one cluster
+		uidToID[res.Cluster+"/"+string(obj.GetUID())] = id
+		if obj.GetLabels()[oam.LabelOAMResourceType] == oam.ResourceTypeWorkload {
+			workloads[res.Cluster+"/"+res.Component] = id
+		}
+	}
+	for i, res := range resources {
+		for _, owner := range res.Object.GetOwnerReferences() {
+			if from, ok := uidToID[res.Cluster+"/"+string(owner.UID)]; ok {
+				graph.Edges = append(graph.Edges, GraphEdge{From: from, To: graph.Nodes[i].ID, Type: EdgeTypeOwner})
+			}
+		}
+		if res.Object.GetLabels()[oam.LabelOAMResourceType] == oam.ResourceTypeTrait {
+			if from, ok := workloads[res.Cluster+"/"+res.Component]; ok {
+				graph.Edges = append(graph.Edges, GraphEdge{From: from, To: graph.Nodes[i].ID, Type: EdgeTypeTrait})
+			}
+		}
+	}
+	return graph
+}
+
+// getResourceHealth finds the health of the workload or trait which creates the resource
+func getResourceHealth(res Resource, status common.AppStatus) string {
+	labels := res.Object.GetLabels()
+	health := HealthStatusUnknown
+	for _, service := range status.Services {
+		if service.Name != res.Component {
+			continue
+		}
+		healthy, found := service.Healthy, true
+		if labels[oam.LabelOAMResourceType] == oam.ResourceTypeTrait {
+			found = false
+			for _, trait := range service.Traits {
+				if trait.Type == labels[oam.TraitTypeLabel] {
+					healthy, found = trait.Healthy, true
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+		// the resource is unhealthy if it is unhealthy in any env
+		if !healthy {
+			return HealthStatusUnhealthy
+		}
+		health = HealthStatusHealthy
+	}
+	return health
+}
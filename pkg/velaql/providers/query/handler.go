@@ -18,12 +18,20 @@ package query
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	stdctx "context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -33,16 +41,20 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	apis "github.com/oam-dev/kubevela/apis/types"
 	helmapi "github.com/oam-dev/kubevela/pkg/appfile/helm/flux2apis"
 	"github.com/oam-dev/kubevela/pkg/cue/model/value"
 	"github.com/oam-dev/kubevela/pkg/multicluster"
+	"github.com/oam-dev/kubevela/pkg/oam"
 	"github.com/oam-dev/kubevela/pkg/utils"
 	wfContext "github.com/oam-dev/kubevela/pkg/workflow/context"
 	"github.com/oam-dev/kubevela/pkg/workflow/providers"
@@ -56,39 +68,112 @@ const (
 	HelmReleaseKind = "HelmRelease"
 )
 
-var fluxcdGroupVersion = schema.GroupVersion{Group: "helm.toolkit.fluxcd.io", Version: "v2beta1"}
+// isHelmRelease checks if the resource is a HelmRelease of fluxcd, any version in the group is matched
+// since the newer fluxcd serves the HelmRelease in v2beta2 and v2
+func isHelmRelease(gvk schema.GroupVersionKind) bool {
+	return gvk.Group == helmapi.HelmReleaseGVK.Group && gvk.Kind == HelmReleaseKind
+}
 
 type provider struct {
 	cli client.Client
 	cfg *rest.Config
+	// allowedClusters restricts the clusters the handlers can reach
+	allowedClusters clusterAllowlist
+	// ingressClassPortAnnotations are the port annotations of the ingress classes used by the endpoints of ingresses
+	ingressClassPortAnnotations map[string]IngressPortAnnotations
+
+	// clientSet is created on the first use and shared by the handlers, it serves all the clusters since the cluster
+	// of a request is carried by its context and routed by the cluster gateway
+	clientSet   kubernetes.Interface
+	clientSetMu sync.Mutex
+}
+
+const (
+	// ClientSetQPS and ClientSetBurst rate limit the shared clientset if the rest config doesn't set the limits
+	ClientSetQPS   = 50
+	ClientSetBurst = 100
+)
+
+// getClientSet returns the shared clientset and creates it on the first call
+func (h *provider) getClientSet() (kubernetes.Interface, error) {
+	h.clientSetMu.Lock()
+	defer h.clientSetMu.Unlock()
+	if h.clientSet != nil {
+		return h.clientSet, nil
+	}
+	cfg := rest.CopyConfig(h.cfg)
+	if cfg.QPS == 0 && cfg.Burst == 0 && cfg.RateLimiter == nil {
+		cfg.QPS, cfg.Burst = ClientSetQPS, ClientSetBurst
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	h.clientSet = clientSet
+	return clientSet, nil
 }
 
 // Resource refer to an object with cluster info
 type Resource struct {
-	Cluster   string                     `json:"cluster"`
-	Component string                     `json:"component"`
-	Revision  string                     `json:"revision"`
-	Object    *unstructured.Unstructured `json:"object"`
+	Cluster   string `json:"cluster"`
+	Component string `json:"component"`
+	Revision  string `json:"revision"`
+	// Trait is the type of the trait which creates the resource, it is empty if the resource is created by the workload
+	Trait  string                     `json:"trait,omitempty"`
+	Object *unstructured.Unstructured `json:"object"`
 }
 
 // Option is the query option
 type Option struct {
-	Name      string       `json:"name"`
-	Namespace string       `json:"namespace"`
-	Filter    FilterOption `json:"filter,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Cluster is the cluster the application lives in, the hub cluster is used if not set.
+	// It is only used to get the application by collectServiceEndpoints.
+	Cluster string       `json:"cluster,omitempty"`
+	Filter  FilterOption `json:"filter,omitempty"`
+	// Concurrency is the max number of clusters collected in parallel, DefaultCollectConcurrency is used if not set
+	Concurrency int `json:"concurrency,omitempty"`
+	// PerKindLimit caps the number of the returned resources of each kind, all the resources are returned if not set
+	PerKindLimit int `json:"perKindLimit,omitempty"`
+	// PublishVersion collects the resources deployed by the publish version instead of the latest ones
+	PublishVersion string `json:"publishVersion,omitempty"`
 }
 
-// FilterOption filter resource created by component
+// FilterOption filter resource created by component, a resource is returned only if it matches all the options
 type FilterOption struct {
 	Cluster          string   `json:"cluster,omitempty"`
 	ClusterNamespace string   `json:"clusterNamespace,omitempty"`
 	Components       []string `json:"components,omitempty"`
+	// Annotations filter the resources whose annotations contain all the key/value pairs
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// OlderThan and NewerThan filter the resources by their age, such as 72h, they are parsed by time.ParseDuration
+	OlderThan string `json:"olderThan,omitempty"`
+	NewerThan string `json:"newerThan,omitempty"`
+	// CreatedBy filters the resources created by the traits or the workloads, it is ResourceCreatedByTrait or
+	// ResourceCreatedByWorkload
+	CreatedBy string `json:"createdBy,omitempty"`
 }
 
 // ServiceEndpoint record the access endpoints of the application services
 type ServiceEndpoint struct {
 	Endpoint Endpoint               `json:"endpoint"`
 	Ref      corev1.ObjectReference `json:"ref"`
+	// Component is read from the labels of the service or ingress, or the HelmRelease which creates them
+	Component string `json:"component,omitempty"`
+	// Pending means the endpoint is still being provisioned, such as a LoadBalancer service without ingress addresses
+	Pending bool `json:"pending,omitempty"`
+	// Probe is the result of probing the endpoint, it is set only if the probe is requested for the HTTP(S) endpoints
+	Probe *EndpointProbe `json:"probe,omitempty"`
+	// BackendReady is set for the ingress endpoints, it is true if the backend service of the path has any ready
+	// endpoint, so that a route which exists but can't serve is told apart
+	BackendReady *bool `json:"backendReady,omitempty"`
+	// Headless means the endpoint is a pod behind a headless service, the host is the DNS name of the pod
+	Headless bool `json:"headless,omitempty"`
+	// Pod is the name of the pod of the headless endpoint
+	Pod string `json:"pod,omitempty"`
+
+	// backendService is the name of the backend service of the ingress path
+	backendService string
 }
 
 // String return endpoint URL
@@ -130,6 +215,38 @@ type Endpoint struct {
 
 	// the path for the endpoint
 	Path string `json:"path,omitempty"`
+
+	// the TLS certificate of the https endpoint created by ingress
+	// +optional
+	TLS *EndpointTLS `json:"tls,omitempty"`
+
+	// the family of the host, IPv4, IPv6 or hostname, it's the primary IP family of the service for the node port
+	// +optional
+	IPFamily string `json:"ipFamily,omitempty"`
+}
+
+// IPFamilyHostname is the IP family of the endpoint whose host is a domain name
+const IPFamilyHostname = "hostname"
+
+// getHostIPFamily returns the IP family of the address, or hostname if it's not an IP
+func getHostIPFamily(host string) string {
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return IPFamilyHostname
+	case ip.To4() != nil:
+		return string(corev1.IPv4Protocol)
+	default:
+		return string(corev1.IPv6Protocol)
+	}
+}
+
+// EndpointTLS is the TLS certificate that backs an endpoint
+type EndpointTLS struct {
+	SecretName string `json:"secretName"`
+	// CommonName and NotAfter are read from the certificate in the secret, they are empty if the secret can't be read
+	CommonName string   `json:"commonName,omitempty"`
+	NotAfter   *v1.Time `json:"notAfter,omitempty"`
 }
 
 // ListResourcesInApp lists CRs created by Application
@@ -142,10 +259,35 @@ func (h *provider) ListResourcesInApp(ctx wfContext.Context, v *value.Value, act
 	if err = val.UnmarshalTo(&opt); err != nil {
 		return err
 	}
-	collector := NewAppCollector(h.cli, opt)
+	collector := h.newAppCollector(opt)
 	appResList, err := collector.CollectResourceFromApp()
 	if err != nil {
-		return v.FillObject(err.Error(), "err")
+		return fillQueryError(v, err)
+	}
+	if withCoverage, err := v.GetBool("withClusterCoverage"); err == nil && withCoverage {
+		app := new(v1beta1.Application)
+		if err = h.cli.Get(stdctx.Background(), client.ObjectKey{Name: opt.Name, Namespace: opt.Namespace}, app); err != nil {
+			return fillQueryError(v, err)
+		}
+		coverage, err := collectClusterCoverage(app, appResList)
+		if err != nil {
+			return fillQueryError(v, err)
+		}
+		if err = v.FillObject(coverage, "clusterCoverage"); err != nil {
+			return err
+		}
+	}
+	if withNamespaces, err := v.GetBool("withNamespaces"); err == nil && withNamespaces {
+		if err = v.FillObject(collectResourceNamespaces(appResList, opt.Namespace), "namespaces"); err != nil {
+			return err
+		}
+	}
+	if opt.PerKindLimit > 0 {
+		var counts map[string]int
+		appResList, counts = limitResourcesPerKind(appResList, opt.PerKindLimit)
+		if err = v.FillObject(counts, "counts"); err != nil {
+			return err
+		}
 	}
 	return v.FillObject(appResList, "list")
 }
@@ -155,10 +297,13 @@ func (h *provider) CollectPods(ctx wfContext.Context, v *value.Value, act types.
 	if err != nil {
 		return err
 	}
-	cluster, err := v.GetString("cluster")
+	cluster, err := getClusterName(v)
 	if err != nil {
 		return err
 	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
 	obj := new(unstructured.Unstructured)
 	if err = val.UnmarshalTo(obj); err != nil {
 		return err
@@ -167,8 +312,8 @@ func (h *provider) CollectPods(ctx wfContext.Context, v *value.Value, act types.
 	var pods []*unstructured.Unstructured
 	var collector PodCollector
 
-	switch obj.GroupVersionKind() {
-	case fluxcdGroupVersion.WithKind(HelmReleaseKind):
+	switch {
+	case isHelmRelease(obj.GroupVersionKind()):
 		collector = helmReleasePodCollector
 	default:
 		collector = NewPodCollector(obj.GroupVersionKind())
@@ -176,7 +321,43 @@ func (h *provider) CollectPods(ctx wfContext.Context, v *value.Value, act types.
 
 	pods, err = collector(h.cli, obj, cluster)
 	if err != nil {
-		return v.FillObject(err.Error(), "err")
+		return fillQueryError(v, err)
+	}
+	summary, err := summarizePods(h.cli, pods, cluster)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	setPodsOwner(summary, obj)
+	var phases []string
+	if phasesValue, err := v.LookupValue("phases"); err == nil {
+		if err = phasesValue.UnmarshalTo(&phases); err != nil {
+			return errors.Wrapf(err, "invalid phases")
+		}
+	}
+	pods = filterPodsByStatus(pods, summary, phases)
+	if includeEvents, err := v.GetBool("includeEvents"); err == nil && includeEvents {
+		if err = attachPodEvents(h.cli, summary, cluster, DefaultPodEventsLimit); err != nil {
+			return fillQueryError(v, err)
+		}
+	}
+	if includeEnv, err := v.GetBool("includeEnv"); err == nil && includeEnv {
+		if err = attachPodEnv(h.cli, summary, pods, cluster); err != nil {
+			return fillQueryError(v, err)
+		}
+	}
+	if includeWorkload, err := v.GetBool("includeWorkload"); err == nil && includeWorkload {
+		rollout, err := getWorkloadRollout(h.cli, obj, cluster)
+		if err != nil {
+			return fillQueryError(v, err)
+		}
+		if rollout != nil {
+			if err = v.FillObject(rollout, "workload"); err != nil {
+				return err
+			}
+		}
+	}
+	if err = v.FillObject(summary, "summary"); err != nil {
+		return err
 	}
 	return v.FillObject(pods, "list")
 }
@@ -186,10 +367,13 @@ func (h *provider) SearchEvents(ctx wfContext.Context, v *value.Value, act types
 	if err != nil {
 		return err
 	}
-	cluster, err := v.GetString("cluster")
+	cluster, err := getClusterName(v)
 	if err != nil {
 		return err
 	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
 	obj := new(unstructured.Unstructured)
 	if err = val.UnmarshalTo(obj); err != nil {
 		return err
@@ -205,16 +389,54 @@ func (h *provider) SearchEvents(ctx wfContext.Context, v *value.Value, act types
 		},
 	}
 	if err := h.cli.List(listCtx, &eventList, listOpts...); err != nil {
-		return v.FillObject(err.Error(), "err")
+		return fillQueryError(v, err)
+	}
+	var reasons []string
+	if reasonsValue, err := v.LookupValue("reasons"); err == nil {
+		if err = reasonsValue.UnmarshalTo(&reasons); err != nil {
+			return errors.Wrapf(err, "invalid reasons")
+		}
+	}
+	eventList.Items = filterEventsByReason(eventList.Items, reasons)
+	if _, err := v.LookupValue("limit"); err == nil {
+		limit, err := v.GetInt64("limit")
+		if err != nil {
+			return errors.Wrapf(err, "invalid limit")
+		}
+		eventList.Items = limitRecentEvents(eventList.Items, int(limit))
 	}
 	return v.FillObject(eventList.Items, "list")
 }
 
+// DescribeResource describes a resource with its conditions, recent events and pods like kubectl describe
+func (h *provider) DescribeResource(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	description, err := describeResource(h.cli, obj, cluster)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(description, "description")
+}
+
 // generatorServiceEndpoints generator service endpoints is available for common component type,
 // such as webservice or helm
 // it can not support the cloud service component currently
 func (h *provider) GeneratorServiceEndpoints(wfctx wfContext.Context, v *value.Value, act types.Action) error {
-	ctx := stdctx.Background()
+	ctx := getContext(wfctx)
 	findResource := func(obj client.Object, name, namespace, cluster string) error {
 		obj.SetNamespace(namespace)
 		obj.SetName(name)
@@ -237,14 +459,119 @@ func (h *provider) GeneratorServiceEndpoints(wfctx wfContext.Context, v *value.V
 	if err = val.UnmarshalTo(&opt); err != nil {
 		return err
 	}
-	app := new(v1beta1.Application)
-	err = findResource(app, opt.Name, opt.Namespace, "")
+	if err = h.allowedClusters.checkOption(opt); err != nil {
+		return fillQueryError(v, err)
+	}
+	var waitSeconds int64
+	if _, err := v.LookupValue("waitSeconds"); err == nil {
+		if waitSeconds, err = v.GetInt64("waitSeconds"); err != nil {
+			return errors.Wrapf(err, "invalid waitSeconds")
+		}
+	}
+	healthyOnly, err := v.GetBool("healthyOnly")
+	healthyOnly = err == nil && healthyOnly
+	var skippedUnhealthy int
+	collectEndpoints := func() ([]ServiceEndpoint, error) {
+		app := new(v1beta1.Application)
+		if err := findResource(app, opt.Name, opt.Namespace, normalizeClusterName(opt.Cluster)); err != nil {
+			return nil, fmt.Errorf("query app failure %w", err)
+		}
+		serviceEndpoints, skipped := h.collectServiceEndpoints(ctx, app, opt, healthyOnly, findResource)
+		skippedUnhealthy = skipped
+		return serviceEndpoints, nil
+	}
+	serviceEndpoints, err := collectEndpoints()
 	if err != nil {
-		return fmt.Errorf("query app failure %w", err)
+		return err
+	}
+	// poll the endpoints until one of them is ready, the endpoints available at the deadline are returned
+	ready := isAnyEndpointReady(serviceEndpoints)
+	if !ready && waitSeconds > 0 {
+		// the polling stops when the query is cancelled too
+		pollCtx, cancel := stdctx.WithTimeout(ctx, endpointsWaitTimeout(waitSeconds))
+		_ = wait.PollImmediateUntil(endpointsPollInterval, func() (bool, error) {
+			if serviceEndpoints, err = collectEndpoints(); err != nil {
+				return false, err
+			}
+			ready = isAnyEndpointReady(serviceEndpoints)
+			return ready, nil
+		}, pollCtx.Done())
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	if probe, err := v.GetBool("probe"); err == nil && probe {
+		probeEndpoints(ctx, serviceEndpoints)
+	}
+	if err = v.FillObject(ready, "ready"); err != nil {
+		return err
+	}
+	if healthyOnly {
+		if err = v.FillObject(skippedUnhealthy, "skippedUnhealthy"); err != nil {
+			return err
+		}
+	}
+	if groupByComponent, err := v.GetBool("groupByComponent"); err == nil && groupByComponent {
+		if err = v.FillObject(groupEndpointsByComponent(serviceEndpoints), "groups"); err != nil {
+			return err
+		}
+	}
+	return v.FillObject(serviceEndpoints, "list")
+}
+
+// getComponentsHealth returns whether each component is healthy, a component is unhealthy if it is unhealthy in any env
+func getComponentsHealth(status common.AppStatus) map[string]bool {
+	healthy := map[string]bool{}
+	for _, service := range status.Services {
+		if h, ok := healthy[service.Name]; ok && !h {
+			continue
+		}
+		healthy[service.Name] = service.Healthy
+	}
+	return healthy
+}
+
+// endpointsPollInterval is the interval to poll the endpoints if waitSeconds is set
+var endpointsPollInterval = 2 * time.Second
+
+// maxEndpointsWaitSeconds caps waitSeconds so that a query can't hold the provider for long
+const maxEndpointsWaitSeconds = 60
+
+// endpointsWaitTimeout returns how long the endpoints are polled for waitSeconds
+func endpointsWaitTimeout(waitSeconds int64) time.Duration {
+	if waitSeconds > maxEndpointsWaitSeconds {
+		waitSeconds = maxEndpointsWaitSeconds
 	}
+	return time.Duration(waitSeconds) * time.Second
+}
+
+// isAnyEndpointReady checks if any endpoint is resolved and not being provisioned
+func isAnyEndpointReady(endpoints []ServiceEndpoint) bool {
+	for _, endpoint := range endpoints {
+		if !endpoint.Pending {
+			return true
+		}
+	}
+	return false
+}
+
+// collectServiceEndpoints collects the endpoints of the services and ingresses applied by the application,
+// if healthyOnly is set, the resources of the components which are not healthy are skipped and counted
+func (h *provider) collectServiceEndpoints(ctx stdctx.Context, app *v1beta1.Application, opt Option, healthyOnly bool,
+	findResource func(obj client.Object, name, namespace, cluster string) error) ([]ServiceEndpoint, int) {
 	var serviceEndpoints []ServiceEndpoint
+	healthy := getComponentsHealth(app.Status)
+	skippedUnhealthy := 0
+	isUnhealthy := func(obj client.Object) bool {
+		if healthyOnly && !healthy[obj.GetLabels()[oam.LabelAppComponent]] {
+			skippedUnhealthy++
+			return true
+		}
+		return false
+	}
 	for _, resource := range app.Status.AppliedResources {
-		if !isResourceInTargetCluster(opt.Filter, resource) {
+		if !isResourceInTargetCluster(opt.Filter, resource) || !h.allowedClusters.allows(resource.Cluster) {
 			continue
 		}
 		switch resource.Kind {
@@ -256,7 +583,13 @@ func (h *provider) GeneratorServiceEndpoints(wfctx wfContext.Context, v *value.V
 					klog.Error(err, fmt.Sprintf("find v1 Ingress %s/%s from cluster %s failure", resource.Name, resource.Namespace, resource.Cluster))
 					continue
 				}
-				serviceEndpoints = append(serviceEndpoints, generatorFromIngress(ingress)...)
+				if isUnhealthy(&ingress) {
+					continue
+				}
+				endpoints := setEndpointsComponent(generatorFromIngress(ingress, h.ingressClassPortAnnotations), &ingress)
+				resolveEndpointCertificates(ctx, h.cli, endpoints, resource.Cluster)
+				resolveIngressBackends(h.cli, endpoints, resource.Cluster)
+				serviceEndpoints = append(serviceEndpoints, endpoints...)
 			} else {
 				klog.Warning("not support ingress version", "version", resource.GroupVersionKind())
 			}
@@ -267,18 +600,33 @@ func (h *provider) GeneratorServiceEndpoints(wfctx wfContext.Context, v *value.V
 				klog.Error(err, fmt.Sprintf("find v1 Service %s/%s from cluster %s failure", resource.Name, resource.Namespace, resource.Cluster))
 				continue
 			}
-			serviceEndpoints = append(serviceEndpoints, generatorFromService(service)...)
-		case helmapi.HelmReleaseGVK.Kind:
+			if isUnhealthy(&service) {
+				continue
+			}
+			serviceEndpoints = append(serviceEndpoints, setEndpointsComponent(generatorFromService(service), &service)...)
+			serviceEndpoints = append(serviceEndpoints, setEndpointsComponent(h.collectHeadlessEndpoints(service, resource.Cluster), &service)...)
+		case HelmReleaseKind:
+			if !isHelmRelease(resource.GroupVersionKind()) {
+				continue
+			}
 			obj := new(unstructured.Unstructured)
-			obj.SetNamespace(resource.Namespace)
-			obj.SetName(resource.Name)
+			obj.SetGroupVersionKind(resource.GroupVersionKind())
+			// the services of the HelmRelease don't carry the labels of the application, check the HelmRelease instead
+			if err := findResource(obj, resource.Name, resource.Namespace, resource.Cluster); err != nil {
+				klog.Error(err, fmt.Sprintf("find HelmRelease %s/%s from cluster %s failure", resource.Name, resource.Namespace, resource.Cluster))
+				continue
+			}
+			if isUnhealthy(obj) {
+				continue
+			}
 			hc := NewHelmReleaseCollector(h.cli, obj)
 			services, err := hc.CollectServices(ctx, resource.Cluster)
 			if err != nil {
 				klog.Error(err, "collect service by helm release failure", "helmRelease", resource.Name, "namespace", resource.Namespace, "cluster", resource.Cluster)
 			}
 			for _, service := range services {
-				serviceEndpoints = append(serviceEndpoints, generatorFromService(service)...)
+				serviceEndpoints = append(serviceEndpoints, setEndpointsComponent(generatorFromService(service), obj)...)
+				serviceEndpoints = append(serviceEndpoints, setEndpointsComponent(h.collectHeadlessEndpoints(service, resource.Cluster), obj)...)
 			}
 
 			// only support network/v1beta1
@@ -287,11 +635,46 @@ func (h *provider) GeneratorServiceEndpoints(wfctx wfContext.Context, v *value.V
 				klog.Error(err, "collect ingres by helm release failure", "helmRelease", resource.Name, "namespace", resource.Namespace, "cluster", resource.Cluster)
 			}
 			for _, ing := range ingress {
-				serviceEndpoints = append(serviceEndpoints, generatorFromIngress(ing)...)
+				endpoints := setEndpointsComponent(generatorFromIngress(ing, h.ingressClassPortAnnotations), obj)
+				resolveEndpointCertificates(ctx, h.cli, endpoints, resource.Cluster)
+				resolveIngressBackends(h.cli, endpoints, resource.Cluster)
+				serviceEndpoints = append(serviceEndpoints, endpoints...)
 			}
 		}
 	}
-	return v.FillObject(serviceEndpoints, "list")
+	return serviceEndpoints, skippedUnhealthy
+}
+
+// collectHeadlessEndpoints returns the endpoints of the pods if the service is headless, the failure is only logged
+// like the other resources which fail to be read
+func (h *provider) collectHeadlessEndpoints(service corev1.Service, cluster string) []ServiceEndpoint {
+	if !isHeadlessService(service) {
+		return nil
+	}
+	endpoints, err := generatorFromHeadlessService(h.cli, service, cluster)
+	if err != nil {
+		klog.Error(err, fmt.Sprintf("collect the pods of headless Service %s/%s from cluster %s failure", service.Namespace, service.Name, cluster))
+	}
+	return endpoints
+}
+
+// setEndpointsComponent sets the component of the endpoints from the labels of the object creating them
+func setEndpointsComponent(endpoints []ServiceEndpoint, obj client.Object) []ServiceEndpoint {
+	component := obj.GetLabels()[oam.LabelAppComponent]
+	for i := range endpoints {
+		endpoints[i].Component = component
+	}
+	return endpoints
+}
+
+// groupEndpointsByComponent groups the endpoints by their components, the endpoints without component are grouped by
+// the empty name
+func groupEndpointsByComponent(endpoints []ServiceEndpoint) map[string][]ServiceEndpoint {
+	groups := map[string][]ServiceEndpoint{}
+	for _, endpoint := range endpoints {
+		groups[endpoint.Component] = append(groups[endpoint.Component], endpoint)
+	}
+	return groups
 }
 
 var (
@@ -302,11 +685,47 @@ func isTerminatedContainerNotFound(err error) bool {
 	return err != nil && terminatedContainerNotFoundRegex.MatchString(err.Error())
 }
 
-func (h *provider) CollectLogsInPod(ctx wfContext.Context, v *value.Value, act types.Action) error {
+// normalizeClusterName maps the empty cluster name to the local cluster name, both of them mean the hub cluster
+func normalizeClusterName(cluster string) string {
+	if cluster == "" {
+		return multicluster.ClusterLocalName
+	}
+	return cluster
+}
+
+// getClusterName reads the cluster of the query, the empty cluster is normalized to the local cluster name
+func getClusterName(v *value.Value) (string, error) {
 	cluster, err := v.GetString("cluster")
+	if err != nil {
+		return "", err
+	}
+	return normalizeClusterName(cluster), nil
+}
+
+// newAppCollector creates the collector of the application which skips the resources in the clusters not allowed
+func (h *provider) newAppCollector(opt Option) *AppCollector {
+	collector := NewAppCollector(h.cli, opt)
+	collector.allowedClusters = h.allowedClusters
+	return collector
+}
+
+// getContext returns the context of the query carried by the workflow context, such as the velaql view context,
+// the background context is used if there is none
+func getContext(ctx wfContext.Context) stdctx.Context {
+	if carrier, ok := ctx.(interface{ GetContext() stdctx.Context }); ok {
+		return carrier.GetContext()
+	}
+	return stdctx.Background()
+}
+
+func (h *provider) CollectLogsInPod(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	cluster, err := getClusterName(v)
 	if err != nil {
 		return errors.Wrapf(err, "invalid cluster")
 	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
 	namespace, err := v.GetString("namespace")
 	if err != nil {
 		return errors.Wrapf(err, "invalid namespace")
@@ -323,8 +742,34 @@ func (h *provider) CollectLogsInPod(ctx wfContext.Context, v *value.Value, act t
 	if err = val.UnmarshalTo(opts); err != nil {
 		return errors.Wrapf(err, "invalid log options content")
 	}
-	cliCtx := multicluster.ContextWithClusterName(stdctx.Background(), cluster)
-	clientSet, err := kubernetes.NewForConfig(h.cfg)
+	if sinceTimeValue, err := v.LookupValue("sinceTime"); err == nil && opts.SinceTime == nil {
+		sinceTime, err := sinceTimeValue.CueValue().String()
+		if err != nil {
+			return errors.Wrapf(err, "invalid sinceTime")
+		}
+		t, err := time.Parse(time.RFC3339, sinceTime)
+		if err != nil {
+			return errors.Wrapf(err, "invalid sinceTime %q, it must be a RFC3339 time such as 2006-01-02T15:04:05Z", sinceTime)
+		}
+		opts.SinceTime = &v1.Time{Time: t}
+	}
+	// the log streams are closed once the query is cancelled
+	cliCtx := multicluster.ContextWithClusterName(getContext(ctx), cluster)
+	// the logs are followed until the budget is spent, and what is read so far is returned
+	readCtx := cliCtx
+	if _, err := v.LookupValue("followSeconds"); err == nil {
+		followSeconds, err := v.GetInt64("followSeconds")
+		if err != nil {
+			return errors.Wrapf(err, "invalid followSeconds")
+		}
+		if followSeconds > 0 {
+			opts.Follow = true
+			var cancel stdctx.CancelFunc
+			readCtx, cancel = stdctx.WithTimeout(cliCtx, time.Duration(followSeconds)*time.Second)
+			defer cancel()
+		}
+	}
+	clientSet, err := h.getClientSet()
 	if err != nil {
 		return errors.Wrapf(err, "failed to create kubernetes clientset")
 	}
@@ -332,30 +777,102 @@ func (h *provider) CollectLogsInPod(ctx wfContext.Context, v *value.Value, act t
 	if err != nil {
 		return errors.Wrapf(err, "failed to get pod")
 	}
-	req := clientSet.CoreV1().Pods(namespace).GetLogs(pod, opts)
-	readCloser, err := req.Stream(cliCtx)
-	if err != nil && !isTerminatedContainerNotFound(err) {
-		return errors.Wrapf(err, "failed to get stream logs")
+	// the logs since the last restart are read from the start of the current container, unless the time is set explicitly
+	if sinceLastRestart, err := v.GetBool("sinceLastRestart"); err == nil && sinceLastRestart &&
+		opts.SinceTime == nil && opts.SinceSeconds == nil && !opts.Previous {
+		startTime := getContainerStartTime(podInst, opts.Container)
+		opts.SinceTime = &startTime
+	}
+	containers := []string{opts.Container}
+	if allContainers, err := v.GetBool("allContainers"); err == nil && allContainers {
+		containers = getPodContainerNames(podInst)
 	}
-	r := bufio.NewReader(readCloser)
+	// the init containers are read in order, each one after a separator, and those which never ran are only noted
+	initContainers, err := v.GetBool("initContainers")
+	initContainers = err == nil && initContainers
+	var notStarted map[string]bool
+	if initContainers {
+		containers, notStarted = getInitContainerNames(podInst)
+	}
+	prefix, err := v.GetBool("prefix")
+	prefix = err == nil && prefix
 	var b strings.Builder
+	var truncated bool
 	var readErr error
-	if err == nil {
-		defer func() {
-			_ = readCloser.Close()
-		}()
-		for {
-			s, err := r.ReadString('\n')
-			b.WriteString(s)
-			if err != nil {
-				if !errors.Is(err, io.EOF) {
-					readErr = err
-				}
+	var followStopped bool
+	// the budget is spent only if the reading stops because of it rather than the cancellation of the query
+	budgetSpent := func() bool {
+		return readCtx.Err() != nil && cliCtx.Err() == nil
+	}
+	for _, container := range containers {
+		if budgetSpent() {
+			// the containers left are not read once the follow budget is spent
+			followStopped = true
+			break
+		}
+		if initContainers {
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+				b.WriteString("\n")
+			}
+			// the separators are also counted in the limit of the combined logs
+			b.WriteString(initContainerSeparator(container, notStarted[container]))
+			if opts.LimitBytes != nil && int64(b.Len()) > *opts.LimitBytes {
+				truncated = true
 				break
 			}
+			if notStarted[container] {
+				continue
+			}
 		}
-	} else {
-		readErr = err
+		containerOpts := opts.DeepCopy()
+		containerOpts.Container = container
+		if opts.LimitBytes != nil {
+			remaining := *opts.LimitBytes - int64(b.Len())
+			if remaining <= 0 {
+				// the limit is reached by the previous containers
+				truncated = true
+				break
+			}
+			containerOpts.LimitBytes = &remaining
+		}
+		containerLogs, containerTruncated, containerReadErr, err := streamLogs(readCtx, clientSet, namespace, pod, containerOpts)
+		if err != nil && budgetSpent() {
+			followStopped = true
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to get stream logs")
+		}
+		if err = cliCtx.Err(); err != nil {
+			return errors.Wrapf(err, "the query of the logs is cancelled")
+		}
+		if containerReadErr != nil && budgetSpent() {
+			// the stream is closed by the end of the follow budget, the logs read so far are kept
+			containerReadErr = nil
+			followStopped = true
+		}
+		if readErr == nil {
+			readErr = containerReadErr
+		}
+		if prefix {
+			if container == "" && len(podInst.Spec.Containers) > 0 {
+				container = podInst.Spec.Containers[0].Name
+			}
+			containerLogs = prefixLines(containerLogs, "["+container+"] ")
+		}
+		b.WriteString(containerLogs)
+		// the prefixes are also counted in the limit of the combined logs
+		if opts.LimitBytes != nil && int64(b.Len()) > *opts.LimitBytes {
+			containerTruncated = true
+		}
+		if containerTruncated {
+			truncated = true
+			break
+		}
+	}
+	logs := b.String()
+	if opts.LimitBytes != nil && int64(len(logs)) > *opts.LimitBytes {
+		logs = logs[:*opts.LimitBytes]
 	}
 	toDate := v1.Now()
 	var fromDate v1.Time
@@ -367,131 +884,1007 @@ func (h *provider) CollectLogsInPod(ctx wfContext.Context, v *value.Value, act t
 	} else {
 		fromDate = podInst.CreationTimestamp
 	}
+	info := map[string]interface{}{
+		"fromDate":      fromDate,
+		"toDate":        toDate,
+		"bytesReturned": len(logs),
+	}
+	if truncated {
+		info["truncatedAtLimit"] = true
+	}
+	if followStopped {
+		info["followStopped"] = true
+	}
 	o := map[string]interface{}{
-		"logs": b.String(),
-		"info": map[string]interface{}{
-			"fromDate": fromDate,
-			"toDate":   toDate,
-		},
+		"logs": logs,
+		"info": info,
+	}
+	if compress, err := v.GetBool("compress"); err == nil && compress {
+		logsGzip, err := compressLogs(logs)
+		if err != nil {
+			return errors.Wrapf(err, "failed to compress logs")
+		}
+		o["logs"] = ""
+		o["logsGzip"] = logsGzip
+		info["compressed"] = true
 	}
 	if readErr != nil {
 		o["err"] = readErr.Error()
+		o["errDetail"] = NewQueryError(readErr)
 	}
 	return v.FillObject(o, "outputs")
 }
 
-// Install register handlers to provider discover.
-func Install(p providers.Providers, cli client.Client, cfg *rest.Config) {
-	prd := &provider{
-		cli: cli,
-		cfg: cfg,
+// getContainerStartTime returns the time the current incarnation of the container started,
+// the creation time of the pod is used if the container doesn't report it
+func getContainerStartTime(pod *corev1.Pod, container string) v1.Time {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if container != "" && cs.Name != container {
+			continue
+		}
+		switch {
+		case cs.State.Running != nil && !cs.State.Running.StartedAt.IsZero():
+			return cs.State.Running.StartedAt
+		case cs.State.Terminated != nil && !cs.State.Terminated.StartedAt.IsZero():
+			return cs.State.Terminated.StartedAt
+		case cs.LastTerminationState.Terminated != nil && !cs.LastTerminationState.Terminated.FinishedAt.IsZero():
+			// the container is waiting to restart, the logs after the last termination belong to the next incarnation
+			return cs.LastTerminationState.Terminated.FinishedAt
+		}
+		return pod.CreationTimestamp
 	}
-
-	p.Register(ProviderName, map[string]providers.Handler{
-		"listResourcesInApp":      prd.ListResourcesInApp,
-		"collectPods":             prd.CollectPods,
-		"searchEvents":            prd.SearchEvents,
-		"collectLogsInPod":        prd.CollectLogsInPod,
-		"collectServiceEndpoints": prd.GeneratorServiceEndpoints,
-	})
+	return pod.CreationTimestamp
 }
 
-func generatorFromService(service corev1.Service) []ServiceEndpoint {
-	var serviceEndpoints []ServiceEndpoint
-	switch service.Spec.Type {
-	case corev1.ServiceTypeLoadBalancer:
-		for _, port := range service.Spec.Ports {
-			for _, ingress := range service.Status.LoadBalancer.Ingress {
-				if ingress.Hostname != "" {
-					serviceEndpoints = append(serviceEndpoints, ServiceEndpoint{
-						Endpoint: Endpoint{
-							Protocol: port.Protocol,
-							Host:     ingress.Hostname,
-							Port:     port.Port,
-						},
-						Ref: corev1.ObjectReference{
-							Kind:            service.Kind,
-							Namespace:       service.ObjectMeta.Namespace,
-							Name:            service.ObjectMeta.Name,
-							UID:             service.UID,
-							APIVersion:      service.APIVersion,
-							ResourceVersion: service.ResourceVersion,
-						},
-					})
-				}
-				if ingress.IP != "" {
-					serviceEndpoints = append(serviceEndpoints, ServiceEndpoint{
-						Endpoint: Endpoint{
-							Protocol: port.Protocol,
-							Host:     ingress.IP,
-							Port:     port.Port,
-						},
-						Ref: corev1.ObjectReference{
-							Kind:            service.Kind,
-							Namespace:       service.ObjectMeta.Namespace,
-							Name:            service.ObjectMeta.Name,
-							UID:             service.UID,
-							APIVersion:      service.APIVersion,
-							ResourceVersion: service.ResourceVersion,
-						},
-					})
-				}
-			}
-		}
-	case corev1.ServiceTypeNodePort:
-		for _, port := range service.Spec.Ports {
-			serviceEndpoints = append(serviceEndpoints, ServiceEndpoint{
-				Endpoint: Endpoint{
-					Protocol: port.Protocol,
-					Port:     port.NodePort,
-				},
-				Ref: corev1.ObjectReference{
-					Kind:            service.Kind,
-					Namespace:       service.ObjectMeta.Namespace,
-					Name:            service.ObjectMeta.Name,
-					UID:             service.UID,
-					APIVersion:      service.APIVersion,
-					ResourceVersion: service.ResourceVersion,
-				},
-			})
+// streamLogs reads the logs of a container, readErr is set if the logs of the terminated container are not found
+// or the stream is broken, they are returned along with the logs read
+func streamLogs(ctx stdctx.Context, clientSet kubernetes.Interface, namespace, pod string, opts *corev1.PodLogOptions) (logs string, truncated bool, readErr error, err error) {
+	readCloser, err := clientSet.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+	if err != nil {
+		if isTerminatedContainerNotFound(err) {
+			return "", false, err, nil
 		}
-	case corev1.ServiceTypeClusterIP, corev1.ServiceTypeExternalName:
+		return "", false, nil, err
 	}
-	return serviceEndpoints
+	defer func() {
+		_ = readCloser.Close()
+	}()
+	logs, truncated, readErr = readLogs(readCloser, opts.LimitBytes)
+	return logs, truncated, readErr, nil
 }
 
-func generatorFromIngress(ingress networkv1beta1.Ingress) (serviceEndpoints []ServiceEndpoint) {
-	getAppProtocol := func(host string) string {
-		if len(ingress.Spec.TLS) > 0 {
-			for _, tls := range ingress.Spec.TLS {
-				if len(tls.Hosts) > 0 && utils.StringsContain(tls.Hosts, host) {
-					return "https"
-				}
-				if len(tls.Hosts) == 0 {
-					return "https"
-				}
-			}
+// getPodContainerNames returns the names of the init containers and containers of the pod in order
+func getPodContainerNames(pod *corev1.Pod) []string {
+	var names []string
+	for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// getInitContainerNames returns the names of the init containers of the pod in order, and those which never ran
+func getInitContainerNames(pod *corev1.Pod) ([]string, map[string]bool) {
+	statuses := map[string]corev1.ContainerStatus{}
+	for _, cs := range pod.Status.InitContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+	var names []string
+	notStarted := map[string]bool{}
+	for _, c := range pod.Spec.InitContainers {
+		names = append(names, c.Name)
+		cs, ok := statuses[c.Name]
+		if !ok || (cs.State.Running == nil && cs.State.Terminated == nil && cs.LastTerminationState.Terminated == nil) {
+			notStarted[c.Name] = true
 		}
-		return "http"
 	}
-	// It depends on the Ingress Controller
+	return names, notStarted
+}
+
+// initContainerSeparator is the line written before the logs of each init container
+func initContainerSeparator(container string, notStarted bool) string {
+	if notStarted {
+		return fmt.Sprintf("==> init container %s (not started) <==\n", container)
+	}
+	return fmt.Sprintf("==> init container %s <==\n", container)
+}
+
+// prefixLines adds the prefix to every line of the logs
+func prefixLines(logs, prefix string) string {
+	if logs == "" {
+		return ""
+	}
+	lines := strings.SplitAfter(logs, "\n")
+	var b strings.Builder
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		b.WriteString(prefix)
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// compressLogs gzips the logs and encodes them in base64
+func compressLogs(logs string) (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(logs)); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// readLogs reads the logs line by line and never returns more than limitBytes bytes if it is set
+func readLogs(reader io.Reader, limitBytes *int64) (logs string, truncated bool, err error) {
+	if limitBytes != nil {
+		// read one more byte to know whether the logs exceed the limit
+		reader = io.LimitReader(reader, *limitBytes+1)
+	}
+	r := bufio.NewReader(reader)
+	var b strings.Builder
+	for {
+		s, readErr := r.ReadString('\n')
+		b.WriteString(s)
+		if readErr != nil {
+			if !errors.Is(readErr, io.EOF) {
+				err = readErr
+			}
+			break
+		}
+	}
+	logs = b.String()
+	if limitBytes != nil && int64(len(logs)) > *limitBytes {
+		logs, truncated = logs[:*limitBytes], true
+	}
+	return logs, truncated, err
+}
+
+// CollectResourceDrift compares the live object of an applied resource with the configuration last applied by KubeVela
+func (h *provider) CollectResourceDrift(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	readCtx := multicluster.ContextWithClusterName(stdctx.Background(), cluster)
+	if err = h.cli.Get(readCtx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return fillQueryError(v, err)
+	}
+	drift, err := computeResourceDrift(obj)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(drift, "drift")
+}
+
+// GetResourceYAML gets the manifest of the live object in yaml and json without the noisy metadata
+func (h *provider) GetResourceYAML(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	readCtx := multicluster.ContextWithClusterName(stdctx.Background(), cluster)
+	if err = h.cli.Get(readCtx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return fillQueryError(v, err)
+	}
+	manifest, err := getResourceManifest(obj)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(manifest, "manifest")
+}
+
+// GetLastApplied gets the configuration last applied to the live object, found is false if it's not recorded
+func (h *provider) GetLastApplied(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	readCtx := multicluster.ContextWithClusterName(stdctx.Background(), cluster)
+	if err = h.cli.Get(readCtx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return fillQueryError(v, err)
+	}
+	lastApplied, err := getLastAppliedConfig(obj)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(lastApplied, "lastApplied")
+}
+
+// AppCluster is a cluster the application is deployed to
+type AppCluster struct {
+	Cluster string `json:"cluster"`
+	// Resources is the number of the resources applied to the cluster
+	Resources int `json:"resources"`
+}
+
+// ListAppClusters lists the clusters the application is deployed to, sorted by the cluster name
+func (h *provider) ListAppClusters(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	app := new(v1beta1.Application)
+	if err = h.cli.Get(stdctx.Background(), client.ObjectKey{Name: opt.Name, Namespace: opt.Namespace}, app); err != nil {
+		return fillQueryError(v, err)
+	}
+	counts := map[string]int{}
+	for _, res := range app.Status.AppliedResources {
+		counts[normalizeClusterName(res.Cluster)]++
+	}
+	clusters := make([]AppCluster, 0, len(counts))
+	for cluster, count := range counts {
+		clusters = append(clusters, AppCluster{Cluster: cluster, Resources: count})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Cluster < clusters[j].Cluster
+	})
+	return v.FillObject(clusters, "list")
+}
+
+// CollectImages lists the container images used by the workloads of the application
+func (h *provider) CollectImages(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	images, err := collectImages(h.cli, resources)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(images, "list")
+}
+
+// CollectProbes lists the liveness, readiness and startup probes of the containers in the workloads of the application
+func (h *provider) CollectProbes(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(collectProbes(resources), "list")
+}
+
+// CollectRoutingRules flattens the rules of the Ingresses and the HTTPRoutes of the application into the routing rules
+func (h *provider) CollectRoutingRules(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	rules, err := collectRoutingRules(resources)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(rules, "list")
+}
+
+// CollectHelmValues returns the values in effect for the HelmRelease, the values read from the Secrets are redacted
+func (h *provider) CollectHelmValues(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	if !isHelmRelease(obj.GroupVersionKind()) {
+		return errors.Errorf("%s %s is not a HelmRelease", obj.GetKind(), obj.GetName())
+	}
+	values, err := collectHelmValues(h.cli, obj, cluster)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	if err = v.FillObject(values.Sources, "sources"); err != nil {
+		return err
+	}
+	return v.FillObject(values.Values, "values")
+}
+
+// CollectJobs returns the completion status of the Jobs and the CronJobs of the application
+func (h *provider) CollectJobs(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	jobs, err := collectJobs(resources)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(jobs, "list")
+}
+
+// CollectDistribution returns the ready and total replicas of each component in each cluster
+func (h *provider) CollectDistribution(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	distribution, err := collectDistribution(h.cli, resources)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(distribution, "distribution")
+}
+
+// CollectAppGraph returns the resources of the application and their relationships as a graph
+func (h *provider) CollectAppGraph(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	app := new(v1beta1.Application)
+	if err = h.cli.Get(stdctx.Background(), client.ObjectKey{Name: opt.Name, Namespace: opt.Namespace}, app); err != nil {
+		return fillQueryError(v, err)
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(buildAppGraph(resources, app.Status), "graph")
+}
+
+// CollectResourceProvenance collects where the resource of the application comes from
+func (h *provider) CollectResourceProvenance(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	resVal, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = resVal.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	app := new(v1beta1.Application)
+	if err = h.cli.Get(stdctx.Background(), client.ObjectKey{Name: opt.Name, Namespace: opt.Namespace}, app); err != nil {
+		return fillQueryError(v, err)
+	}
+	provenance, err := collectResourceProvenance(h.cli, app, obj, cluster)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(provenance, "provenance")
+}
+
+// CollectRevisionDiff collects the difference between the latest revision of the application and the previous one
+func (h *provider) CollectRevisionDiff(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	app := new(v1beta1.Application)
+	if err = h.cli.Get(stdctx.Background(), client.ObjectKey{Name: opt.Name, Namespace: opt.Namespace}, app); err != nil {
+		return fillQueryError(v, err)
+	}
+	diff, err := collectRevisionDiff(h.cli, app)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(diff, "diff")
+}
+
+// CollectServiceBackends collects the ready backend addresses behind the service
+func (h *provider) CollectServiceBackends(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	backends, err := collectServiceBackends(h.cli, cluster, obj.GetNamespace(), obj.GetName())
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(backends, "backends")
+}
+
+// CollectPVCs collects the status of the PersistentVolumeClaims used by the workload
+func (h *provider) CollectPVCs(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	pvcs, err := collectPVCs(h.cli, obj, cluster)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(pvcs, "list")
+}
+
+// CollectWorkflowStatus collects the normalized workflow status of the application
+func (h *provider) CollectWorkflowStatus(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	app := new(v1beta1.Application)
+	if err = h.cli.Get(stdctx.Background(), client.ObjectKey{Name: opt.Name, Namespace: opt.Namespace}, app); err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(collectWorkflowStatus(app.Status), "workflow")
+}
+
+// CollectAppReadiness computes the readiness of the application from the health of its components
+func (h *provider) CollectAppReadiness(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	app := new(v1beta1.Application)
+	if err = h.cli.Get(stdctx.Background(), client.ObjectKey{Name: opt.Name, Namespace: opt.Namespace}, app); err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(collectAppReadiness(app.Status), "readiness")
+}
+
+// CollectServices collects the services created by the application with their selectors and the readiness of the endpoints
+func (h *provider) CollectServices(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	services, err := collectServices(h.cli, resources)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(services, "list")
+}
+
+// CollectAppWarnings collects the warning events across the resources of the application, the resources with the
+// most warnings come first
+func (h *provider) CollectAppWarnings(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	limit := int64(DefaultAppWarningsLimit)
+	if _, err := v.LookupValue("limit"); err == nil {
+		if limit, err = v.GetInt64("limit"); err != nil {
+			return errors.Wrapf(err, "invalid limit")
+		}
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	warnings, err := collectAppWarnings(h.cli, resources, int(limit))
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(warnings, "list")
+}
+
+// CollectConfigRefs collects the ConfigMaps and Secrets referenced by the pod template of the workload,
+// only the keys of the data are returned
+func (h *provider) CollectConfigRefs(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	refs, err := collectConfigRefs(h.cli, obj, cluster)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(refs, "list")
+}
+
+// CollectSecrets lists the Secrets applied or referenced by the application with their types and keys, the data of
+// the Secrets is never returned
+func (h *provider) CollectSecrets(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	secrets, err := collectSecrets(h.cli, resources)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(secrets, "list")
+}
+
+// CollectApplyStatus returns when each resource of the application is applied last and whether its controller
+// has observed the latest generation
+func (h *provider) CollectApplyStatus(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	resources, err := h.newAppCollector(opt).CollectResourceFromApp()
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(collectApplyStatus(resources), "list")
+}
+
+// CollectHPA collects the status of the HorizontalPodAutoscalers scaling the workload
+func (h *provider) CollectHPA(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	hpas, err := collectHPAs(h.cli, obj, cluster)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(hpas, "list")
+}
+
+// CollectServiceAccount collects the service account used by the pods of the workload and the bindings granting it
+// roles, the rules of the roles are only read if includeRules is set
+func (h *provider) CollectServiceAccount(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("value")
+	if err != nil {
+		return err
+	}
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	obj := new(unstructured.Unstructured)
+	if err = val.UnmarshalTo(obj); err != nil {
+		return err
+	}
+	includeRules, err := v.GetBool("includeRules")
+	includeRules = err == nil && includeRules
+	info, err := collectServiceAccount(h.cli, obj, cluster, includeRules)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(info, "serviceAccount")
+}
+
+// CollectNodeConditions collects the conditions and the resources of the nodes in the cluster
+func (h *provider) CollectNodeConditions(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	cluster, err := getClusterName(v)
+	if err != nil {
+		return err
+	}
+	if err = h.allowedClusters.check(cluster); err != nil {
+		return fillQueryError(v, err)
+	}
+	nodes, err := collectNodeConditions(h.cli, cluster)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(nodes, "list")
+}
+
+// CollectPolicies collects the policies of the application, the components of each environment are resolved
+// for the env-binding policies
+func (h *provider) CollectPolicies(ctx wfContext.Context, v *value.Value, act types.Action) error {
+	val, err := v.LookupValue("app")
+	if err != nil {
+		return err
+	}
+	opt := Option{}
+	if err = val.UnmarshalTo(&opt); err != nil {
+		return err
+	}
+	app := new(v1beta1.Application)
+	if err = h.cli.Get(stdctx.Background(), client.ObjectKey{Name: opt.Name, Namespace: opt.Namespace}, app); err != nil {
+		return fillQueryError(v, err)
+	}
+	policies, err := collectPolicies(app)
+	if err != nil {
+		return fillQueryError(v, err)
+	}
+	return v.FillObject(policies, "list")
+}
+
+// Install register handlers to provider discover.
+func Install(p providers.Providers, cli client.Client, cfg *rest.Config) {
+	prd := &provider{
+		cli:                         cli,
+		cfg:                         cfg,
+		allowedClusters:             newClusterAllowlist(AllowedClusters),
+		ingressClassPortAnnotations: IngressClassPortAnnotations,
+	}
+
+	p.Register(ProviderName, map[string]providers.Handler{
+		"listResourcesInApp":        prd.ListResourcesInApp,
+		"collectPods":               prd.CollectPods,
+		"searchEvents":              prd.SearchEvents,
+		"collectLogsInPod":          prd.CollectLogsInPod,
+		"collectServiceEndpoints":   prd.GeneratorServiceEndpoints,
+		"collectResourceDrift":      prd.CollectResourceDrift,
+		"collectImages":             prd.CollectImages,
+		"listAppClusters":           prd.ListAppClusters,
+		"collectAppGraph":           prd.CollectAppGraph,
+		"describeResource":          prd.DescribeResource,
+		"collectResourceProvenance": prd.CollectResourceProvenance,
+		"collectRevisionDiff":       prd.CollectRevisionDiff,
+		"collectServiceBackends":    prd.CollectServiceBackends,
+		"getResourceYAML":           prd.GetResourceYAML,
+		"collectPVCs":               prd.CollectPVCs,
+		"collectWorkflowStatus":     prd.CollectWorkflowStatus,
+		"collectServices":           prd.CollectServices,
+		"collectAppWarnings":        prd.CollectAppWarnings,
+		"getLastApplied":            prd.GetLastApplied,
+		"collectConfigRefs":         prd.CollectConfigRefs,
+		"collectHPA":                prd.CollectHPA,
+		"collectServiceAccount":     prd.CollectServiceAccount,
+		"collectPolicies":           prd.CollectPolicies,
+		"collectNodeConditions":     prd.CollectNodeConditions,
+		"collectAppReadiness":       prd.CollectAppReadiness,
+		"collectRoutingRules":       prd.CollectRoutingRules,
+		"collectDistribution":       prd.CollectDistribution,
+		"collectHelmValues":         prd.CollectHelmValues,
+		"collectJobs":               prd.CollectJobs,
+		"collectProbes":             prd.CollectProbes,
+		"collectSecrets":            prd.CollectSecrets,
+		"collectApplyStatus":        prd.CollectApplyStatus,
+	})
+}
+
+func generatorFromService(service corev1.Service) []ServiceEndpoint {
+	var serviceEndpoints []ServiceEndpoint
+	switch service.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		for _, port := range service.Spec.Ports {
+			if len(service.Status.LoadBalancer.Ingress) == 0 {
+				serviceEndpoints = append(serviceEndpoints, ServiceEndpoint{
+					Endpoint: Endpoint{
+						Protocol:    port.Protocol,
+						AppProtocol: port.AppProtocol,
+						Port:        port.Port,
+					},
+					Ref: corev1.ObjectReference{
+						Kind:            service.Kind,
+						Namespace:       service.ObjectMeta.Namespace,
+						Name:            service.ObjectMeta.Name,
+						UID:             service.UID,
+						APIVersion:      service.APIVersion,
+						ResourceVersion: service.ResourceVersion,
+					},
+					Pending: true,
+				})
+			}
+			for _, ingress := range service.Status.LoadBalancer.Ingress {
+				if ingress.Hostname != "" {
+					serviceEndpoints = append(serviceEndpoints, ServiceEndpoint{
+						Endpoint: Endpoint{
+							Protocol:    port.Protocol,
+							AppProtocol: port.AppProtocol,
+							Host:        ingress.Hostname,
+							Port:        port.Port,
+							IPFamily:    IPFamilyHostname,
+						},
+						Ref: corev1.ObjectReference{
+							Kind:            service.Kind,
+							Namespace:       service.ObjectMeta.Namespace,
+							Name:            service.ObjectMeta.Name,
+							UID:             service.UID,
+							APIVersion:      service.APIVersion,
+							ResourceVersion: service.ResourceVersion,
+						},
+					})
+				}
+				if ingress.IP != "" {
+					serviceEndpoints = append(serviceEndpoints, ServiceEndpoint{
+						Endpoint: Endpoint{
+							Protocol:    port.Protocol,
+							AppProtocol: port.AppProtocol,
+							Host:        ingress.IP,
+							Port:        port.Port,
+							IPFamily:    getHostIPFamily(ingress.IP),
+						},
+						Ref: corev1.ObjectReference{
+							Kind:            service.Kind,
+							Namespace:       service.ObjectMeta.Namespace,
+							Name:            service.ObjectMeta.Name,
+							UID:             service.UID,
+							APIVersion:      service.APIVersion,
+							ResourceVersion: service.ResourceVersion,
+						},
+					})
+				}
+			}
+		}
+	case corev1.ServiceTypeNodePort:
+		var ipFamily string
+		if len(service.Spec.IPFamilies) > 0 {
+			ipFamily = string(service.Spec.IPFamilies[0])
+		}
+		for _, port := range service.Spec.Ports {
+			serviceEndpoints = append(serviceEndpoints, ServiceEndpoint{
+				Endpoint: Endpoint{
+					Protocol:    port.Protocol,
+					AppProtocol: port.AppProtocol,
+					Port:        port.NodePort,
+					IPFamily:    ipFamily,
+				},
+				Ref: corev1.ObjectReference{
+					Kind:            service.Kind,
+					Namespace:       service.ObjectMeta.Namespace,
+					Name:            service.ObjectMeta.Name,
+					UID:             service.UID,
+					APIVersion:      service.APIVersion,
+					ResourceVersion: service.ResourceVersion,
+				},
+			})
+		}
+	case corev1.ServiceTypeClusterIP, corev1.ServiceTypeExternalName:
+	}
+	return serviceEndpoints
+}
+
+// IngressPortAnnotations are the keys of the annotations recording the ports listened by the ingress controller
+type IngressPortAnnotations struct {
+	HTTP  string
+	HTTPS string
+}
+
+// DefaultIngressPortAnnotations are the annotation keys used for all the ingresses
+var DefaultIngressPortAnnotations = IngressPortAnnotations{
+	HTTP:  apis.AnnoIngressControllerHTTPPort,
+	HTTPS: apis.AnnoIngressControllerHTTPSPort,
+}
+
+// IngressClassPortAnnotations configures the annotation keys for the ingresses of an ingress class, they take
+// precedence over DefaultIngressPortAnnotations. It is read by Install, and can be parsed from the flag value by
+// ParseIngressClassPortAnnotations.
+var IngressClassPortAnnotations map[string]IngressPortAnnotations
+
+// ParseIngressClassPortAnnotations parses the comma separated <class>=<http annotation>:<https annotation> entries,
+// such as traefik=traefik.example.io/http-port:traefik.example.io/https-port
+func ParseIngressClassPortAnnotations(value string) (map[string]IngressPortAnnotations, error) {
+	annotations := map[string]IngressPortAnnotations{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		classKeys := strings.SplitN(entry, "=", 2)
+		if len(classKeys) != 2 || classKeys[0] == "" {
+			return nil, fmt.Errorf("invalid ingress class port annotations %q, the format is <class>=<http annotation>:<https annotation>", entry)
+		}
+		keys := strings.Split(classKeys[1], ":")
+		if len(keys) != 2 || keys[0] == "" || keys[1] == "" {
+			return nil, fmt.Errorf("invalid ingress class port annotations %q, the format is <class>=<http annotation>:<https annotation>", entry)
+		}
+		annotations[classKeys[0]] = IngressPortAnnotations{HTTP: keys[0], HTTPS: keys[1]}
+	}
+	return annotations, nil
+}
+
+// getIngressClass returns the class of the ingress from the spec or the legacy annotation
+func getIngressClass(ingress networkv1beta1.Ingress) string {
+	if ingress.Spec.IngressClassName != nil {
+		return *ingress.Spec.IngressClassName
+	}
+	return ingress.Annotations[ingressClassAnnotation]
+}
+
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+func generatorFromIngress(ingress networkv1beta1.Ingress, classPortAnnotations map[string]IngressPortAnnotations) (serviceEndpoints []ServiceEndpoint) {
+	getTLS := func(host string) *networkv1beta1.IngressTLS {
+		for i, tls := range ingress.Spec.TLS {
+			if len(tls.Hosts) == 0 || utils.StringsContain(tls.Hosts, host) {
+				return &ingress.Spec.TLS[i]
+			}
+		}
+		return nil
+	}
+	// It depends on the Ingress Controller
 	getEndpointPort := func(appProtocol string) int {
-		if appProtocol == "https" {
-			if port, err := strconv.Atoi(ingress.Annotations[apis.AnnoIngressControllerHTTPSPort]); port > 0 && err == nil {
+		keys := []IngressPortAnnotations{DefaultIngressPortAnnotations}
+		if classKeys, ok := classPortAnnotations[getIngressClass(ingress)]; ok {
+			keys = append([]IngressPortAnnotations{classKeys}, keys...)
+		}
+		for _, key := range keys {
+			annotation := key.HTTP
+			if appProtocol == "https" {
+				annotation = key.HTTPS
+			}
+			if port, err := strconv.Atoi(ingress.Annotations[annotation]); port > 0 && err == nil {
 				return port
 			}
-			return 443
 		}
-		if port, err := strconv.Atoi(ingress.Annotations[apis.AnnoIngressControllerHTTPPort]); port > 0 && err == nil {
-			return port
+		if appProtocol == "https" {
+			return 443
 		}
 		return 80
 	}
 	for _, rule := range ingress.Spec.Rules {
-		var appProtocol = getAppProtocol(rule.Host)
+		var appProtocol = "http"
+		var secretName string
+		if tls := getTLS(rule.Host); tls != nil {
+			appProtocol = "https"
+			secretName = tls.SecretName
+		}
 		var appPort = getEndpointPort(appProtocol)
 		if rule.HTTP != nil {
 			for _, path := range rule.HTTP.Paths {
+				var pathTLS *EndpointTLS
+				if secretName != "" {
+					pathTLS = &EndpointTLS{SecretName: secretName}
+				}
 				serviceEndpoints = append(serviceEndpoints, ServiceEndpoint{
 					Endpoint: Endpoint{
 						Protocol:    corev1.ProtocolTCP,
@@ -499,6 +1892,7 @@ func generatorFromIngress(ingress networkv1beta1.Ingress) (serviceEndpoints []Se
 						Host:        rule.Host,
 						Path:        path.Path,
 						Port:        int32(appPort),
+						TLS:         pathTLS,
 					},
 					Ref: corev1.ObjectReference{
 						Kind:            ingress.Kind,
@@ -508,9 +1902,77 @@ func generatorFromIngress(ingress networkv1beta1.Ingress) (serviceEndpoints []Se
 						APIVersion:      ingress.APIVersion,
 						ResourceVersion: ingress.ResourceVersion,
 					},
+					backendService: path.Backend.ServiceName,
 				})
 			}
 		}
 	}
 	return serviceEndpoints
 }
+
+// resolveEndpointCertificates reads the certificates of the https endpoints from their TLS secrets.
+// Each secret is got once, and the endpoint keeps only the secret name if the secret can't be read.
+func resolveEndpointCertificates(ctx stdctx.Context, cli client.Client, endpoints []ServiceEndpoint, cluster string) {
+	certs := map[string]*x509.Certificate{}
+	for i := range endpoints {
+		tls := endpoints[i].Endpoint.TLS
+		if tls == nil {
+			continue
+		}
+		key := endpoints[i].Ref.Namespace + "/" + tls.SecretName
+		cert, ok := certs[key]
+		if !ok {
+			cert = getCertificateFromSecret(ctx, cli, endpoints[i].Ref.Namespace, tls.SecretName, cluster)
+			certs[key] = cert
+		}
+		if cert != nil {
+			notAfter := v1.NewTime(cert.NotAfter)
+			tls.CommonName = cert.Subject.CommonName
+			tls.NotAfter = &notAfter
+		}
+	}
+}
+
+// resolveIngressBackends sets whether the backend service of each ingress endpoint has any ready endpoint.
+// Each service is checked once, and the readiness is left unset if the backends of the service can't be read.
+func resolveIngressBackends(cli client.Client, endpoints []ServiceEndpoint, cluster string) {
+	ready := map[string]*bool{}
+	for i := range endpoints {
+		service := endpoints[i].backendService
+		if service == "" {
+			continue
+		}
+		key := endpoints[i].Ref.Namespace + "/" + service
+		hasReady, ok := ready[key]
+		if !ok {
+			backends, err := collectServiceBackends(cli, cluster, endpoints[i].Ref.Namespace, service)
+			if err != nil {
+				klog.Warningf("fail to get the backends of the service %s from cluster %s: %v", key, cluster, err)
+			} else {
+				hasReady = pointer.Bool(len(backends) > 0)
+			}
+			ready[key] = hasReady
+		}
+		if hasReady != nil {
+			endpoints[i].BackendReady = pointer.Bool(*hasReady)
+		}
+	}
+}
+
+func getCertificateFromSecret(ctx stdctx.Context, cli client.Client, namespace, name, cluster string) *x509.Certificate {
+	secret := corev1.Secret{}
+	if err := cli.Get(multicluster.ContextWithClusterName(ctx, cluster), client.ObjectKey{Namespace: namespace, Name: name}, &secret); err != nil {
+		klog.Warningf("fail to get the TLS secret %s/%s from cluster %s: %v", namespace, name, cluster, err)
+		return nil
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		klog.Warningf("fail to parse the certificate in the TLS secret %s/%s: %v", namespace, name, err)
+		return nil
+	}
+	return cert
+}
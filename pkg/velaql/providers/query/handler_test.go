@@ -17,28 +17,60 @@
 package query
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	v1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkv1 "k8s.io/api/networking/v1"
 	networkv1beta1 "k8s.io/api/networking/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1alpha1"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	helmapi "github.com/oam-dev/kubevela/pkg/appfile/helm/flux2apis"
 	"github.com/oam-dev/kubevela/pkg/cue/model/value"
+	"github.com/oam-dev/kubevela/pkg/multicluster"
 	"github.com/oam-dev/kubevela/pkg/oam"
 	"github.com/oam-dev/kubevela/pkg/oam/util"
+	wfContext "github.com/oam-dev/kubevela/pkg/workflow/context"
 	"github.com/oam-dev/kubevela/pkg/workflow/providers"
 )
 
@@ -50,6 +82,7 @@ type AppResourcesList struct {
 
 type PodList struct {
 	List    []*unstructured.Unstructured `json:"list"`
+	Summary PodsSummary                  `json:"summary"`
 	Value   interface{}                  `json:"value"`
 	Cluster string                       `json:"cluster"`
 }
@@ -191,6 +224,185 @@ var _ = Describe("Test Query Provider", func() {
 			Expect(newAppResList.List[1].Object.GroupVersionKind()).Should(Equal(updateApp.Status.AppliedResources[1].GroupVersionKind()))
 		})
 
+		It("Test filter resources by annotations", func() {
+			obj := &unstructured.Unstructured{}
+			obj.SetAnnotations(map[string]string{"team": "payments", "tier": "backend"})
+			Expect(isResourceMatchAnnotations(FilterOption{}, obj)).Should(BeTrue())
+			Expect(isResourceMatchAnnotations(FilterOption{Annotations: map[string]string{"team": "payments"}}, obj)).Should(BeTrue())
+			Expect(isResourceMatchAnnotations(FilterOption{Annotations: map[string]string{"team": "payments", "tier": "frontend"}}, obj)).Should(BeFalse())
+			Expect(isResourceMatchAnnotations(FilterOption{Annotations: map[string]string{"owner": "ops"}}, obj)).Should(BeFalse())
+		})
+
+		It("Test filter resources by age", func() {
+			now := time.Now()
+			obj := &unstructured.Unstructured{}
+			obj.SetCreationTimestamp(metav1.NewTime(now.Add(-2 * time.Hour)))
+			Expect(isResourceInAgeRange(FilterOption{}, obj, now)).Should(BeTrue())
+			Expect(isResourceInAgeRange(FilterOption{OlderThan: "1h"}, obj, now)).Should(BeTrue())
+			Expect(isResourceInAgeRange(FilterOption{OlderThan: "3h"}, obj, now)).Should(BeFalse())
+			Expect(isResourceInAgeRange(FilterOption{NewerThan: "3h"}, obj, now)).Should(BeTrue())
+			Expect(isResourceInAgeRange(FilterOption{NewerThan: "1h"}, obj, now)).Should(BeFalse())
+			Expect(isResourceInAgeRange(FilterOption{OlderThan: "1h", NewerThan: "3h"}, obj, now)).Should(BeTrue())
+			Expect(validateAgeFilter(FilterOption{OlderThan: "1h"})).Should(BeNil())
+			Expect(validateAgeFilter(FilterOption{NewerThan: "3 days"})).ShouldNot(BeNil())
+		})
+
+		It("Test filter resources by the trait or the workload creating them", func() {
+			workload, trait, auxiliary, legacyTrait := &unstructured.Unstructured{}, &unstructured.Unstructured{}, &unstructured.Unstructured{}, &unstructured.Unstructured{}
+			workload.SetLabels(map[string]string{oam.LabelOAMResourceType: oam.ResourceTypeWorkload})
+			trait.SetLabels(map[string]string{oam.LabelOAMResourceType: oam.ResourceTypeTrait, oam.TraitTypeLabel: "gateway"})
+			auxiliary.SetLabels(map[string]string{oam.TraitTypeLabel: "AuxiliaryWorkload"})
+			legacyTrait.SetLabels(map[string]string{oam.LabelOAMResourceType: oam.ResourceTypeTrait})
+			Expect(getResourceTrait(workload)).Should(Equal(""))
+			Expect(getResourceTrait(trait)).Should(Equal("gateway"))
+			Expect(getResourceTrait(auxiliary)).Should(Equal(""))
+			Expect(getResourceTrait(legacyTrait)).Should(Equal(oam.ResourceTypeTrait))
+			Expect(isResourceCreatedBy(FilterOption{}, trait)).Should(BeTrue())
+			Expect(isResourceCreatedBy(FilterOption{CreatedBy: ResourceCreatedByTrait}, trait)).Should(BeTrue())
+			Expect(isResourceCreatedBy(FilterOption{CreatedBy: ResourceCreatedByTrait}, auxiliary)).Should(BeFalse())
+			Expect(isResourceCreatedBy(FilterOption{CreatedBy: ResourceCreatedByWorkload}, workload)).Should(BeTrue())
+			Expect(isResourceCreatedBy(FilterOption{CreatedBy: ResourceCreatedByWorkload}, trait)).Should(BeFalse())
+			Expect(validateCreatedByFilter(FilterOption{CreatedBy: ResourceCreatedByWorkload})).Should(BeNil())
+			Expect(validateCreatedByFilter(FilterOption{CreatedBy: "policy"})).ShouldNot(BeNil())
+		})
+
+		It("Test read the inventory of kustomization", func() {
+			kustomization := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "kustomize.toolkit.fluxcd.io/v1beta2",
+				"kind":       "Kustomization",
+				"metadata":   map[string]interface{}{"name": "podinfo", "namespace": "flux-system"},
+				"status": map[string]interface{}{
+					"inventory": map[string]interface{}{
+						"entries": []interface{}{
+							map[string]interface{}{"id": "default_podinfo_apps_Deployment", "v": "v1"},
+							map[string]interface{}{"id": "default_podinfo__Service", "v": "v1"},
+							map[string]interface{}{"id": "_podinfo-ns__Namespace", "v": "v1"},
+							map[string]interface{}{"id": "invalid", "v": "v1"},
+						},
+					},
+				},
+			}}
+			Expect(isKustomization(kustomization.GroupVersionKind())).Should(BeTrue())
+			Expect(getKustomizationInventory(kustomization)).Should(Equal([]corev1.ObjectReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "podinfo"},
+				{APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "podinfo"},
+				{APIVersion: "v1", Kind: "Namespace", Name: "podinfo-ns"},
+			}))
+		})
+
+		It("Test limit resources per kind", func() {
+			var resources []Resource
+			for _, kind := range []string{"ConfigMap", "ConfigMap", "Deployment", "ConfigMap"} {
+				obj := &unstructured.Unstructured{}
+				obj.SetKind(kind)
+				resources = append(resources, Resource{Object: obj})
+			}
+			limited, counts := limitResourcesPerKind(resources, 2)
+			Expect(limited).Should(Equal(resources[:3]))
+			Expect(counts).Should(Equal(map[string]int{"ConfigMap": 3, "Deployment": 1}))
+		})
+
+		It("Test collect cluster coverage", func() {
+			app := &v1beta1.Application{}
+			Expect(collectClusterCoverage(app, []Resource{{Cluster: ""}})).Should(Equal(&ClusterCoverage{
+				Targeted: []string{"local"}, Actual: []string{"local"},
+			}))
+
+			app.Spec.Policies = []v1beta1.AppPolicy{{
+				Name: "example-multi-env-policy",
+				Type: v1alpha1.EnvBindingPolicyType,
+				Properties: util.Object2RawExtension(v1alpha1.EnvBindingSpec{Envs: []v1alpha1.EnvConfig{
+					{Name: "staging", Placement: v1alpha1.EnvPlacement{ClusterSelector: &common.ClusterSelector{Name: "cluster-staging"}}},
+					{Name: "prod", Placement: v1alpha1.EnvPlacement{ClusterSelector: &common.ClusterSelector{Labels: map[string]string{"env": "prod"}}}},
+					{Name: "canary", Placement: v1alpha1.EnvPlacement{ClusterSelector: &common.ClusterSelector{Labels: map[string]string{"env": "canary"}}}},
+				}}),
+			}, {
+				Name:       "topology-policy",
+				Type:       topologyPolicyType,
+				Properties: util.Object2RawExtension(map[string]interface{}{"clusters": []string{"cluster-edge"}}),
+			}}
+			app.Status.PolicyStatus = []common.PolicyStatus{{
+				Name: "example-multi-env-policy",
+				Type: v1alpha1.EnvBindingPolicyType,
+				Status: util.Object2RawExtension(v1alpha1.EnvBindingStatus{Envs: []v1alpha1.EnvStatus{
+					{Env: "prod", Placements: []v1alpha1.PlacementDecision{{Cluster: "cluster-prod-1"}, {Cluster: "cluster-prod-2"}}},
+				}}),
+			}}
+			resources := []Resource{{Cluster: "cluster-staging"}, {Cluster: "cluster-prod-1"}, {Cluster: "cluster-unknown"}}
+			Expect(collectClusterCoverage(app, resources)).Should(Equal(&ClusterCoverage{
+				Targeted:       []string{"cluster-edge", "cluster-prod-1", "cluster-prod-2", "cluster-staging"},
+				Actual:         []string{"cluster-prod-1", "cluster-staging", "cluster-unknown"},
+				Missing:        []string{"cluster-edge", "cluster-prod-2"},
+				Unexpected:     []string{"cluster-unknown"},
+				UnresolvedEnvs: []string{"example-multi-env-policy/canary"},
+			}))
+		})
+
+		It("Test get objects in clusters keeps the order of refs", func() {
+			namespace := "test-collect-order"
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			Expect(k8sClient.Create(ctx, &ns)).Should(BeNil())
+			deploy := baseDeploy.DeepCopy()
+			deploy.SetName("web")
+			deploy.SetNamespace(namespace)
+			Expect(k8sClient.Create(ctx, deploy)).Should(BeNil())
+			svc := baseService.DeepCopy()
+			svc.SetName("web")
+			svc.SetNamespace(namespace)
+			Expect(k8sClient.Create(ctx, svc)).Should(BeNil())
+
+			refs := []common.ClusterObjectReference{
+				{ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "Service", Namespace: namespace, Name: "web"}},
+				{ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "Service", Namespace: namespace, Name: "not-exist"}},
+				{ObjectReference: corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: namespace, Name: "web"}},
+			}
+			for _, concurrency := range []int{0, 1} {
+				collector := NewAppCollector(k8sClient, Option{Concurrency: concurrency})
+				objs, err := collector.getObjectsInClusters(refs)
+				Expect(err).Should(BeNil())
+				Expect(len(objs)).Should(Equal(3))
+				Expect(objs[0].GetKind()).Should(Equal("Service"))
+				Expect(objs[1]).Should(BeNil())
+				Expect(objs[2].GetKind()).Should(Equal("Deployment"))
+			}
+
+			sortClusterObjectReferences(refs)
+			Expect(refs[0].Kind).Should(Equal("Deployment"))
+			Expect(refs[1].Name).Should(Equal("not-exist"))
+			Expect(refs[2].Name).Should(Equal("web"))
+		})
+
+		It("Test collect resources in the namespaces other than the application", func() {
+			namespace := "test-cross-ns-trait"
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			Expect(k8sClient.Create(ctx, &ns)).Should(BeNil())
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web-route", Namespace: namespace}}
+			Expect(k8sClient.Create(ctx, cm)).Should(BeNil())
+			collector := NewAppCollector(k8sClient, Option{Name: "web", Namespace: "default"})
+			objs, err := collector.getObjectsInClusters([]common.ClusterObjectReference{
+				{ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: namespace, Name: "web-route"}},
+			})
+			Expect(err).Should(BeNil())
+			Expect(objs).Should(HaveLen(1))
+			Expect(objs[0]).ShouldNot(BeNil())
+			Expect(objs[0].GetNamespace()).Should(Equal(namespace))
+
+			newObj := func(namespace string) *unstructured.Unstructured {
+				obj := &unstructured.Unstructured{}
+				obj.SetNamespace(namespace)
+				return obj
+			}
+			resources := []Resource{
+				{Object: newObj("default")}, {Object: newObj("default")}, {Object: objs[0]},
+				{Cluster: "worker", Object: newObj("default")}, {Object: newObj("")},
+			}
+			Expect(collectResourceNamespaces(resources, "default")).Should(Equal([]ResourceNamespace{
+				{Cluster: "local", Namespace: "default", Resources: 2},
+				{Cluster: "local", Namespace: namespace, Resources: 1, External: true},
+				{Cluster: "worker", Namespace: "default", Resources: 1},
+			}))
+		})
+
 		It("Test list resource with incomplete parameter", func() {
 			optWithoutApp := ""
 			prd := provider{cli: k8sClient}
@@ -202,188 +414,2608 @@ var _ = Describe("Test Query Provider", func() {
 		})
 	})
 
-	Context("Test CollectPods", func() {
-		It("Test collect pod from workload deployment", func() {
-			deploy := baseDeploy.DeepCopy()
-			deploy.SetName("test-collect-pod")
-			deploy.Spec.Selector = &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					oam.LabelAppComponent: "test",
-				},
+	Context("Test CollectPods", func() {
+		It("Test collect pod from workload deployment", func() {
+			deploy := baseDeploy.DeepCopy()
+			deploy.SetName("test-collect-pod")
+			deploy.Spec.Selector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					oam.LabelAppComponent: "test",
+				},
+			}
+			deploy.Spec.Template.ObjectMeta.SetLabels(map[string]string{
+				oam.LabelAppComponent: "test",
+			})
+			Expect(k8sClient.Create(ctx, deploy)).Should(BeNil())
+			for i := 1; i <= 5; i++ {
+				pod := basePod.DeepCopy()
+				pod.SetName(fmt.Sprintf("test-collect-pod-%d", i))
+				pod.SetLabels(map[string]string{
+					oam.LabelAppComponent: "test",
+				})
+				Expect(k8sClient.Create(ctx, pod)).Should(BeNil())
+			}
+
+			prd := provider{cli: k8sClient}
+			unstructuredDeploy, err := util.Object2Unstructured(deploy)
+			Expect(err).Should(BeNil())
+			unstructuredDeploy.SetGroupVersionKind((&corev1.ObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+			}).GroupVersionKind())
+
+			deployJson, err := json.Marshal(unstructuredDeploy)
+			Expect(err).Should(BeNil())
+			opt := fmt.Sprintf(`value: %s
+cluster: ""`, deployJson)
+			v, err := value.NewValue(opt, nil, "")
+			Expect(err).Should(BeNil())
+			Expect(prd.CollectPods(nil, v, nil)).Should(BeNil())
+
+			podList := new(PodList)
+			Expect(v.UnmarshalTo(podList)).Should(BeNil())
+			Expect(len(podList.List)).Should(Equal(5))
+			Expect(len(podList.Summary.Pods)).Should(Equal(5))
+			for _, pod := range podList.List {
+				Expect(pod.GroupVersionKind()).Should(Equal((&corev1.ObjectReference{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				}).GroupVersionKind()))
+			}
+		})
+
+		It("Test collect the rollout of the workload", func() {
+			deploy := baseDeploy.DeepCopy()
+			deploy.SetName("test-workload-rollout")
+			deploy.SetAnnotations(map[string]string{"deployment.kubernetes.io/revision": "2"})
+			deploy.Spec.Replicas = pointer.Int32(5)
+			deploy.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test-workload-rollout"}}
+			deploy.Spec.Template.ObjectMeta.SetLabels(map[string]string{"app": "test-workload-rollout"})
+			Expect(k8sClient.Create(ctx, deploy)).Should(BeNil())
+			deploy.Status = v1.DeploymentStatus{
+				ObservedGeneration: deploy.Generation,
+				Replicas:           5, UpdatedReplicas: 3, ReadyReplicas: 4, AvailableReplicas: 4, UnavailableReplicas: 1,
+			}
+			Expect(k8sClient.Status().Update(ctx, deploy)).Should(BeNil())
+
+			unstructuredDeploy, err := util.Object2Unstructured(deploy)
+			Expect(err).Should(BeNil())
+			unstructuredDeploy.SetGroupVersionKind(v1.SchemeGroupVersion.WithKind("Deployment"))
+			deployJson, err := json.Marshal(unstructuredDeploy)
+			Expect(err).Should(BeNil())
+			v, err := value.NewValue(fmt.Sprintf(`value: %s
+cluster: ""
+includeWorkload: true`, deployJson), nil, "")
+			Expect(err).Should(BeNil())
+			prd := provider{cli: k8sClient}
+			Expect(prd.CollectPods(nil, v, nil)).Should(BeNil())
+			workload, err := v.LookupValue("workload")
+			Expect(err).Should(BeNil())
+			rollout := WorkloadRollout{}
+			Expect(workload.UnmarshalTo(&rollout)).Should(BeNil())
+			Expect(rollout).Should(Equal(WorkloadRollout{
+				Kind: "Deployment", Name: "test-workload-rollout",
+				Desired: 5, Updated: 3, Ready: 4, Available: 4, Unavailable: 1, UpdateRevision: "2",
+			}))
+
+			service, err := util.Object2Unstructured(&corev1.Service{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test-workload-rollout", Namespace: "default"},
+			})
+			Expect(err).Should(BeNil())
+			noRollout, err := getWorkloadRollout(k8sClient, service, "")
+			Expect(err).Should(BeNil())
+			Expect(noRollout).Should(BeNil())
+		})
+
+		It("Test summarize pods with node placement", func() {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name: "node-summary",
+				Labels: map[string]string{
+					corev1.LabelTopologyZone:   "zone-a",
+					corev1.LabelTopologyRegion: "region-1",
+				},
+			}}
+			Expect(k8sClient.Create(ctx, node)).Should(BeNil())
+			running := basePod.DeepCopy()
+			running.SetName("pod-on-node")
+			running.Spec.NodeName = "node-summary"
+			running.Status.Phase = corev1.PodRunning
+			pending := basePod.DeepCopy()
+			pending.SetName("pod-unschedulable")
+			pending.Status.Phase = corev1.PodPending
+			pending.Status.Conditions = []corev1.PodCondition{{
+				Type:   corev1.PodScheduled,
+				Status: corev1.ConditionFalse,
+				Reason: corev1.PodReasonUnschedulable,
+			}}
+			var objs []*unstructured.Unstructured
+			for _, pod := range []*corev1.Pod{running, pending} {
+				obj, err := util.Object2Unstructured(pod)
+				Expect(err).Should(BeNil())
+				objs = append(objs, obj)
+			}
+
+			summary, err := summarizePods(k8sClient, objs, "")
+			Expect(err).Should(BeNil())
+			Expect(summary.Pods).Should(Equal([]PodSummary{{
+				Name:       "pod-on-node",
+				Namespace:  "default",
+				Phase:      corev1.PodRunning,
+				Component:  "express-server",
+				Status:     "Running",
+				NodeName:   "node-summary",
+				Zone:       "zone-a",
+				Region:     "region-1",
+				Containers: []ContainerResources{{Name: "express-server-1"}},
+			}, {
+				Name:              "pod-unschedulable",
+				Namespace:         "default",
+				Phase:             corev1.PodPending,
+				Component:         "express-server",
+				Status:            "Pending",
+				SchedulingPending: true,
+				Containers:        []ContainerResources{{Name: "express-server-1"}},
+				UnmetConditions:   []PodConditionSummary{{Type: corev1.PodScheduled, Reason: corev1.PodReasonUnschedulable}},
+			}}))
+			Expect(summary.Total).Should(Equal(2))
+		})
+
+		It("Test get the requests and limits of pod", func() {
+			pod := corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{
+					Name:      "init",
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}},
+				}},
+				Containers: []corev1.Container{{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:              resource.MustParse("1"),
+							corev1.ResourceMemory:           resource.MustParse("512Mi"),
+							corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+						},
+					},
+				}, {
+					Name:      "sidecar",
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}},
+				}, {
+					Name: "unlimited",
+				}},
+			}}
+			containers, requests, limits := getPodResources(pod)
+			Expect(containers).Should(HaveLen(3))
+			Expect(containers[0].Limits).Should(HaveLen(2))
+			Expect(containers[2].Requests).Should(BeNil())
+			cpu, memory := requests[corev1.ResourceCPU], requests[corev1.ResourceMemory]
+			Expect(cpu.String()).Should(Equal("600m"))
+			Expect(memory.String()).Should(Equal("256Mi"))
+			cpu = limits[corev1.ResourceCPU]
+			Expect(cpu.String()).Should(Equal("1"))
+			Expect(limits).Should(HaveLen(2))
+		})
+
+		It("Test get the unmet conditions and terminations of pod", func() {
+			pod := corev1.Pod{Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+					{Type: corev1.ContainersReady, Status: corev1.ConditionFalse, Reason: "ContainersNotReady", Message: "containers with unready status: [main]"},
+				},
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "init",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}},
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:                 "main",
+					RestartCount:         3,
+					State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+				}, {
+					Name:  "sidecar",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			}}
+			Expect(getPodUnmetConditions(pod)).Should(Equal([]PodConditionSummary{
+				{Type: corev1.ContainersReady, Reason: "ContainersNotReady", Message: "containers with unready status: [main]"},
+			}))
+			Expect(getContainerTerminations(pod)).Should(Equal([]ContainerTermination{
+				{Name: "init", Reason: "Completed", Current: true},
+				{Name: "main", Reason: "OOMKilled", ExitCode: 137, RestartCount: 3},
+			}))
+		})
+
+		It("Test set the component and revision of pods", func() {
+			labeled := basePod.DeepCopy()
+			labeled.SetName("pod-labeled")
+			labeled.SetLabels(map[string]string{oam.LabelAppComponent: "frontend", oam.LabelAppRevision: "app-v2"})
+			unlabeled := basePod.DeepCopy()
+			unlabeled.SetName("pod-unlabeled")
+			unlabeled.SetLabels(nil)
+			var objs []*unstructured.Unstructured
+			for _, pod := range []*corev1.Pod{labeled, unlabeled} {
+				obj, err := util.Object2Unstructured(pod)
+				Expect(err).Should(BeNil())
+				objs = append(objs, obj)
+			}
+			workload := &unstructured.Unstructured{}
+			workload.SetLabels(map[string]string{oam.LabelAppComponent: "backend", oam.LabelAppRevision: "app-v1"})
+
+			summary, err := summarizePods(k8sClient, objs, "")
+			Expect(err).Should(BeNil())
+			setPodsOwner(summary, workload)
+			Expect(summary.Pods[0].Component).Should(Equal("frontend"))
+			Expect(summary.Pods[0].Revision).Should(Equal("app-v2"))
+			Expect(summary.Pods[1].Component).Should(Equal("backend"))
+			Expect(summary.Pods[1].Revision).Should(Equal("app-v1"))
+		})
+
+		It("Test filter pods by status", func() {
+			running := basePod.DeepCopy()
+			running.SetName("pod-running")
+			running.Status.Phase = corev1.PodRunning
+			crashing := basePod.DeepCopy()
+			crashing.SetName("pod-crashing")
+			crashing.Status.Phase = corev1.PodRunning
+			crashing.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "express-server-1",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}}
+			failed := basePod.DeepCopy()
+			failed.SetName("pod-failed")
+			failed.Status.Phase = corev1.PodFailed
+			var objs []*unstructured.Unstructured
+			for _, pod := range []*corev1.Pod{running, crashing, failed} {
+				obj, err := util.Object2Unstructured(pod)
+				Expect(err).Should(BeNil())
+				objs = append(objs, obj)
+			}
+
+			summary, err := summarizePods(k8sClient, objs, "")
+			Expect(err).Should(BeNil())
+			Expect(filterPodsByStatus(objs, summary, nil)).Should(HaveLen(3))
+			filtered := filterPodsByStatus(objs, summary, []string{"crashloopbackoff", "Failed"})
+			Expect(filtered).Should(Equal(objs[1:]))
+			Expect(summary.Pods).Should(HaveLen(2))
+			Expect(summary.Pods[0].Name).Should(Equal("pod-crashing"))
+			Expect(summary.Total).Should(Equal(3))
+			Expect(summary.Statuses).Should(Equal(map[string]int{"Running": 1, "CrashLoopBackOff": 1, "Failed": 1}))
+		})
+
+		It("Test attach the most recent events to pods", func() {
+			now := time.Now()
+			for i, reason := range []string{"Scheduled", "Pulling", "BackOff"} {
+				event := &corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: fmt.Sprintf("pod-with-events.%d", i), Namespace: "default"},
+					InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "pod-with-events", Namespace: "default"},
+					Type:           corev1.EventTypeNormal,
+					Reason:         reason,
+					Count:          1,
+					LastTimestamp:  metav1.NewTime(now.Add(time.Duration(i) * time.Minute)),
+				}
+				Expect(k8sClient.Create(ctx, event)).Should(BeNil())
+			}
+			summary := &PodsSummary{Pods: []PodSummary{
+				{Name: "pod-with-events", Namespace: "default"},
+				{Name: "pod-without-events", Namespace: "default"},
+			}}
+			Expect(attachPodEvents(k8sClient, summary, "", 2)).Should(BeNil())
+			Expect(summary.Pods[0].Events).Should(HaveLen(2))
+			Expect(summary.Pods[0].Events[0].Reason).Should(Equal("BackOff"))
+			Expect(summary.Pods[0].Events[1].Reason).Should(Equal("Pulling"))
+			Expect(summary.Pods[1].Events).Should(BeEmpty())
+		})
+
+		It("Test attach the env of containers to pods", func() {
+			Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-env-config", Namespace: "default"},
+				Data:       map[string]string{"LEVEL": "debug", "MODE": "dev"},
+			})).Should(BeNil())
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-env-secret", Namespace: "default"},
+				Data:       map[string][]byte{"TOKEN": []byte("secret-token")},
+			})).Should(BeNil())
+			pod := &corev1.Pod{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-with-env", Namespace: "default"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:  "main",
+					Image: "nginx",
+					EnvFrom: []corev1.EnvFromSource{
+						{Prefix: "APP_", ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "pod-env-config"}}},
+						{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "pod-env-secret"}}},
+					},
+					Env: []corev1.EnvVar{
+						{Name: "APP_MODE", Value: "prod"},
+						{Name: "LOG_LEVEL", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "pod-env-config"}, Key: "LEVEL"}}},
+						{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "db"}, Key: "password"}}},
+						{Name: "POD_IP", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"}}},
+					},
+				}}},
+			}
+			obj, err := util.Object2Unstructured(pod)
+			Expect(err).Should(BeNil())
+			summary := &PodsSummary{Pods: []PodSummary{{Name: "pod-with-env", Namespace: "default"}}}
+			Expect(attachPodEnv(k8sClient, summary, []*unstructured.Unstructured{obj}, "")).Should(BeNil())
+			Expect(summary.Pods[0].ContainerEnv).Should(Equal([]ContainerEnv{{Name: "main", Env: []EnvVar{
+				{Name: "APP_LEVEL", Value: "debug", From: "configMap/pod-env-config/LEVEL"},
+				{Name: "APP_MODE", Value: "prod"},
+				{Name: "TOKEN", From: "secret/pod-env-secret/TOKEN", Redacted: true},
+				{Name: "LOG_LEVEL", Value: "debug", From: "configMap/pod-env-config/LEVEL"},
+				{Name: "PASSWORD", From: "secret/db/password", Redacted: true},
+				{Name: "POD_IP", From: "fieldRef/status.podIP"},
+			}}}))
+		})
+
+		It("Test match HelmRelease of any version", func() {
+			for _, version := range []string{"v2beta1", "v2beta2", "v2"} {
+				Expect(isHelmRelease(schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Version: version, Kind: "HelmRelease"})).Should(BeTrue())
+			}
+			Expect(isHelmRelease(schema.GroupVersionKind{Group: "apps.example.io", Version: "v1", Kind: "HelmRelease"})).Should(BeFalse())
+			Expect(isHelmRelease(schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Version: "v2beta1", Kind: "HelmChart"})).Should(BeFalse())
+		})
+
+		It("Test collect pod with incomplete parameter", func() {
+			emptyOpt := ""
+			prd := provider{cli: k8sClient}
+			v, err := value.NewValue(emptyOpt, nil, "")
+			Expect(err).Should(BeNil())
+			err = prd.CollectPods(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(Equal("var(path=value) not exist"))
+
+			optWithoutCluster := `value: {}`
+			v, err = value.NewValue(optWithoutCluster, nil, "")
+			Expect(err).Should(BeNil())
+			err = prd.CollectPods(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(Equal("var(path=cluster) not exist"))
+
+			optWithWrongValue := `value: {test: 1}
+cluster: "test"`
+			v, err = value.NewValue(optWithWrongValue, nil, "")
+			Expect(err).Should(BeNil())
+			err = prd.CollectPods(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+		})
+	})
+
+	Context("Test search event from k8s object", func() {
+		It("Test search event with incomplete parameter", func() {
+			emptyOpt := ""
+			prd := provider{cli: k8sClient}
+			v, err := value.NewValue(emptyOpt, nil, "")
+			Expect(err).Should(BeNil())
+			err = prd.SearchEvents(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(Equal("var(path=value) not exist"))
+
+			optWithoutCluster := `value: {}`
+			v, err = value.NewValue(optWithoutCluster, nil, "")
+			Expect(err).Should(BeNil())
+			err = prd.SearchEvents(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(Equal("var(path=cluster) not exist"))
+
+			optWithWrongValue := `value: {}
+cluster: "test"`
+			v, err = value.NewValue(optWithWrongValue, nil, "")
+			Expect(err).Should(BeNil())
+			err = prd.SearchEvents(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+		})
+
+		It("Test search the most recent events", func() {
+			now := time.Now()
+			for i, reason := range []string{"Scheduled", "Pulling", "BackOff"} {
+				event := &corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: fmt.Sprintf("hot-object.%d", i), Namespace: "default"},
+					InvolvedObject: corev1.ObjectReference{Kind: "ConfigMap", Name: "hot-object", Namespace: "default"},
+					Type:           corev1.EventTypeNormal,
+					Reason:         reason,
+					LastTimestamp:  metav1.NewTime(now.Add(time.Duration(i) * time.Minute)),
+				}
+				Expect(k8sClient.Create(ctx, event)).Should(BeNil())
+			}
+			prd := provider{cli: k8sClient}
+			v, err := value.NewValue(`value: {
+	apiVersion: "v1"
+	kind: "ConfigMap"
+	metadata: {
+		name: "hot-object"
+		namespace: "default"
+	}
+}
+cluster: ""
+limit: 2`, nil, "")
+			Expect(err).Should(BeNil())
+			Expect(prd.SearchEvents(nil, v, nil)).Should(BeNil())
+			var events []corev1.Event
+			list, err := v.LookupValue("list")
+			Expect(err).Should(BeNil())
+			Expect(list.UnmarshalTo(&events)).Should(BeNil())
+			Expect(events).Should(HaveLen(2))
+			Expect(events[0].Reason).Should(Equal("BackOff"))
+			Expect(events[1].Reason).Should(Equal("Pulling"))
+		})
+
+		It("Test search the events of the reasons", func() {
+			for i, reason := range []string{"Scheduled", "FailedScheduling", "BackOff"} {
+				Expect(k8sClient.Create(ctx, &corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: fmt.Sprintf("reason-object.%d", i), Namespace: "default"},
+					InvolvedObject: corev1.ObjectReference{Kind: "ConfigMap", Name: "reason-object", Namespace: "default"},
+					Type:           corev1.EventTypeWarning,
+					Reason:         reason,
+				})).Should(BeNil())
+			}
+			prd := provider{cli: k8sClient}
+			search := func(reasons string) []corev1.Event {
+				v, err := value.NewValue(`value: {
+	apiVersion: "v1"
+	kind: "ConfigMap"
+	metadata: {
+		name: "reason-object"
+		namespace: "default"
+	}
+}
+cluster: ""
+reasons: `+reasons, nil, "")
+				Expect(err).Should(BeNil())
+				Expect(prd.SearchEvents(nil, v, nil)).Should(BeNil())
+				var events []corev1.Event
+				list, err := v.LookupValue("list")
+				Expect(err).Should(BeNil())
+				Expect(list.UnmarshalTo(&events)).Should(BeNil())
+				return events
+			}
+			events := search(`["FailedScheduling", "BackOff"]`)
+			Expect(events).Should(HaveLen(2))
+			for _, event := range events {
+				Expect(event.Reason).Should(BeElementOf("FailedScheduling", "BackOff"))
+			}
+			Expect(search(`[]`)).Should(HaveLen(3))
+			Expect(search(`["Pulling"]`)).Should(BeEmpty())
+		})
+	})
+
+	Context("Test CollectLogsInPod", func() {
+		It("Test CollectLogsInPod with specified container", func() {
+			prd := provider{cli: k8sClient, cfg: cfg}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "hello-world", Namespace: "default"},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "main", Image: "busybox"}},
+				}}
+			Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+
+			v, err := value.NewValue(``, nil, "")
+			Expect(err).Should(Succeed())
+			err = prd.CollectLogsInPod(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(ContainSubstring("var(path=cluster) not exist"))
+
+			v, err = value.NewValue(`cluster: "local"`, nil, "")
+			Expect(err).Should(Succeed())
+			err = prd.CollectLogsInPod(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(ContainSubstring("var(path=namespace) not exist"))
+
+			v, err = value.NewValue(`cluster: "local"
+namespace: "default"`, nil, "")
+			Expect(err).Should(Succeed())
+			err = prd.CollectLogsInPod(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(ContainSubstring("var(path=pod) not exist"))
+
+			v, err = value.NewValue(`cluster: "local"
+namespace: "default"
+pod: "hello-world"`, nil, "")
+			Expect(err).Should(Succeed())
+			err = prd.CollectLogsInPod(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(ContainSubstring("var(path=options) not exist"))
+
+			v, err = value.NewValue(`cluster: "local"
+namespace: "default"
+pod: "hello-world"
+options: {
+  container: 1
+}`, nil, "")
+			Expect(err).Should(Succeed())
+			err = prd.CollectLogsInPod(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(ContainSubstring("invalid log options content"))
+
+			v, err = value.NewValue(`cluster: "local"
+namespace: "default"
+pod: "hello-world"
+options: {
+  container: "main"
+  previous: true
+  sinceSeconds: 100
+  tailLines: 50
+}`, nil, "")
+			Expect(err).Should(Succeed())
+			Expect(prd.CollectLogsInPod(nil, v, nil)).Should(Succeed())
+			_, err = v.GetString("outputs", "logs")
+			Expect(err).Should(Succeed())
+
+			v, err = value.NewValue(`cluster: "local"
+namespace: "default"
+pod: "hello-world"
+sinceTime: "yesterday"
+options: {
+  container: "main"
+}`, nil, "")
+			Expect(err).Should(Succeed())
+			err = prd.CollectLogsInPod(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(ContainSubstring("invalid sinceTime \"yesterday\""))
+
+			v, err = value.NewValue(`cluster: "local"
+namespace: "default"
+pod: "hello-world"
+sinceTime: "2021-11-01T08:00:00Z"
+options: {
+  container: "main"
+  previous: true
+}`, nil, "")
+			Expect(err).Should(Succeed())
+			Expect(prd.CollectLogsInPod(nil, v, nil)).Should(Succeed())
+			fromDate, err := v.GetString("outputs", "info", "fromDate")
+			Expect(err).Should(Succeed())
+			Expect(fromDate).Should(Equal("2021-11-01T08:00:00Z"))
+		})
+
+		It("Test share the clientset between the log queries", func() {
+			prd := provider{cli: k8sClient, cfg: cfg}
+			clientSet, err := prd.getClientSet()
+			Expect(err).Should(BeNil())
+			shared, err := prd.getClientSet()
+			Expect(err).Should(BeNil())
+			Expect(shared).Should(BeIdenticalTo(clientSet))
+		})
+
+		It("Test CollectLogsInPod with follow budget", func() {
+			prd := provider{cli: k8sClient, cfg: cfg, clientSet: fake.NewSimpleClientset(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "hello-world", Namespace: "default"},
+			})}
+			v, err := value.NewValue(`cluster: "local"
+namespace: "default"
+pod: "hello-world"
+followSeconds: "ten"
+options: {
+  container: "main"
+}`, nil, "")
+			Expect(err).Should(Succeed())
+			err = prd.CollectLogsInPod(nil, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.Error()).Should(ContainSubstring("invalid followSeconds"))
+
+			v, err = value.NewValue(`cluster: "local"
+namespace: "default"
+pod: "hello-world"
+followSeconds: 1
+options: {
+  container: "main"
+}`, nil, "")
+			Expect(err).Should(Succeed())
+			Expect(prd.CollectLogsInPod(nil, v, nil)).Should(Succeed())
+			logs, err := v.GetString("outputs", "logs")
+			Expect(err).Should(Succeed())
+			Expect(logs).Should(Equal("fake logs"))
+			_, err = v.LookupValue("outputs", "err")
+			Expect(err).ShouldNot(BeNil())
+		})
+
+		It("Test CollectLogsInPod with cancelled query", func() {
+			prd := provider{cli: k8sClient, cfg: cfg}
+			v, err := value.NewValue(`cluster: "local"
+namespace: "default"
+pod: "hello-world"
+options: {
+  container: "main"
+}`, nil, "")
+			Expect(err).Should(Succeed())
+			queryCtx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err = prd.CollectLogsInPod(&queryContext{ctx: queryCtx}, v, nil)
+			Expect(err).ShouldNot(BeNil())
+			Expect(errors.Is(err, context.Canceled)).Should(BeTrue())
+			Expect(getContext(nil)).Should(Equal(context.Background()))
+		})
+
+		It("Test get the start time of the current container", func() {
+			created := metav1.NewTime(time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC))
+			started := metav1.NewTime(created.Add(time.Hour))
+			finished := metav1.NewTime(created.Add(2 * time.Hour))
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}}
+			Expect(getContainerStartTime(pod, "")).Should(Equal(created))
+
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "sidecar",
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: started}},
+			}, {
+				Name:                 "main",
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: finished}},
+			}, {
+				Name: "init",
+			}}
+			Expect(getContainerStartTime(pod, "")).Should(Equal(started))
+			Expect(getContainerStartTime(pod, "main")).Should(Equal(finished))
+			Expect(getContainerStartTime(pod, "init")).Should(Equal(created))
+		})
+
+		It("Test prefix the lines of logs", func() {
+			Expect(prefixLines("", "[web] ")).Should(Equal(""))
+			Expect(prefixLines("line1\nline2\n", "[web] ")).Should(Equal("[web] line1\n[web] line2\n"))
+			Expect(prefixLines("line1\nline2", "[web] ")).Should(Equal("[web] line1\n[web] line2"))
+			pod := &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init"}},
+				Containers:     []corev1.Container{{Name: "web"}, {Name: "sidecar"}},
+			}}
+			Expect(getPodContainerNames(pod)).Should(Equal([]string{"init", "web", "sidecar"}))
+		})
+
+		It("Test get the init containers in order", func() {
+			pod := &corev1.Pod{
+				Spec: corev1.PodSpec{InitContainers: []corev1.Container{{Name: "migrate"}, {Name: "wait-db"}, {Name: "seed"}, {Name: "warmup"}}},
+				Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "migrate", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+					{Name: "wait-db", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+						LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}},
+					{Name: "seed", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}},
+				}},
+			}
+			names, notStarted := getInitContainerNames(pod)
+			Expect(names).Should(Equal([]string{"migrate", "wait-db", "seed", "warmup"}))
+			Expect(notStarted).Should(Equal(map[string]bool{"seed": true, "warmup": true}))
+			Expect(initContainerSeparator("migrate", false)).Should(Equal("==> init container migrate <==\n"))
+			Expect(initContainerSeparator("seed", true)).Should(Equal("==> init container seed (not started) <==\n"))
+		})
+
+		It("Test compress logs", func() {
+			logsGzip, err := compressLogs("line1\nline2\n")
+			Expect(err).Should(BeNil())
+			compressed, err := base64.StdEncoding.DecodeString(logsGzip)
+			Expect(err).Should(BeNil())
+			r, err := gzip.NewReader(bytes.NewReader(compressed))
+			Expect(err).Should(BeNil())
+			logs, err := io.ReadAll(r)
+			Expect(err).Should(BeNil())
+			Expect(string(logs)).Should(Equal("line1\nline2\n"))
+		})
+
+		It("Test read logs with limit bytes", func() {
+			logs, truncated, err := readLogs(strings.NewReader("line1\nline2\n"), nil)
+			Expect(err).Should(BeNil())
+			Expect(truncated).Should(BeFalse())
+			Expect(logs).Should(Equal("line1\nline2\n"))
+
+			limit := int64(8)
+			logs, truncated, err = readLogs(strings.NewReader("line1\nline2\n"), &limit)
+			Expect(err).Should(BeNil())
+			Expect(truncated).Should(BeTrue())
+			Expect(logs).Should(Equal("line1\nli"))
+
+			limit = int64(12)
+			logs, truncated, err = readLogs(strings.NewReader("line1\nline2\n"), &limit)
+			Expect(err).Should(BeNil())
+			Expect(truncated).Should(BeFalse())
+			Expect(logs).Should(Equal("line1\nline2\n"))
+		})
+	})
+
+	It("Test install provider", func() {
+		p := providers.NewProviders()
+		Install(p, k8sClient, cfg)
+		h, ok := p.GetHandler("query", "listResourcesInApp")
+		Expect(h).ShouldNot(BeNil())
+		Expect(ok).Should(Equal(true))
+		h, ok = p.GetHandler("query", "collectPods")
+		Expect(h).ShouldNot(BeNil())
+		Expect(ok).Should(Equal(true))
+		h, ok = p.GetHandler("query", "searchEvents")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectLogsInPod")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectServiceEndpoints")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectResourceDrift")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectImages")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "listAppClusters")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectAppGraph")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "describeResource")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectResourceProvenance")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectRevisionDiff")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectServiceBackends")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "getResourceYAML")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectPVCs")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectWorkflowStatus")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectServices")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectAppWarnings")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "getLastApplied")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectConfigRefs")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectHPA")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectServiceAccount")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectPolicies")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectNodeConditions")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectAppReadiness")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectRoutingRules")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectDistribution")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectHelmValues")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectJobs")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectProbes")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectSecrets")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+		h, ok = p.GetHandler("query", "collectApplyStatus")
+		Expect(ok).Should(Equal(true))
+		Expect(h).ShouldNot(BeNil())
+	})
+
+	It("Test convert errors to structured query errors", func() {
+		notFound := kerrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod")
+		Expect(NewQueryError(notFound)).Should(Equal(QueryError{Code: 404, Reason: metav1.StatusReasonNotFound, Message: notFound.Error()}))
+		forbidden := kerrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "pod", fmt.Errorf("no permission"))
+		Expect(NewQueryError(errors.Wrap(forbidden, "fail to get pod")).Reason).Should(Equal(metav1.StatusReasonForbidden))
+		Expect(NewQueryError(fmt.Errorf("unknown"))).Should(Equal(QueryError{Code: 500, Reason: metav1.StatusReasonInternalError, Message: "unknown"}))
+
+		v, err := value.NewValue("", nil, "")
+		Expect(err).Should(BeNil())
+		Expect(fillQueryError(v, notFound)).Should(BeNil())
+		errMessage, err := v.GetString("err")
+		Expect(err).Should(BeNil())
+		Expect(errMessage).Should(Equal(notFound.Error()))
+		reason, err := v.GetString("errDetail", "reason")
+		Expect(err).Should(BeNil())
+		Expect(reason).Should(Equal("NotFound"))
+	})
+
+	It("Test the cluster allowlist", func() {
+		prd := provider{cli: k8sClient, allowedClusters: newClusterAllowlist([]string{"local"})}
+		v, err := value.NewValue(`cluster: "forbidden"`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(prd.CollectNodeConditions(nil, v, nil)).Should(BeNil())
+		errMessage, err := v.GetString("err")
+		Expect(err).Should(BeNil())
+		Expect(errMessage).Should(ContainSubstring("not in the allowlist"))
+		reason, err := v.GetString("errDetail", "reason")
+		Expect(err).Should(BeNil())
+		Expect(reason).Should(Equal("Forbidden"))
+		_, err = v.LookupValue("list")
+		Expect(err).ShouldNot(BeNil())
+
+		v, err = value.NewValue(`cluster: ""`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(prd.CollectNodeConditions(nil, v, nil)).Should(BeNil())
+		_, err = v.LookupValue("err")
+		Expect(err).ShouldNot(BeNil())
+
+		v, err = value.NewValue(`cluster: "forbidden"
+namespace: "default"
+pod: "hello-world"
+options: container: "main"`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(prd.CollectLogsInPod(nil, v, nil)).Should(BeNil())
+		errMessage, err = v.GetString("err")
+		Expect(err).Should(BeNil())
+		Expect(errMessage).Should(ContainSubstring("not in the allowlist"))
+		reason, err = v.GetString("errDetail", "reason")
+		Expect(err).Should(BeNil())
+		Expect(reason).Should(Equal("Forbidden"))
+		_, err = v.LookupValue("outputs")
+		Expect(err).ShouldNot(BeNil())
+
+		// the resources in the clusters not allowed are skipped without reaching the clusters
+		collector := prd.newAppCollector(Option{})
+		objs, err := collector.getObjectsInClusters([]common.ClusterObjectReference{{
+			Cluster:         "forbidden",
+			ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "web"},
+		}})
+		Expect(err).Should(BeNil())
+		Expect(objs).Should(Equal([]*unstructured.Unstructured{nil}))
+		_, err = prd.newAppCollector(Option{Name: "app", Namespace: "default", Filter: FilterOption{Cluster: "forbidden"}}).CollectResourceFromApp()
+		Expect(kerrors.IsForbidden(err)).Should(BeTrue())
+
+		// all the clusters are allowed if the allowlist is empty
+		Expect(newClusterAllowlist(nil).allows("forbidden")).Should(BeTrue())
+		Expect(newClusterAllowlist([]string{""}).allows("local")).Should(BeTrue())
+	})
+
+	It("Test describe resource", func() {
+		deploy := baseDeploy.DeepCopy()
+		deploy.SetName("describe-deploy")
+		deploy.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "describe"}}
+		deploy.Spec.Template.ObjectMeta.SetLabels(map[string]string{"app": "describe"})
+		Expect(k8sClient.Create(ctx, deploy)).Should(BeNil())
+		pod := basePod.DeepCopy()
+		pod.SetName("describe-pod")
+		pod.SetLabels(map[string]string{"app": "describe"})
+		Expect(k8sClient.Create(ctx, pod)).Should(BeNil())
+		event := &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "describe-deploy.scaled", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{
+				Kind: "Deployment", Name: "describe-deploy", Namespace: "default", UID: deploy.UID,
+			},
+			Type:          corev1.EventTypeNormal,
+			Reason:        "ScalingReplicaSet",
+			Count:         1,
+			LastTimestamp: metav1.Now(),
+		}
+		Expect(k8sClient.Create(ctx, event)).Should(BeNil())
+
+		prd := provider{cli: k8sClient}
+		v, err := value.NewValue(`value: {
+	apiVersion: "apps/v1"
+	kind: "Deployment"
+	name: "describe-deploy"
+	namespace: "default"
+}
+cluster: ""`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(prd.DescribeResource(nil, v, nil)).Should(BeNil())
+		var res struct {
+			Description ResourceDescription `json:"description"`
+		}
+		Expect(v.UnmarshalTo(&res)).Should(BeNil())
+		Expect(res.Description.Metadata.Kind).Should(Equal("Deployment"))
+		Expect(res.Description.Metadata.Name).Should(Equal("describe-deploy"))
+		Expect(res.Description.Events).Should(HaveLen(1))
+		Expect(res.Description.Events[0].Reason).Should(Equal("ScalingReplicaSet"))
+		Expect(res.Description.Pods).Should(HaveLen(1))
+		Expect(res.Description.Pods[0].Name).Should(Equal("describe-pod"))
+
+		v, err = value.NewValue(`value: {
+	apiVersion: "apps/v1"
+	kind: "Deployment"
+	name: "not-exist"
+	namespace: "default"
+}
+cluster: ""`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(prd.DescribeResource(nil, v, nil)).Should(BeNil())
+		reason, err := v.GetString("errDetail", "reason")
+		Expect(err).Should(BeNil())
+		Expect(reason).Should(Equal("NotFound"))
+	})
+
+	It("Test collect resource provenance", func() {
+		namespace := "test-provenance"
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).Should(BeNil())
+		app := &v1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: namespace},
+			Spec: v1beta1.ApplicationSpec{
+				Components: []common.ApplicationComponent{{Name: "web", Type: "webservice"}},
+				Workflow: &v1beta1.Workflow{Steps: []v1beta1.WorkflowStep{{
+					Name:       "deploy-web",
+					Type:       "apply-component",
+					Properties: util.Object2RawExtension(map[string]string{"component": "web"}),
+				}}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		rt := &v1beta1.ResourceTracker{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "app-v1-" + namespace,
+				Labels:      map[string]string{oam.LabelAppName: "app", oam.LabelAppNamespace: namespace},
+				Annotations: map[string]string{oam.AnnotationPublishVersion: "alpha"},
+			},
+			Spec: v1beta1.ResourceTrackerSpec{
+				Type:                  v1beta1.ResourceTrackerTypeVersioned,
+				ApplicationGeneration: 1,
+				ManagedResources: []v1beta1.ManagedResource{{
+					ClusterObjectReference: common.ClusterObjectReference{ObjectReference: corev1.ObjectReference{
+						APIVersion: "apps/v1", Kind: "Deployment", Namespace: namespace, Name: "web",
+					}},
+					OAMObjectReference: common.OAMObjectReference{Component: "web"},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, rt)).Should(BeNil())
+
+		prd := provider{cli: k8sClient}
+		v, err := value.NewValue(fmt.Sprintf(`
+app: {
+	name: "app"
+	namespace: "%s"
+}
+value: {
+	apiVersion: "apps/v1"
+	kind: "Deployment"
+	metadata: {
+		name: "web"
+		namespace: "%s"
+		labels: "app.oam.dev/appRevision": "app-v1"
+	}
+}
+cluster: ""
+`, namespace, namespace), nil, "")
+		Expect(err).Should(BeNil())
+		Expect(prd.CollectResourceProvenance(nil, v, nil)).Should(BeNil())
+		provenance := ResourceProvenance{}
+		Expect(v.UnmarshalTo(&struct {
+			Provenance *ResourceProvenance `json:"provenance"`
+		}{Provenance: &provenance})).Should(BeNil())
+		Expect(provenance).Should(Equal(ResourceProvenance{
+			Component:       "web",
+			AppRevision:     "app-v1",
+			WorkflowStep:    "deploy-web",
+			PublishVersion:  "alpha",
+			ResourceTracker: "app-v1-" + namespace,
+		}))
+	})
+
+	It("Test collect resources of publish version", func() {
+		namespace := "test-publish-version"
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).Should(BeNil())
+		app := &v1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: namespace},
+			Spec:       v1beta1.ApplicationSpec{Components: []common.ApplicationComponent{{Name: "config", Type: "k8s-objects"}}},
+		}
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		for i, publishVersion := range []string{"alpha", "beta"} {
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:      "config-" + publishVersion,
+				Namespace: namespace,
+				Labels:    map[string]string{oam.LabelAppComponent: "config"},
+			}}
+			Expect(k8sClient.Create(ctx, cm)).Should(BeNil())
+			Expect(k8sClient.Create(ctx, &v1beta1.ResourceTracker{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        fmt.Sprintf("app-%s-%s", publishVersion, namespace),
+					Labels:      map[string]string{oam.LabelAppName: "app", oam.LabelAppNamespace: namespace},
+					Annotations: map[string]string{oam.AnnotationPublishVersion: publishVersion},
+				},
+				Spec: v1beta1.ResourceTrackerSpec{
+					Type:                  v1beta1.ResourceTrackerTypeVersioned,
+					ApplicationGeneration: int64(i + 1),
+					ManagedResources: []v1beta1.ManagedResource{{
+						ClusterObjectReference: common.ClusterObjectReference{ObjectReference: corev1.ObjectReference{
+							APIVersion: "v1", Kind: "ConfigMap", Namespace: namespace, Name: cm.Name,
+						}},
+						OAMObjectReference: common.OAMObjectReference{Component: "config"},
+					}},
+				},
+			})).Should(BeNil())
+		}
+
+		resources, err := NewAppCollector(k8sClient, Option{Name: "app", Namespace: namespace, PublishVersion: "alpha"}).CollectResourceFromApp()
+		Expect(err).Should(BeNil())
+		Expect(resources).Should(HaveLen(1))
+		Expect(resources[0].Object.GetName()).Should(Equal("config-alpha"))
+		Expect(resources[0].Component).Should(Equal("config"))
+
+		_, err = NewAppCollector(k8sClient, Option{Name: "app", Namespace: namespace, PublishVersion: "gamma"}).CollectResourceFromApp()
+		Expect(err).ShouldNot(BeNil())
+		Expect(err.Error()).Should(ContainSubstring("publish version gamma of application app not found"))
+	})
+
+	It("Test diff the revisions of application", func() {
+		previous := []common.ApplicationComponent{{
+			Name:       "web",
+			Type:       "webservice",
+			Properties: util.Object2RawExtension(map[string]interface{}{"image": "nginx:1.20", "cpu": "0.5", "env": map[string]interface{}{"A": "1", "B": "2"}}),
+			Traits: []common.ApplicationTrait{
+				{Type: "scaler", Properties: util.Object2RawExtension(map[string]interface{}{"replicas": 1})},
+				{Type: "expose", Properties: util.Object2RawExtension(map[string]interface{}{"port": []int{80}})},
+			},
+		}, {
+			Name: "db",
+			Type: "worker",
+		}, {
+			Name:       "cache",
+			Type:       "worker",
+			Properties: util.Object2RawExtension(map[string]interface{}{"image": "redis"}),
+		}}
+		current := []common.ApplicationComponent{{
+			Name:       "web",
+			Type:       "webservice",
+			Properties: util.Object2RawExtension(map[string]interface{}{"image": "nginx:1.21", "env": map[string]interface{}{"A": "1", "B": "3"}, "port": 80}),
+			Traits: []common.ApplicationTrait{
+				{Type: "scaler", Properties: util.Object2RawExtension(map[string]interface{}{"replicas": 2})},
+				{Type: "gateway", Properties: util.Object2RawExtension(map[string]interface{}{"domain": "example.com"})},
+			},
+		}, {
+			Name:       "cache",
+			Type:       "worker",
+			Properties: util.Object2RawExtension(map[string]interface{}{"image": "redis"}),
+		}, {
+			Name: "queue",
+			Type: "worker",
+		}}
+		diff := &RevisionDiff{}
+		Expect(diffComponents(diff, previous, current)).Should(BeNil())
+		Expect(diff.AddedComponents).Should(Equal([]string{"queue"}))
+		Expect(diff.RemovedComponents).Should(Equal([]string{"db"}))
+		Expect(diff.ModifiedComponents).Should(Equal([]ComponentDiff{{
+			Name: "web",
+			Type: "webservice",
+			Properties: []PropertyChange{
+				{Path: "cpu", Old: "0.5"},
+				{Path: "env.B", Old: "2", New: "3"},
+				{Path: "image", Old: "nginx:1.20", New: "nginx:1.21"},
+				{Path: "port", New: float64(80)},
+			},
+			AddedTraits:    []string{"gateway"},
+			RemovedTraits:  []string{"expose"},
+			ModifiedTraits: []string{"scaler"},
+		}}))
+
+		revisions := []v1beta1.ApplicationRevision{
+			{ObjectMeta: metav1.ObjectMeta{Name: "app-v3"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "app-v1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "app-v2"}},
+		}
+		Expect(findPreviousRevision(revisions, "app-v3").Name).Should(Equal("app-v2"))
+		Expect(findPreviousRevision(revisions, "app-v1")).Should(BeNil())
+	})
+
+	It("Test get the manifest of resource", func() {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetName("manifest")
+		obj.SetNamespace("default")
+		obj.SetAnnotations(map[string]string{
+			oam.AnnotationLastAppliedConfiguration: "{}",
+			oam.AnnotationInplaceUpgrade:           "true",
+			"team":                                 "payments",
+		})
+		obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply}})
+		manifest, err := getResourceManifest(obj)
+		Expect(err).Should(BeNil())
+		Expect(manifest.YAML).Should(Equal(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    team: payments
+  name: manifest
+  namespace: default
+`))
+		Expect(manifest.JSON).Should(ContainSubstring(`"annotations":{"team":"payments"}`))
+		Expect(manifest.JSON).ShouldNot(ContainSubstring("managedFields"))
+		Expect(obj.GetManagedFields()).Should(HaveLen(1))
+	})
+
+	It("Test get the last applied configuration of resource", func() {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetName("last-applied")
+		lastApplied, err := getLastAppliedConfig(obj)
+		Expect(err).Should(BeNil())
+		Expect(lastApplied).Should(Equal(&LastAppliedConfig{Found: false}))
+
+		obj.SetAnnotations(map[string]string{
+			oam.AnnotationLastAppliedConfiguration: `{"apiVersion":"v1","kind":"ConfigMap","data":{"key":"kubectl"}}`,
+			oam.AnnotationLastAppliedConfig:        `{"apiVersion":"v1","kind":"ConfigMap","data":{"key":"vela"}}`,
+		})
+		lastApplied, err = getLastAppliedConfig(obj)
+		Expect(err).Should(BeNil())
+		Expect(lastApplied.Found).Should(BeTrue())
+		Expect(lastApplied.Annotation).Should(Equal(oam.AnnotationLastAppliedConfig))
+		Expect(lastApplied.Manifest["data"]).Should(Equal(map[string]interface{}{"key": "vela"}))
+		Expect(lastApplied.YAML).Should(Equal("apiVersion: v1\ndata:\n  key: vela\nkind: ConfigMap\n"))
+
+		obj.SetAnnotations(map[string]string{oam.AnnotationLastAppliedConfig: "invalid"})
+		_, err = getLastAppliedConfig(obj)
+		Expect(err).ShouldNot(BeNil())
+	})
+
+	It("Test build app graph", func() {
+		newObj := func(apiVersion, kind, name, uid, resourceType, traitType string) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion(apiVersion)
+			obj.SetKind(kind)
+			obj.SetNamespace("default")
+			obj.SetName(name)
+			obj.SetUID(types.UID(uid))
+			labels := map[string]string{oam.LabelOAMResourceType: resourceType}
+			if traitType != "" {
+				labels[oam.TraitTypeLabel] = traitType
+			}
+			obj.SetLabels(labels)
+			return obj
+		}
+		deploy := newObj("apps/v1", "Deployment", "web", "uid-deploy", oam.ResourceTypeWorkload, "")
+		rs := newObj("apps/v1", "ReplicaSet", "web-abc", "uid-rs", "", "")
+		rs.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "uid-deploy"}})
+		svc := newObj("v1", "Service", "web", "uid-svc", oam.ResourceTypeTrait, "gateway")
+		cm := newObj("v1", "ConfigMap", "web-config", "uid-cm", oam.ResourceTypeTrait, "storage")
+
+		graph := buildAppGraph([]Resource{
+			{Component: "web", Cluster: "local", Object: deploy},
+			{Component: "web", Cluster: "local", Object: rs},
+			{Component: "web", Cluster: "local", Object: svc},
+			{Component: "web", Cluster: "local", Object: cm},
+		}, common.AppStatus{Services: []common.ApplicationComponentStatus{{
+			Name:    "web",
+			Healthy: true,
+			Traits:  []common.ApplicationTraitStatus{{Type: "gateway", Healthy: false}},
+		}}})
+		Expect(graph.Nodes).Should(HaveLen(4))
+		Expect(graph.Nodes[0]).Should(Equal(GraphNode{
+			ID:         "local/apps/v1/Deployment/default/web",
+			Cluster:    "local",
+			Component:  "web",
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  "default",
+			Name:       "web",
+			Health:     HealthStatusHealthy,
+		}))
+		Expect(graph.Nodes[2].Health).Should(Equal(HealthStatusUnhealthy))
+		Expect(graph.Nodes[3].Health).Should(Equal(HealthStatusUnknown))
+		Expect(graph.Edges).Should(Equal([]GraphEdge{
+			{From: "local/apps/v1/Deployment/default/web", To: "local/apps/v1/ReplicaSet/default/web-abc", Type: EdgeTypeOwner},
+			{From: "local/apps/v1/Deployment/default/web", To: "local/v1/Service/default/web", Type: EdgeTypeTrait},
+			{From: "local/apps/v1/Deployment/default/web", To: "local/v1/ConfigMap/default/web-config", Type: EdgeTypeTrait},
+		}))
+	})
+
+	It("Test list app clusters", func() {
+		app := &v1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-clusters", Namespace: "default"},
+			Spec: v1beta1.ApplicationSpec{Components: []common.ApplicationComponent{{
+				Name:       "web",
+				Type:       "webservice",
+				Properties: util.Object2RawExtension(map[string]string{"image": "busybox"}),
+			}}},
+		}
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		ref := func(cluster, name string) common.ClusterObjectReference {
+			return common.ClusterObjectReference{Cluster: cluster, ObjectReference: corev1.ObjectReference{
+				APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: name,
+			}}
+		}
+		app.Status.AppliedResources = []common.ClusterObjectReference{
+			ref("", "web"), ref("cluster-b", "web"), ref("cluster-a", "web"), ref("cluster-b", "web-canary"),
+		}
+		Expect(k8sClient.Status().Update(ctx, app)).Should(BeNil())
+
+		prd := provider{cli: k8sClient}
+		v, err := value.NewValue(`app: {
+	name: "app-clusters"
+	namespace: "default"
+}`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(prd.ListAppClusters(nil, v, nil)).Should(BeNil())
+		var clusters struct {
+			List []AppCluster `json:"list"`
+		}
+		Expect(v.UnmarshalTo(&clusters)).Should(BeNil())
+		Expect(clusters.List).Should(Equal([]AppCluster{
+			{Cluster: "cluster-a", Resources: 1},
+			{Cluster: "cluster-b", Resources: 2},
+			{Cluster: "local", Resources: 1},
+		}))
+	})
+
+	It("Test collect node conditions", func() {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-pressure"}, Spec: corev1.NodeSpec{Unschedulable: true}}
+		Expect(k8sClient.Create(ctx, node)).Should(BeNil())
+		node.Status = corev1.NodeStatus{
+			Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("8Gi")},
+			Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3800m"), corev1.ResourceMemory: resource.MustParse("7Gi")},
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue, Reason: "KubeletReady"},
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue, Reason: "KubeletHasInsufficientMemory", Message: "kubelet has insufficient memory available"},
+				{Type: corev1.NodeNetworkUnavailable, Status: corev1.ConditionFalse},
+			},
+		}
+		Expect(k8sClient.Status().Update(ctx, node)).Should(BeNil())
+
+		prd := provider{cli: k8sClient}
+		v, err := value.NewValue(`cluster: ""`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(prd.CollectNodeConditions(nil, v, nil)).Should(BeNil())
+		var nodes struct {
+			List []NodeConditions `json:"list"`
+		}
+		Expect(v.UnmarshalTo(&nodes)).Should(BeNil())
+		var found *NodeConditions
+		for i := range nodes.List {
+			if nodes.List[i].Name == "node-pressure" {
+				found = &nodes.List[i]
+			}
+		}
+		Expect(found).ShouldNot(BeNil())
+		Expect(found.Unschedulable).Should(BeTrue())
+		cpu, allocatable := found.Capacity[corev1.ResourceCPU], found.Allocatable[corev1.ResourceCPU]
+		Expect(cpu.String()).Should(Equal("4"))
+		Expect(allocatable.String()).Should(Equal("3800m"))
+		Expect(found.Conditions).Should(HaveLen(4))
+		Expect(found.Conditions[1].Type).Should(Equal(corev1.NodeMemoryPressure))
+		Expect(found.Conditions[1].Status).Should(Equal(corev1.ConditionTrue))
+		Expect(found.Conditions[1].Message).Should(Equal("kubelet has insufficient memory available"))
+		// the conditions not reported by the kubelet are unknown
+		Expect(found.Conditions[2]).Should(Equal(NodeCondition{Type: corev1.NodeDiskPressure, Status: corev1.ConditionUnknown}))
+	})
+
+	It("Test collect policies of app", func() {
+		app := &v1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-policies", Namespace: "default"},
+			Spec: v1beta1.ApplicationSpec{
+				Components: []common.ApplicationComponent{{
+					Name:       "web",
+					Type:       "webservice",
+					Properties: util.Object2RawExtension(map[string]interface{}{"image": "busybox", "cpu": "0.5"}),
+				}, {
+					Name:       "worker",
+					Type:       "worker",
+					Properties: util.Object2RawExtension(map[string]interface{}{"image": "busybox"}),
+				}},
+				Policies: []v1beta1.AppPolicy{{
+					Name:       "health",
+					Type:       "health",
+					Properties: util.Object2RawExtension(map[string]interface{}{"probeInterval": 30}),
+				}, {
+					Name: "envs",
+					Type: v1alpha1.EnvBindingPolicyType,
+					Properties: util.Object2RawExtension(v1alpha1.EnvBindingSpec{Envs: []v1alpha1.EnvConfig{{
+						Name:      "prod",
+						Placement: v1alpha1.EnvPlacement{ClusterSelector: &common.ClusterSelector{Name: "cluster-prod"}},
+						Selector:  &v1alpha1.EnvSelector{Components: []string{"web"}},
+						Patch: v1alpha1.EnvPatch{Components: []v1alpha1.EnvComponentPatch{{
+							Name:       "web",
+							Type:       "webservice",
+							Properties: util.Object2RawExtension(map[string]interface{}{"image": "nginx"}),
+						}}},
+					}}}),
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		app.Status.PolicyStatus = []common.PolicyStatus{{
+			Name: "envs",
+			Type: v1alpha1.EnvBindingPolicyType,
+			Status: util.Object2RawExtension(v1alpha1.EnvBindingStatus{Envs: []v1alpha1.EnvStatus{{
+				Env:        "prod",
+				Placements: []v1alpha1.PlacementDecision{{Cluster: "cluster-prod", Namespace: "default"}},
+			}}}),
+		}}
+		Expect(k8sClient.Status().Update(ctx, app)).Should(BeNil())
+
+		prd := provider{cli: k8sClient}
+		v, err := value.NewValue(`app: {
+	name: "app-policies"
+	namespace: "default"
+}`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(prd.CollectPolicies(nil, v, nil)).Should(BeNil())
+		var policies struct {
+			List []AppPolicy `json:"list"`
+		}
+		Expect(v.UnmarshalTo(&policies)).Should(BeNil())
+		Expect(policies.List).Should(HaveLen(2))
+		Expect(policies.List[0].Type).Should(Equal("health"))
+		Expect(policies.List[0].Properties).Should(Equal(map[string]interface{}{"probeInterval": float64(30)}))
+		Expect(policies.List[0].Envs).Should(BeEmpty())
+		envs := policies.List[1].Envs
+		Expect(envs).Should(HaveLen(1))
+		Expect(envs[0].Name).Should(Equal("prod"))
+		Expect(envs[0].Placement.ClusterSelector.Name).Should(Equal("cluster-prod"))
+		Expect(envs[0].Decisions).Should(Equal([]v1alpha1.PlacementDecision{{Cluster: "cluster-prod", Namespace: "default"}}))
+		// the worker is not selected by the env, and the image of web is overridden
+		Expect(envs[0].Components).Should(HaveLen(1))
+		properties, err := util.RawExtension2Map(envs[0].Components[0].Properties)
+		Expect(err).Should(BeNil())
+		Expect(properties).Should(Equal(map[string]interface{}{"image": "nginx", "cpu": "0.5"}))
+	})
+
+	It("Test collect config refs of workload", func() {
+		Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "refs-config", Namespace: "default"},
+			Data:       map[string]string{"log-level": "info", "port": "80"},
+		})).Should(BeNil())
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "refs-secret", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("top-secret"), "username": []byte("admin")},
+		})).Should(BeNil())
+		deploy := baseDeploy.DeepCopy()
+		deploy.SetName("refs-deploy")
+		deploy.Spec.Template.Spec.Containers = []corev1.Container{{
+			Name:    "app",
+			Image:   "nginx",
+			EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "refs-config"}}}},
+			Env: []corev1.EnvVar{{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "refs-secret"}, Key: "password",
+			}}}},
+		}}
+		deploy.Spec.Template.Spec.Volumes = []corev1.Volume{{
+			Name:         "secret",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "refs-secret", Items: []corev1.KeyToPath{{Key: "username", Path: "user"}}}},
+		}, {
+			Name: "missing",
+			VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{{
+				ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "refs-missing"}},
+			}}}},
+		}}
+		obj, err := util.Object2Unstructured(deploy)
+		Expect(err).Should(BeNil())
+		refs, err := collectConfigRefs(k8sClient, obj, "")
+		Expect(err).Should(BeNil())
+		Expect(refs).Should(Equal([]ConfigRef{{
+			Kind: "ConfigMap", Name: "refs-config", Namespace: "default",
+			Sources: []string{ConfigRefSourceEnvFrom}, Exists: true, Keys: []string{"log-level", "port"},
+		}, {
+			Kind: "Secret", Name: "refs-secret", Namespace: "default",
+			Sources:        []string{ConfigRefSourceEnv, ConfigRefSourceVolume},
+			ReferencedKeys: []string{"password", "username"},
+			Exists:         true,
+			Keys:           []string{"password", "username"},
+		}, {
+			Kind: "ConfigMap", Name: "refs-missing", Namespace: "default",
+			Sources: []string{ConfigRefSourceVolume},
+		}}))
+		data, err := json.Marshal(refs)
+		Expect(err).Should(BeNil())
+		Expect(string(data)).ShouldNot(ContainSubstring("top-secret"))
+	})
+
+	It("Test collect hpa of workload", func() {
+		utilization := int32(80)
+		newHPA := func(name, apiVersion, target string) *autoscalingv2beta2.HorizontalPodAutoscaler {
+			return &autoscalingv2beta2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{APIVersion: apiVersion, Kind: "Deployment", Name: target},
+					MinReplicas:    pointer.Int32(1),
+					MaxReplicas:    5,
+					Metrics: []autoscalingv2beta2.MetricSpec{{
+						Type: autoscalingv2beta2.ResourceMetricSourceType,
+						Resource: &autoscalingv2beta2.ResourceMetricSource{
+							Name:   corev1.ResourceCPU,
+							Target: autoscalingv2beta2.MetricTarget{Type: autoscalingv2beta2.UtilizationMetricType, AverageUtilization: &utilization},
+						},
+					}, {
+						Type: autoscalingv2beta2.PodsMetricSourceType,
+						Pods: &autoscalingv2beta2.PodsMetricSource{
+							Metric: autoscalingv2beta2.MetricIdentifier{Name: "requests_per_second"},
+							Target: autoscalingv2beta2.MetricTarget{Type: autoscalingv2beta2.AverageValueMetricType, AverageValue: resource.NewQuantity(100, resource.DecimalSI)},
+						},
+					}},
+				},
+			}
+		}
+		hpa := newHPA("hpa-web", "apps/v1", "hpa-web")
+		Expect(k8sClient.Create(ctx, hpa)).Should(BeNil())
+		current := int32(50)
+		hpa.Status = autoscalingv2beta2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 2,
+			DesiredReplicas: 3,
+			CurrentMetrics: []autoscalingv2beta2.MetricStatus{{
+				Type: autoscalingv2beta2.ResourceMetricSourceType,
+				Resource: &autoscalingv2beta2.ResourceMetricStatus{
+					Name:    corev1.ResourceCPU,
+					Current: autoscalingv2beta2.MetricValueStatus{AverageUtilization: &current},
+				},
+			}},
+		}
+		Expect(k8sClient.Status().Update(ctx, hpa)).Should(BeNil())
+		Expect(k8sClient.Create(ctx, newHPA("hpa-other", "apps/v1", "hpa-other"))).Should(BeNil())
+
+		deploy := baseDeploy.DeepCopy()
+		deploy.SetName("hpa-web")
+		obj, err := util.Object2Unstructured(deploy)
+		Expect(err).Should(BeNil())
+		hpas, err := collectHPAs(k8sClient, obj, "")
+		Expect(err).Should(BeNil())
+		Expect(hpas).Should(HaveLen(1))
+		Expect(hpas[0].Name).Should(Equal("hpa-web"))
+		Expect(*hpas[0].MinReplicas).Should(Equal(int32(1)))
+		Expect(hpas[0].CurrentReplicas).Should(Equal(int32(2)))
+		Expect(hpas[0].DesiredReplicas).Should(Equal(int32(3)))
+		Expect(hpas[0].Metrics).Should(Equal([]HPAMetric{
+			{Type: autoscalingv2beta2.ResourceMetricSourceType, Name: "cpu", Target: "80%", Current: "50%"},
+			{Type: autoscalingv2beta2.PodsMetricSourceType, Name: "requests_per_second", Target: "100"},
+		}))
+	})
+
+	It("Test collect service account of workload", func() {
+		Expect(k8sClient.Create(ctx, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "sa-web", Namespace: "default"}})).Should(BeNil())
+		Expect(k8sClient.Create(ctx, &rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "sa-web-reader", Namespace: "default"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}},
+		})).Should(BeNil())
+		Expect(k8sClient.Create(ctx, &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "sa-web-reader", Namespace: "default"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa-web"}},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "sa-web-reader"},
+		})).Should(BeNil())
+		Expect(k8sClient.Create(ctx, &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "sa-other-reader", Namespace: "default"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa-other", Namespace: "default"}},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "sa-web-reader"},
+		})).Should(BeNil())
+		Expect(k8sClient.Create(ctx, &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "sa-web-view"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa-web", Namespace: "default"}},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "sa-web-missing"},
+		})).Should(BeNil())
+		deploy := baseDeploy.DeepCopy()
+		deploy.Spec.Template.Spec.ServiceAccountName = "sa-web"
+		obj, err := util.Object2Unstructured(deploy)
+		Expect(err).Should(BeNil())
+
+		info, err := collectServiceAccount(k8sClient, obj, "", false)
+		Expect(err).Should(BeNil())
+		Expect(info.Name).Should(Equal("sa-web"))
+		Expect(info.Exists).Should(BeTrue())
+		Expect(info.Bindings).Should(ContainElement(ServiceAccountBinding{
+			Kind: "RoleBinding", Name: "sa-web-reader", Namespace: "default", RoleKind: "Role", RoleName: "sa-web-reader",
+		}))
+		Expect(info.Bindings).Should(ContainElement(ServiceAccountBinding{
+			Kind: "ClusterRoleBinding", Name: "sa-web-view", RoleKind: "ClusterRole", RoleName: "sa-web-missing",
+		}))
+		for _, binding := range info.Bindings {
+			Expect(binding.Name).ShouldNot(Equal("sa-other-reader"))
+			Expect(binding.Rules).Should(BeEmpty())
+		}
+
+		info, err = collectServiceAccount(k8sClient, obj, "", true)
+		Expect(err).Should(BeNil())
+		for _, binding := range info.Bindings {
+			if binding.Name == "sa-web-reader" {
+				Expect(binding.Rules).Should(HaveLen(1))
+			}
+		}
+
+		deploy.Spec.Template.Spec.ServiceAccountName = ""
+		obj, err = util.Object2Unstructured(deploy)
+		Expect(err).Should(BeNil())
+		info, err = collectServiceAccount(k8sClient, obj, "", false)
+		Expect(err).Should(BeNil())
+		Expect(info.Name).Should(Equal("default"))
+	})
+
+	It("Test normalize the hub cluster name", func() {
+		Expect(normalizeClusterName("")).Should(Equal(multicluster.ClusterLocalName))
+		Expect(normalizeClusterName("local")).Should(Equal(multicluster.ClusterLocalName))
+		Expect(normalizeClusterName("cluster-a")).Should(Equal("cluster-a"))
+		ref := func(cluster string) common.ClusterObjectReference {
+			return common.ClusterObjectReference{Cluster: cluster, ObjectReference: corev1.ObjectReference{Namespace: "default"}}
+		}
+		filter := FilterOption{Cluster: "local", ClusterNamespace: "default"}
+		Expect(isResourceInTargetCluster(filter, ref(""))).Should(BeTrue())
+		Expect(isResourceInTargetCluster(filter, ref("local"))).Should(BeTrue())
+		Expect(isResourceInTargetCluster(filter, ref("cluster-a"))).Should(BeFalse())
+		filter = FilterOption{ClusterNamespace: "default"}
+		Expect(isResourceInTargetCluster(filter, ref("local"))).Should(BeTrue())
+
+		v, err := value.NewValue(`cluster: ""`, nil, "")
+		Expect(err).Should(BeNil())
+		cluster, err := getClusterName(v)
+		Expect(err).Should(BeNil())
+		Expect(cluster).Should(Equal(multicluster.ClusterLocalName))
+	})
+
+	It("Test collect images", func() {
+		pod := basePod.DeepCopy()
+		pod.Spec.InitContainers = []corev1.Container{{Name: "init", Image: "busybox"}}
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "sidecar", Image: "crccheck/hello-world"})
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:    "express-server-1",
+			ImageID: "docker-pullable://crccheck/hello-world@sha256:0123abcd",
+		}}
+		podObj, err := util.Object2Unstructured(pod)
+		Expect(err).Should(BeNil())
+		podObj.SetGroupVersionKind(podGVK)
+		cm := &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+
+		images, err := collectImages(k8sClient, []Resource{
+			{Component: "express-server", Cluster: "local", Object: podObj},
+			{Component: "express-server", Cluster: "local", Object: cm},
+		})
+		Expect(err).Should(BeNil())
+		Expect(images).Should(Equal([]ContainerImage{
+			{Image: "busybox", Component: "express-server", Cluster: "local"},
+			{Image: "crccheck/hello-world", Component: "express-server", Cluster: "local", Digest: "sha256:0123abcd"},
+		}))
+	})
+
+	It("Test collect routing rules", func() {
+		prefix := networkv1.PathTypePrefix
+		ingress, err := util.Object2Unstructured(&networkv1.Ingress{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: networkv1.IngressSpec{Rules: []networkv1.IngressRule{{
+				Host: "web.example.com",
+				IngressRuleValue: networkv1.IngressRuleValue{HTTP: &networkv1.HTTPIngressRuleValue{Paths: []networkv1.HTTPIngressPath{{
+					Path:     "/api",
+					PathType: &prefix,
+					Backend:  networkv1.IngressBackend{Service: &networkv1.IngressServiceBackend{Name: "api", Port: networkv1.ServiceBackendPort{Number: 8080}}},
+				}, {
+					Path:     "/",
+					PathType: &prefix,
+					Backend:  networkv1.IngressBackend{Service: &networkv1.IngressServiceBackend{Name: "web", Port: networkv1.ServiceBackendPort{Name: "http"}}},
+				}}}},
+			}}},
+		})
+		Expect(err).Should(BeNil())
+		legacy, err := util.Object2Unstructured(&networkv1beta1.Ingress{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1beta1", Kind: "Ingress"},
+			ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "default"},
+			Spec:       networkv1beta1.IngressSpec{Backend: &networkv1beta1.IngressBackend{ServiceName: "fallback", ServicePort: intstr.FromInt(80)}},
+		})
+		Expect(err).Should(BeNil())
+		route := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1alpha2",
+			"kind":       "HTTPRoute",
+			"metadata":   map[string]interface{}{"name": "route", "namespace": "default"},
+			"spec": map[string]interface{}{
+				"hostnames": []interface{}{"route.example.com"},
+				"rules": []interface{}{map[string]interface{}{
+					"matches":     []interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "Exact", "value": "/healthz"}}},
+					"backendRefs": []interface{}{map[string]interface{}{"name": "web", "port": int64(80)}},
+				}, map[string]interface{}{
+					"backendRefs": []interface{}{map[string]interface{}{"name": "web", "port": int64(80)}},
+				}},
+			},
+		}}
+		rules, err := collectRoutingRules([]Resource{
+			{Component: "web", Cluster: "local", Object: ingress},
+			{Component: "web", Cluster: "local", Object: legacy},
+			{Component: "web", Cluster: "local", Object: route},
+			{Component: "web", Cluster: "local", Object: &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Service"}}},
+		})
+		Expect(err).Should(BeNil())
+		row := func(kind, name, host, path, pathType, service, port string) RoutingRule {
+			return RoutingRule{Kind: kind, Name: name, Namespace: "default", Cluster: "local", Component: "web",
+				Host: host, Path: path, PathType: pathType, BackendService: service, BackendPort: port}
+		}
+		Expect(rules).Should(Equal([]RoutingRule{
+			row("Ingress", "web", "web.example.com", "/api", "Prefix", "api", "8080"),
+			row("Ingress", "web", "web.example.com", "/", "Prefix", "web", "http"),
+			row("Ingress", "legacy", "", "", "", "fallback", "80"),
+			row("HTTPRoute", "route", "route.example.com", "/healthz", "Exact", "web", "80"),
+			row("HTTPRoute", "route", "route.example.com", "/", "PathPrefix", "web", "80"),
+		}))
+	})
+
+	It("Test collect helm values", func() {
+		Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "helm-values", Namespace: "default"},
+			Data: map[string]string{
+				"values.yaml": "image:\n  repository: nginx\n  tag: \"1.20\"\nreplicaCount: 1\n",
+				"tag":         "1.21",
+			},
+		})).Should(Succeed())
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "helm-values", Namespace: "default"},
+			Data:       map[string][]byte{"values.yaml": []byte("auth:\n  password: secret\n  users: [admin]\n")},
+		})).Should(Succeed())
+		hr := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "helm.toolkit.fluxcd.io/v2beta1",
+			"kind":       "HelmRelease",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+			"spec": map[string]interface{}{
+				"valuesFrom": []interface{}{
+					map[string]interface{}{"kind": "ConfigMap", "name": "helm-values"},
+					map[string]interface{}{"kind": "ConfigMap", "name": "helm-values", "valuesKey": "tag", "targetPath": "image.tag"},
+					map[string]interface{}{"kind": "Secret", "name": "helm-values"},
+					map[string]interface{}{"kind": "Secret", "name": "not-exist", "optional": true},
+				},
+				"values": map[string]interface{}{"replicaCount": int64(3)},
+			},
+		}}
+		values, err := collectHelmValues(k8sClient, hr, "local")
+		Expect(err).Should(BeNil())
+		Expect(values.Values).Should(Equal(map[string]interface{}{
+			"image":        map[string]interface{}{"repository": "nginx", "tag": "1.21"},
+			"auth":         map[string]interface{}{"password": "<redacted>", "users": []interface{}{"<redacted>"}},
+			"replicaCount": int64(3),
+		}))
+		Expect(values.Sources).Should(Equal([]HelmValuesSource{
+			{Kind: "ConfigMap", Name: "helm-values", ValuesKey: "values.yaml"},
+			{Kind: "ConfigMap", Name: "helm-values", ValuesKey: "tag", TargetPath: "image.tag"},
+			{Kind: "Secret", Name: "helm-values", ValuesKey: "values.yaml", Redacted: true},
+			{Kind: "Secret", Name: "not-exist", ValuesKey: "values.yaml", Missing: true, Redacted: true},
+		}))
+
+		Expect(unstructured.SetNestedSlice(hr.Object, []interface{}{
+			map[string]interface{}{"kind": "ConfigMap", "name": "not-exist"},
+		}, "spec", "valuesFrom")).Should(Succeed())
+		_, err = collectHelmValues(k8sClient, hr, "local")
+		Expect(err).ShouldNot(BeNil())
+		Expect(err.Error()).Should(ContainSubstring("not found"))
+	})
+
+	It("Test collect jobs", func() {
+		start, end := metav1.NewTime(time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)), metav1.NewTime(time.Date(2021, 11, 1, 0, 5, 0, 0, time.UTC))
+		suspend := true
+		job, err := util.Object2Unstructured(&batchv1.Job{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+			ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+			Spec:       batchv1.JobSpec{Completions: pointer.Int32(2)},
+			Status: batchv1.JobStatus{
+				Succeeded: 2, Failed: 1, StartTime: &start, CompletionTime: &end,
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			},
+		})
+		Expect(err).Should(BeNil())
+		running, err := util.Object2Unstructured(&batchv1.Job{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+			ObjectMeta: metav1.ObjectMeta{Name: "import", Namespace: "default"},
+			Status:     batchv1.JobStatus{Active: 1, StartTime: &start},
+		})
+		Expect(err).Should(BeNil())
+		cronJob, err := util.Object2Unstructured(&batchv1beta1.CronJob{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1beta1", Kind: "CronJob"},
+			ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "default"},
+			Spec:       batchv1beta1.CronJobSpec{Schedule: "0 * * * *", Suspend: &suspend},
+			Status:     batchv1beta1.CronJobStatus{LastScheduleTime: &start, LastSuccessfulTime: &end},
+		})
+		Expect(err).Should(BeNil())
+		jobs, err := collectJobs([]Resource{
+			{Component: "pipeline", Object: job},
+			{Component: "pipeline", Cluster: "worker", Object: running},
+			{Component: "report", Object: cronJob},
+			{Component: "pipeline", Object: &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Service"}}},
+		})
+		Expect(err).Should(BeNil())
+		Expect(jobs).Should(HaveLen(3))
+		Expect(jobs[0]).Should(Equal(JobSummary{
+			Kind: "Job", Name: "migrate", Namespace: "default", Cluster: "local", Component: "pipeline", Status: JobStatusComplete,
+			Completions: pointer.Int32(2), Succeeded: 2, Failed: 1, StartTime: &start, CompletionTime: &end,
+		}))
+		Expect(jobs[1].Cluster).Should(Equal("worker"))
+		Expect(jobs[1].Status).Should(Equal(JobStatusRunning))
+		Expect(jobs[1].Active).Should(Equal(int32(1)))
+		Expect(jobs[2]).Should(Equal(JobSummary{
+			Kind: "CronJob", Name: "report", Namespace: "default", Cluster: "local", Component: "report", Status: JobStatusSuspended,
+			Schedule: "0 * * * *", LastScheduleTime: &start, LastSuccessfulTime: &end,
+		}))
+	})
+
+	It("Test collect probes", func() {
+		deploy, err := util.Object2Unstructured(&v1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: v1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						InitContainers: []corev1.Container{{Name: "init", Image: "busybox"}},
+						Containers: []corev1.Container{{
+							Name:  "main",
+							Image: "nginx",
+							LivenessProbe: &corev1.Probe{
+								Handler:       corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromString("http")}},
+								PeriodSeconds: 5,
+							},
+							ReadinessProbe: &corev1.Probe{
+								Handler:             corev1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(8080)}},
+								InitialDelaySeconds: 3,
+								FailureThreshold:    5,
+							},
+							StartupProbe: &corev1.Probe{
+								Handler: corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"cat", "/tmp/ready"}}},
+							},
+						}, {Name: "sidecar", Image: "envoy"}},
+					},
+				},
+			},
+		})
+		Expect(err).Should(BeNil())
+		cronJob, err := util.Object2Unstructured(&batchv1.CronJob{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "CronJob"},
+			ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "default"},
+			Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:          "report",
+					LivenessProbe: &corev1.Probe{Handler: corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"true"}}}},
+				}}},
+			}}}},
+		})
+		Expect(err).Should(BeNil())
+		probes := collectProbes([]Resource{
+			{Component: "web", Object: deploy},
+			{Component: "report", Cluster: "worker", Object: cronJob},
+			{Component: "web", Object: &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Service"}}},
+		})
+		Expect(probes).Should(HaveLen(2))
+		Expect(probes[0]).Should(Equal(ContainerProbes{
+			Component: "web", Cluster: "local", Kind: "Deployment", Name: "web", Namespace: "default", Container: "main",
+			Liveness: &ProbeConfig{
+				Type: ProbeTypeHTTPGet, Scheme: "HTTP", Path: "/healthz", Port: "http",
+				TimeoutSeconds: 1, PeriodSeconds: 5, SuccessThreshold: 1, FailureThreshold: 3,
+			},
+			Readiness: &ProbeConfig{
+				Type: ProbeTypeTCPSocket, Port: "8080",
+				InitialDelaySeconds: 3, TimeoutSeconds: 1, PeriodSeconds: 10, SuccessThreshold: 1, FailureThreshold: 5,
+			},
+			Startup: &ProbeConfig{
+				Type: ProbeTypeExec, Command: []string{"cat", "/tmp/ready"},
+				TimeoutSeconds: 1, PeriodSeconds: 10, SuccessThreshold: 1, FailureThreshold: 3,
+			},
+		}))
+		Expect(probes[1].Cluster).Should(Equal("worker"))
+		Expect(probes[1].Kind).Should(Equal("CronJob"))
+		Expect(probes[1].Liveness.Type).Should(Equal(ProbeTypeExec))
+		Expect(probes[1].Readiness).Should(BeNil())
+	})
+
+	It("Test collect secrets", func() {
+		namespace := "test-collect-secrets"
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).Should(BeNil())
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: namespace},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("db-secret-value"), "user": []byte("admin")},
+		})).Should(BeNil())
+		managed, err := util.Object2Unstructured(&corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: namespace},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": []byte("managed-secret-value"), "tls.key": []byte("managed-secret-value")},
+		})
+		Expect(err).Should(BeNil())
+		deploy, err := util.Object2Unstructured(&v1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+			Spec: v1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				Containers: []corev1.Container{{
+					Name:    "main",
+					EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "db"}}}},
+				}},
+				Volumes: []corev1.Volume{{Name: "tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "tls"}}}},
+			}}},
+		})
+		Expect(err).Should(BeNil())
+		secrets, err := collectSecrets(k8sClient, []Resource{{Component: "web", Object: managed}, {Component: "web", Object: deploy}})
+		Expect(err).Should(BeNil())
+		Expect(secrets).Should(Equal([]AppSecret{
+			{Name: "db", Namespace: namespace, Cluster: "local", Type: corev1.SecretTypeOpaque, Keys: []string{"password", "user"},
+				Exists: true, ReferencedBy: []string{"Deployment/web"}},
+			{Name: "registry", Namespace: namespace, Cluster: "local", ReferencedBy: []string{"Deployment/web"}},
+			{Name: "tls", Namespace: namespace, Cluster: "local", Type: corev1.SecretTypeTLS, Keys: []string{"tls.crt", "tls.key"},
+				Managed: true, Exists: true, ReferencedBy: []string{"Deployment/web"}},
+		}))
+		v, err := value.NewValue("", nil, "")
+		Expect(err).Should(BeNil())
+		Expect(v.FillObject(secrets, "list")).Should(BeNil())
+		output, err := v.String()
+		Expect(err).Should(BeNil())
+		Expect(output).ShouldNot(ContainSubstring("secret-value"))
+	})
+
+	It("Test collect apply status", func() {
+		applied, scaled := metav1.NewTime(time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)), metav1.NewTime(time.Date(2021, 11, 1, 0, 5, 0, 0, time.UTC))
+		reported := metav1.NewTime(time.Date(2021, 11, 1, 0, 10, 0, 0, time.UTC))
+		deploy, err := util.Object2Unstructured(&v1.Deployment{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 3, ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubevela", Operation: metav1.ManagedFieldsOperationUpdate, Time: &applied, FieldsType: "FieldsV1",
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{},"f:spec":{}}`)}},
+				{Manager: "kube-controller-manager", Operation: metav1.ManagedFieldsOperationUpdate, Time: &scaled, FieldsType: "FieldsV1",
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:status":{}}`)}, Subresource: "status"},
+				{Manager: "kube-controller-manager", Operation: metav1.ManagedFieldsOperationUpdate, Time: &reported, FieldsType: "FieldsV1",
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:status":{}}`)}},
+			}},
+			Status: v1.DeploymentStatus{ObservedGeneration: 2},
+		})
+		Expect(err).Should(BeNil())
+		cm, err := util.Object2Unstructured(&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: "default"},
+		})
+		Expect(err).Should(BeNil())
+		statuses := collectApplyStatus([]Resource{{Component: "web", Object: deploy}, {Component: "web", Cluster: "worker", Object: cm}})
+		Expect(statuses).Should(HaveLen(2))
+		Expect(statuses[0].Kind).Should(Equal("Deployment"))
+		Expect(statuses[0].Cluster).Should(Equal("local"))
+		Expect(statuses[0].Generation).Should(Equal(int64(3)))
+		Expect(statuses[0].LastAppliedTime).ShouldNot(BeNil())
+		Expect(statuses[0].LastAppliedTime.Equal(&applied)).Should(BeTrue())
+		Expect(*statuses[0].ObservedGeneration).Should(Equal(int64(2)))
+		Expect(*statuses[0].Synced).Should(BeFalse())
+		Expect(statuses[1].Cluster).Should(Equal("worker"))
+		Expect(statuses[1].LastAppliedTime).Should(BeNil())
+		Expect(statuses[1].ObservedGeneration).Should(BeNil())
+		Expect(statuses[1].Synced).Should(BeNil())
+	})
+
+	It("Test collect distribution", func() {
+		newDeployment := func(name string) *unstructured.Unstructured {
+			deploy := &v1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: v1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+						Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "nginx"}}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deploy)).Should(Succeed())
+			obj, err := util.Object2Unstructured(deploy)
+			Expect(err).Should(BeNil())
+			return obj
+		}
+		web, worker := newDeployment("distribution-web"), newDeployment("distribution-worker")
+		for i, ready := range []corev1.ConditionStatus{corev1.ConditionTrue, corev1.ConditionFalse} {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("distribution-web-%d", i), Namespace: "default", Labels: map[string]string{"app": "distribution-web"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "nginx"}}},
+			}
+			Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}
+			Expect(k8sClient.Status().Update(ctx, pod)).Should(Succeed())
+		}
+		service, err := util.Object2Unstructured(&corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "distribution-web", Namespace: "default"},
+		})
+		Expect(err).Should(BeNil())
+		distribution, err := collectDistribution(k8sClient, []Resource{
+			{Component: "web", Object: web},
+			{Component: "web", Object: service},
+			{Component: "worker", Object: worker},
+			{Object: web},
+		})
+		Expect(err).Should(BeNil())
+		Expect(distribution).Should(Equal(map[string]map[string]ReplicaCount{
+			"web":    {"local": {ReadyReplicas: 1, TotalReplicas: 2}},
+			"worker": {"local": {ReadyReplicas: 0, TotalReplicas: 0}},
+		}))
+	})
+
+	It("Test collect resource drift", func() {
+		prd := provider{cli: k8sClient}
+		collectDrift := func(name string) *value.Value {
+			v, err := value.NewValue(fmt.Sprintf(`value: {
+	apiVersion: "apps/v1"
+	kind: "Deployment"
+	metadata: {
+		namespace: "default"
+		name: "%s"
+	}
+}
+cluster: ""`, name), nil, "")
+			Expect(err).Should(BeNil())
+			Expect(prd.CollectResourceDrift(nil, v, nil)).Should(BeNil())
+			return v
+		}
+		createWithLastApplied := func(deploy, applied *v1.Deployment) {
+			applied.SetAnnotations(nil)
+			lastApplied, err := json.Marshal(applied)
+			Expect(err).Should(BeNil())
+			deploy.SetAnnotations(map[string]string{
+				oam.AnnotationLastAppliedConfig: string(lastApplied),
+				oam.AnnotationAppRollout:        "true",
+			})
+			Expect(k8sClient.Create(ctx, deploy)).Should(BeNil())
+		}
+
+		By("the apiserver defaulted fields are not drift")
+		deploy := baseDeploy.DeepCopy()
+		deploy.SetName("drift-none")
+		createWithLastApplied(deploy, deploy.DeepCopy())
+		v := collectDrift("drift-none")
+		drift := new(ResourceDrift)
+		Expect(v.UnmarshalTo(&struct {
+			Drift *ResourceDrift `json:"drift"`
+		}{Drift: drift})).Should(BeNil())
+		Expect(drift.Drifted).Should(BeFalse())
+		Expect(drift.Added).Should(BeEmpty())
+		Expect(drift.Removed).Should(BeEmpty())
+		Expect(drift.Changed).Should(BeEmpty())
+
+		By("changed, removed and added fields are reported")
+		deploy = baseDeploy.DeepCopy()
+		deploy.SetName("drift-changed")
+		applied := deploy.DeepCopy()
+		applied.Labels["team"] = "payments"
+		applied.Spec.Template.Spec.Containers[0].Image = "crccheck/hello-world:v1"
+		deploy.Labels["owner"] = "ops"
+		deploy.Spec.Replicas = pointer.Int32Ptr(3)
+		createWithLastApplied(deploy, applied)
+		v = collectDrift("drift-changed")
+		drift = new(ResourceDrift)
+		Expect(v.UnmarshalTo(&struct {
+			Drift *ResourceDrift `json:"drift"`
+		}{Drift: drift})).Should(BeNil())
+		Expect(drift.Drifted).Should(BeTrue())
+		Expect(drift.Changed).Should(HaveLen(2))
+		Expect(drift.Changed[0].Path).Should(Equal("spec.replicas"))
+		Expect(drift.Changed[0].Applied).Should(BeEquivalentTo(2))
+		Expect(drift.Changed[0].Live).Should(BeEquivalentTo(3))
+		Expect(drift.Changed[1].Path).Should(Equal("spec.template.spec.containers.0.image"))
+		Expect(drift.Changed[1].Applied).Should(Equal("crccheck/hello-world:v1"))
+		Expect(drift.Changed[1].Live).Should(Equal("crccheck/hello-world"))
+		Expect(drift.Removed).Should(HaveLen(1))
+		Expect(drift.Removed[0].Path).Should(Equal("metadata.labels.team"))
+		Expect(drift.Removed[0].Applied).Should(Equal("payments"))
+		Expect(drift.Added).Should(HaveLen(1))
+		Expect(drift.Added[0].Path).Should(Equal("metadata.labels.owner"))
+		Expect(drift.Added[0].Live).Should(Equal("ops"))
+
+		By("a resource without the last applied configuration fills err")
+		deploy = baseDeploy.DeepCopy()
+		deploy.SetName("drift-unmanaged")
+		Expect(k8sClient.Create(ctx, deploy)).Should(BeNil())
+		v = collectDrift("drift-unmanaged")
+		errMsg, err := v.GetString("err")
+		Expect(err).Should(BeNil())
+		Expect(errMsg).Should(ContainSubstring("fail to find the last applied configuration"))
+	})
+
+	It("Test wait for the endpoints to be ready", func() {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "wait-lb", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, service)).Should(BeNil())
+		app := &v1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "wait-endpoints-app", Namespace: "default"},
+			Spec: v1beta1.ApplicationSpec{Components: []common.ApplicationComponent{{
+				Name:       "web",
+				Type:       "webservice",
+				Properties: util.Object2RawExtension(map[string]string{"image": "busybox"}),
+			}}},
+		}
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		app.Status.AppliedResources = []common.ClusterObjectReference{{ObjectReference: corev1.ObjectReference{
+			APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "wait-lb",
+		}}}
+		Expect(k8sClient.Status().Update(ctx, app)).Should(BeNil())
+
+		Expect(endpointsWaitTimeout(1)).Should(Equal(time.Second))
+		Expect(endpointsWaitTimeout(maxEndpointsWaitSeconds)).Should(Equal(maxEndpointsWaitSeconds * time.Second))
+		Expect(endpointsWaitTimeout(3600)).Should(Equal(maxEndpointsWaitSeconds * time.Second))
+
+		endpointsPollInterval = 100 * time.Millisecond
+		pr := &provider{cli: k8sClient}
+		collect := func() *value.Value {
+			v, err := value.NewValue(`app: {
+	name: "wait-endpoints-app"
+	namespace: "default"
+}
+waitSeconds: 1`, nil, "")
+			Expect(err).Should(BeNil())
+			Expect(pr.GeneratorServiceEndpoints(nil, v, nil)).Should(BeNil())
+			return v
+		}
+		v := collect()
+		ready, err := v.GetBool("ready")
+		Expect(err).Should(BeNil())
+		Expect(ready).Should(BeFalse())
+		endValue, err := v.Field("list")
+		Expect(err).Should(BeNil())
+		var endpoints []ServiceEndpoint
+		Expect(endValue.Decode(&endpoints)).Should(BeNil())
+		Expect(len(endpoints)).Should(Equal(1))
+		Expect(endpoints[0].Pending).Should(BeTrue())
+
+		service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.10.10.11"}}
+		Expect(k8sClient.Status().Update(ctx, service)).Should(BeNil())
+		v = collect()
+		ready, err = v.GetBool("ready")
+		Expect(err).Should(BeNil())
+		Expect(ready).Should(BeTrue())
+	})
+
+	It("Test skip the endpoints of unhealthy components", func() {
+		for _, comp := range []string{"healthy-web", "unhealthy-web"} {
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: comp, Namespace: "default", Labels: map[string]string{oam.LabelAppComponent: comp}},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort, Ports: []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}}},
 			}
-			deploy.Spec.Template.ObjectMeta.SetLabels(map[string]string{
-				oam.LabelAppComponent: "test",
-			})
-			Expect(k8sClient.Create(ctx, deploy)).Should(BeNil())
-			for i := 1; i <= 5; i++ {
-				pod := basePod.DeepCopy()
-				pod.SetName(fmt.Sprintf("test-collect-pod-%d", i))
-				pod.SetLabels(map[string]string{
-					oam.LabelAppComponent: "test",
-				})
-				Expect(k8sClient.Create(ctx, pod)).Should(BeNil())
+			Expect(k8sClient.Create(ctx, service)).Should(BeNil())
+		}
+		app := &v1beta1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "healthy-endpoints-app", Namespace: "default"},
+			Spec: v1beta1.ApplicationSpec{Components: []common.ApplicationComponent{
+				{Name: "healthy-web", Type: "webservice"},
+				{Name: "unhealthy-web", Type: "webservice"},
+			}},
+		}
+		Expect(k8sClient.Create(ctx, app)).Should(BeNil())
+		app.Status.Services = []common.ApplicationComponentStatus{
+			{Name: "healthy-web", Healthy: true},
+			{Name: "unhealthy-web", Healthy: false},
+		}
+		app.Status.AppliedResources = []common.ClusterObjectReference{
+			{ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "healthy-web"}},
+			{ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "unhealthy-web"}},
+		}
+		Expect(k8sClient.Status().Update(ctx, app)).Should(BeNil())
+
+		pr := &provider{cli: k8sClient}
+		v, err := value.NewValue(`app: {
+	name: "healthy-endpoints-app"
+	namespace: "default"
+}
+healthyOnly: true`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(pr.GeneratorServiceEndpoints(nil, v, nil)).Should(BeNil())
+		skipped, err := v.GetInt64("skippedUnhealthy")
+		Expect(err).Should(BeNil())
+		Expect(skipped).Should(Equal(int64(1)))
+		endValue, err := v.Field("list")
+		Expect(err).Should(BeNil())
+		var endpoints []ServiceEndpoint
+		Expect(endValue.Decode(&endpoints)).Should(BeNil())
+		Expect(len(endpoints)).Should(Equal(1))
+		Expect(endpoints[0].Ref.Name).Should(Equal("healthy-web"))
+		Expect(endpoints[0].Component).Should(Equal("healthy-web"))
+
+		v, err = value.NewValue(`app: {
+	name: "healthy-endpoints-app"
+	namespace: "default"
+}
+groupByComponent: true`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(pr.GeneratorServiceEndpoints(nil, v, nil)).Should(BeNil())
+		groupsValue, err := v.LookupValue("groups")
+		Expect(err).Should(BeNil())
+		var groups map[string][]ServiceEndpoint
+		Expect(groupsValue.UnmarshalTo(&groups)).Should(BeNil())
+		Expect(groups).Should(HaveLen(2))
+		Expect(groups["unhealthy-web"]).Should(HaveLen(1))
+		Expect(groups["unhealthy-web"][0].Ref.Name).Should(Equal("unhealthy-web"))
+
+		Expect(getComponentsHealth(common.AppStatus{Services: []common.ApplicationComponentStatus{
+			{Name: "web", Env: "staging", Healthy: false},
+			{Name: "web", Env: "prod", Healthy: true},
+		}})).Should(Equal(map[string]bool{"web": false}))
+	})
+
+	It("Test probe service endpoints", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
 			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).Should(BeNil())
+		port, err := strconv.Atoi(serverURL.Port())
+		Expect(err).Should(BeNil())
+		httpProtocol, tcpProtocol := "http", "tcp"
+		endpoints := []ServiceEndpoint{
+			{Endpoint: Endpoint{AppProtocol: &httpProtocol, Host: serverURL.Hostname(), Port: int32(port)}},
+			{Endpoint: Endpoint{AppProtocol: &httpProtocol, Host: "127.0.0.1", Port: 1}},
+			{Endpoint: Endpoint{AppProtocol: &tcpProtocol, Host: serverURL.Hostname(), Port: int32(port)}},
+			{Endpoint: Endpoint{AppProtocol: &httpProtocol, Host: serverURL.Hostname(), Port: int32(port)}, Pending: true},
+		}
+		probeEndpoints(context.Background(), endpoints)
+		Expect(endpoints[0].Probe).Should(Equal(&EndpointProbe{Reachable: true, StatusCode: http.StatusNoContent}))
+		Expect(endpoints[1].Probe).ShouldNot(BeNil())
+		Expect(endpoints[1].Probe.Reachable).Should(BeFalse())
+		Expect(endpoints[1].Probe.Message).ShouldNot(BeEmpty())
+		Expect(endpoints[2].Probe).Should(BeNil())
+		Expect(endpoints[3].Probe).Should(BeNil())
+	})
 
-			prd := provider{cli: k8sClient}
-			unstructuredDeploy, err := util.Object2Unstructured(deploy)
-			Expect(err).Should(BeNil())
-			unstructuredDeploy.SetGroupVersionKind((&corev1.ObjectReference{
-				APIVersion: "apps/v1",
-				Kind:       "Deployment",
-			}).GroupVersionKind())
+	It("Test resolve the backends of the ingress endpoints", func() {
+		ready, notReady := true, false
+		for name, isReady := range map[string]*bool{"ingress-ready": &ready, "ingress-not-ready": &notReady} {
+			Expect(k8sClient.Create(ctx, &discoveryv1.EndpointSlice{
+				ObjectMeta:  metav1.ObjectMeta{Name: name + "-abc", Namespace: "default", Labels: map[string]string{discoveryv1.LabelServiceName: name}},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.1.1"}, Conditions: discoveryv1.EndpointConditions{Ready: isReady}}},
+				Ports:       []discoveryv1.EndpointPort{{Port: pointer.Int32(80)}},
+			})).Should(BeNil())
+		}
+		ingress := networkv1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend-health", Namespace: "default"},
+			Spec: networkv1beta1.IngressSpec{Rules: []networkv1beta1.IngressRule{{
+				Host: "ingress.domain.backend",
+				IngressRuleValue: networkv1beta1.IngressRuleValue{HTTP: &networkv1beta1.HTTPIngressRuleValue{
+					Paths: []networkv1beta1.HTTPIngressPath{
+						{Path: "/ready", Backend: networkv1beta1.IngressBackend{ServiceName: "ingress-ready", ServicePort: intstr.FromInt(80)}},
+						{Path: "/not-ready", Backend: networkv1beta1.IngressBackend{ServiceName: "ingress-not-ready", ServicePort: intstr.FromInt(80)}},
+						{Path: "/missing", Backend: networkv1beta1.IngressBackend{ServiceName: "ingress-missing", ServicePort: intstr.FromInt(80)}},
+						{Path: "/resource", Backend: networkv1beta1.IngressBackend{Resource: &corev1.TypedLocalObjectReference{Kind: "Bucket", Name: "static"}}},
+					},
+				}},
+			}}},
+		}
+		endpoints := generatorFromIngress(ingress, nil)
+		resolveIngressBackends(k8sClient, endpoints, "")
+		Expect(endpoints).Should(HaveLen(4))
+		Expect(endpoints[0].BackendReady).Should(Equal(pointer.Bool(true)))
+		Expect(endpoints[1].BackendReady).Should(Equal(pointer.Bool(false)))
+		// the service without any EndpointSlice has no ready endpoint
+		Expect(endpoints[2].BackendReady).Should(Equal(pointer.Bool(false)))
+		Expect(endpoints[3].BackendReady).Should(BeNil())
+	})
 
-			deployJson, err := json.Marshal(unstructuredDeploy)
-			Expect(err).Should(BeNil())
-			opt := fmt.Sprintf(`value: %s
-cluster: ""`, deployJson)
-			v, err := value.NewValue(opt, nil, "")
-			Expect(err).Should(BeNil())
-			Expect(prd.CollectPods(nil, v, nil)).Should(BeNil())
+	It("Test collect service backends", func() {
+		ready, notReady := true, false
+		slice := &discoveryv1.EndpointSlice{
+			ObjectMeta:  metav1.ObjectMeta{Name: "backend-web-abc", Namespace: "default", Labels: map[string]string{discoveryv1.LabelServiceName: "backend-web"}},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{{
+				Addresses:  []string{"10.0.0.1"},
+				Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "web-1", Namespace: "default"},
+			}, {
+				Addresses:  []string{"10.0.0.2"},
+				Conditions: discoveryv1.EndpointConditions{Ready: &notReady},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "web-2", Namespace: "default"},
+			}},
+			Ports: []discoveryv1.EndpointPort{{Name: pointer.String("http"), Port: pointer.Int32(8080)}},
+		}
+		Expect(k8sClient.Create(ctx, slice)).Should(BeNil())
 
-			podList := new(PodList)
-			Expect(v.UnmarshalTo(podList)).Should(BeNil())
-			Expect(len(podList.List)).Should(Equal(5))
-			for _, pod := range podList.List {
-				Expect(pod.GroupVersionKind()).Should(Equal((&corev1.ObjectReference{
-					APIVersion: "v1",
-					Kind:       "Pod",
-				}).GroupVersionKind()))
+		pr := &provider{cli: k8sClient}
+		v, err := value.NewValue(`
+value: {
+	name: "backend-web"
+	namespace: "default"
+}
+cluster: ""`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(pr.CollectServiceBackends(nil, v, nil)).Should(BeNil())
+		var backends []ServiceBackend
+		backendsValue, err := v.LookupValue("backends")
+		Expect(err).Should(BeNil())
+		Expect(backendsValue.UnmarshalTo(&backends)).Should(BeNil())
+		Expect(backends).Should(Equal([]ServiceBackend{{IP: "10.0.0.1", Port: 8080, PortName: "http", Pod: "web-1"}}))
+	})
+
+	It("Test generate the endpoints of the headless service", func() {
+		ready, notReady := true, false
+		Expect(k8sClient.Create(ctx, &discoveryv1.EndpointSlice{
+			ObjectMeta:  metav1.ObjectMeta{Name: "headless-db-abc", Namespace: "default", Labels: map[string]string{discoveryv1.LabelServiceName: "headless-db"}},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{{
+				Addresses:  []string{"10.0.2.1"},
+				Hostname:   pointer.String("db-0"),
+				Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "db-0", Namespace: "default"},
+			}, {
+				Addresses:  []string{"10.0.2.2"},
+				Hostname:   pointer.String("db-1"),
+				Conditions: discoveryv1.EndpointConditions{Ready: &notReady},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "db-1", Namespace: "default"},
+			}, {
+				Addresses: []string{"10.0.2.3"},
+			}},
+			Ports: []discoveryv1.EndpointPort{{Name: pointer.String("mysql"), Port: pointer.Int32(3306)}},
+		})).Should(BeNil())
+
+		service := corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "headless-db", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: corev1.ClusterIPNone},
+		}
+		Expect(isHeadlessService(service)).Should(BeTrue())
+		Expect(generatorFromService(service)).Should(BeEmpty())
+		endpoints, err := generatorFromHeadlessService(k8sClient, service, "")
+		Expect(err).Should(BeNil())
+		Expect(endpoints).Should(Equal([]ServiceEndpoint{{
+			Endpoint: Endpoint{Protocol: corev1.ProtocolTCP, Host: "db-0.headless-db.default.svc", Port: 3306, IPFamily: IPFamilyHostname},
+			Ref:      corev1.ObjectReference{APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "headless-db"},
+			Headless: true,
+			Pod:      "db-0",
+		}}))
+
+		service.Spec.ClusterIP = "10.96.0.10"
+		Expect(isHeadlessService(service)).Should(BeFalse())
+	})
+
+	It("Test collect pvcs of statefulset", func() {
+		newPVC := func(name string) *corev1.PersistentVolumeClaim {
+			return &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					StorageClassName: pointer.String("standard"),
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+					},
+				},
 			}
-		})
+		}
+		bound := newPVC("data-db-0")
+		bound.Spec.VolumeName = "pv-data-db-0"
+		Expect(k8sClient.Create(ctx, bound)).Should(BeNil())
+		bound.Status = corev1.PersistentVolumeClaimStatus{
+			Phase:    corev1.ClaimBound,
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")},
+		}
+		Expect(k8sClient.Status().Update(ctx, bound)).Should(BeNil())
+		pending := newPVC("shared-cache")
+		Expect(k8sClient.Create(ctx, pending)).Should(BeNil())
+		pending.Status.Phase = corev1.ClaimPending
+		Expect(k8sClient.Status().Update(ctx, pending)).Should(BeNil())
+		pod := basePod.DeepCopy()
+		pod.SetName("db-0")
+		pod.SetLabels(map[string]string{"app": "db"})
+		pod.Spec.Volumes = []corev1.Volume{{
+			Name:         "cache",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "shared-cache"}},
+		}}
+		Expect(k8sClient.Create(ctx, pod)).Should(BeNil())
 
-		It("Test collect pod with incomplete parameter", func() {
-			emptyOpt := ""
-			prd := provider{cli: k8sClient}
-			v, err := value.NewValue(emptyOpt, nil, "")
-			Expect(err).Should(BeNil())
-			err = prd.CollectPods(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
-			Expect(err.Error()).Should(Equal("var(path=value) not exist"))
+		pr := &provider{cli: k8sClient}
+		v, err := value.NewValue(`
+value: {
+	apiVersion: "apps/v1"
+	kind: "StatefulSet"
+	metadata: {
+		name: "db"
+		namespace: "default"
+	}
+	spec: {
+		replicas: 2
+		selector: matchLabels: app: "db"
+		volumeClaimTemplates: [{metadata: name: "data"}]
+	}
+}
+cluster: ""`, nil, "")
+		Expect(err).Should(BeNil())
+		Expect(pr.CollectPVCs(nil, v, nil)).Should(BeNil())
+		var pvcs []PVCStatus
+		pvcsValue, err := v.LookupValue("list")
+		Expect(err).Should(BeNil())
+		Expect(pvcsValue.UnmarshalTo(&pvcs)).Should(BeNil())
+		// data-db-1 is not created yet and skipped
+		Expect(pvcs).Should(Equal([]PVCStatus{
+			{Name: "data-db-0", Phase: corev1.ClaimBound, Capacity: "2Gi", VolumeName: "pv-data-db-0", StorageClass: "standard"},
+			{Name: "shared-cache", Phase: corev1.ClaimPending, StorageClass: "standard"},
+		}))
+	})
 
-			optWithoutCluster := `value: {}`
-			v, err = value.NewValue(optWithoutCluster, nil, "")
-			Expect(err).Should(BeNil())
-			err = prd.CollectPods(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
-			Expect(err.Error()).Should(Equal("var(path=cluster) not exist"))
+	It("Test collect app readiness", func() {
+		Expect(collectAppReadiness(common.AppStatus{})).Should(Equal(&AppReadiness{Components: []ComponentReadiness{}}))
 
-			optWithWrongValue := `value: {test: 1}
-cluster: "test"`
-			v, err = value.NewValue(optWithWrongValue, nil, "")
-			Expect(err).Should(BeNil())
-			err = prd.CollectPods(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
-		})
+		readiness := collectAppReadiness(common.AppStatus{Services: []common.ApplicationComponentStatus{
+			{Name: "web", Env: "dev", Healthy: true},
+			{Name: "web", Env: "prod", Healthy: false, Message: "1/3 replicas are ready"},
+			{Name: "worker", Healthy: true, Traits: []common.ApplicationTraitStatus{{Type: "scaler", Healthy: true}}},
+			{Name: "gateway", Healthy: true, Traits: []common.ApplicationTraitStatus{{Type: "ingress", Healthy: false, Message: "no address"}}},
+		}})
+		Expect(readiness.Score).Should(Equal(50))
+		Expect(readiness.Ready).Should(Equal(2))
+		Expect(readiness.Total).Should(Equal(4))
+		Expect(readiness.Components).Should(Equal([]ComponentReadiness{
+			{Name: "web", Score: 50, Ready: 1, Total: 2, Message: "1/3 replicas are ready"},
+			{Name: "worker", Score: 100, Ready: 1, Total: 1},
+			{Name: "gateway", Score: 0, Ready: 0, Total: 1, Message: "no address"},
+		}))
 	})
 
-	Context("Test search event from k8s object", func() {
-		It("Test search event with incomplete parameter", func() {
-			emptyOpt := ""
-			prd := provider{cli: k8sClient}
-			v, err := value.NewValue(emptyOpt, nil, "")
-			Expect(err).Should(BeNil())
-			err = prd.SearchEvents(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
-			Expect(err.Error()).Should(Equal("var(path=value) not exist"))
+	It("Test collect workflow status", func() {
+		Expect(collectWorkflowStatus(common.AppStatus{})).Should(Equal(&WorkflowStatus{Phase: WorkflowPhaseInitializing, Steps: []WorkflowStep{}}))
 
-			optWithoutCluster := `value: {}`
-			v, err = value.NewValue(optWithoutCluster, nil, "")
-			Expect(err).Should(BeNil())
-			err = prd.SearchEvents(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
-			Expect(err.Error()).Should(Equal("var(path=cluster) not exist"))
+		status := common.AppStatus{Workflow: &common.WorkflowStatus{
+			AppRevision: "app-v1",
+			Mode:        common.WorkflowModeStep,
+			Steps: []common.WorkflowStepStatus{{
+				Name:  "deploy",
+				Type:  "deploy2env",
+				Phase: common.WorkflowStepPhaseSucceeded,
+				SubSteps: &common.SubStepsStatus{Steps: []common.WorkflowSubStepStatus{{
+					Name: "deploy-web", Type: "apply-component", Phase: common.WorkflowStepPhaseSucceeded,
+				}}},
+			}, {
+				Name: "approve", Type: "suspend", Phase: common.WorkflowStepPhaseRunning,
+			}},
+			Suspend: true,
+		}}
+		workflow := collectWorkflowStatus(status)
+		Expect(workflow.Phase).Should(Equal(WorkflowPhaseSuspended))
+		Expect(workflow.AppRevision).Should(Equal("app-v1"))
+		Expect(workflow.Steps).Should(HaveLen(2))
+		Expect(workflow.Steps[0].SubSteps).Should(Equal([]WorkflowStep{{Name: "deploy-web", Type: "apply-component", Phase: common.WorkflowStepPhaseSucceeded}}))
+		Expect(workflow.Steps[0].FirstExecuteTime).Should(BeNil())
 
-			optWithWrongValue := `value: {}
-cluster: "test"`
-			v, err = value.NewValue(optWithWrongValue, nil, "")
+		status.Workflow.Suspend = false
+		status.Workflow.Finished = true
+		status.Workflow.Steps[1].Phase = common.WorkflowStepPhaseFailed
+		Expect(collectWorkflowStatus(status).Phase).Should(Equal(WorkflowPhaseFailed))
+		status.Workflow.Steps[1].Phase = common.WorkflowStepPhaseSucceeded
+		Expect(collectWorkflowStatus(status).Phase).Should(Equal(WorkflowPhaseSucceeded))
+		status.Workflow.Terminated = true
+		Expect(collectWorkflowStatus(status).Phase).Should(Equal(WorkflowPhaseTerminated))
+	})
+
+	It("Test collect services of app", func() {
+		ready := true
+		slice := &discoveryv1.EndpointSlice{
+			ObjectMeta:  metav1.ObjectMeta{Name: "routing-web-abc", Namespace: "default", Labels: map[string]string{discoveryv1.LabelServiceName: "routing-web"}},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.3"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}}},
+			Ports:       []discoveryv1.EndpointPort{{Port: pointer.Int32(80)}},
+		}
+		Expect(k8sClient.Create(ctx, slice)).Should(BeNil())
+		newService := func(name string, selector map[string]string) *unstructured.Unstructured {
+			obj, err := util.Object2Unstructured(&corev1.Service{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: corev1.ServiceSpec{
+					Type:     corev1.ServiceTypeClusterIP,
+					Ports:    []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+					Selector: selector,
+				},
+			})
 			Expect(err).Should(BeNil())
-			err = prd.SearchEvents(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
+			return obj
+		}
+		deploy, err := util.Object2Unstructured(baseDeploy)
+		Expect(err).Should(BeNil())
+		services, err := collectServices(k8sClient, []Resource{
+			{Component: "web", Object: newService("routing-web", map[string]string{"app": "web"})},
+			{Component: "web", Object: deploy},
+			{Component: "db", Object: newService("routing-db", map[string]string{"app": "db"})},
 		})
+		Expect(err).Should(BeNil())
+		Expect(services).Should(HaveLen(2))
+		Expect(services[0].Name).Should(Equal("routing-web"))
+		Expect(services[0].Selector).Should(Equal(map[string]string{"app": "web"}))
+		Expect(services[0].Ports[0].TargetPort).Should(Equal(intstr.FromInt(8080)))
+		Expect(services[0].ReadyEndpoints).Should(Equal(1))
+		Expect(services[0].HasReady).Should(BeTrue())
+		Expect(services[1].Component).Should(Equal("db"))
+		Expect(services[1].HasReady).Should(BeFalse())
 	})
 
-	Context("Test CollectLogsInPod", func() {
-		It("Test CollectLogsInPod with specified container", func() {
-			prd := provider{cli: k8sClient, cfg: cfg}
-			pod := &corev1.Pod{
-				ObjectMeta: metav1.ObjectMeta{Name: "hello-world", Namespace: "default"},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{{Name: "main", Image: "busybox"}},
-				}}
-			Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+	It("Test collect warnings of app", func() {
+		now := time.Now()
+		newEvent := func(name, involved, eventType string, count int32, age time.Duration) {
+			event := &corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+				InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Name: involved, Namespace: "default"},
+				Type:           eventType,
+				Reason:         "FailedCreate",
+				Count:          count,
+				LastTimestamp:  metav1.NewTime(now.Add(-age)),
+			}
+			Expect(k8sClient.Create(ctx, event)).Should(BeNil())
+		}
+		newEvent("warning-web.1", "warning-web", corev1.EventTypeWarning, 2, time.Minute)
+		newEvent("warning-web.2", "warning-web", corev1.EventTypeWarning, 1, time.Second)
+		newEvent("warning-web.3", "warning-web", corev1.EventTypeNormal, 10, time.Second)
+		newEvent("warning-db.1", "warning-db", corev1.EventTypeWarning, 3, time.Hour)
+		newEvent("warning-cache.1", "warning-cache", corev1.EventTypeWarning, 1, time.Minute)
+		var resources []Resource
+		for _, name := range []string{"warning-web", "warning-db", "warning-cache", "warning-healthy"} {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("apps/v1")
+			obj.SetKind("Deployment")
+			obj.SetName(name)
+			obj.SetNamespace("default")
+			resources = append(resources, Resource{Component: name, Object: obj})
+		}
+		warnings, err := collectAppWarnings(k8sClient, resources, 2)
+		Expect(err).Should(BeNil())
+		Expect(warnings).Should(HaveLen(2))
+		Expect(warnings[0].Name).Should(Equal("warning-web"))
+		Expect(warnings[0].Count).Should(Equal(int32(3)))
+		Expect(warnings[0].Events).Should(HaveLen(2))
+		Expect(warnings[0].Events[0].Count).Should(Equal(int32(1)))
+		Expect(warnings[1].Name).Should(Equal("warning-db"))
+		warnings, err = collectAppWarnings(k8sClient, resources, 0)
+		Expect(err).Should(BeNil())
+		Expect(warnings).Should(HaveLen(3))
+		Expect(warnings[2].Component).Should(Equal("warning-cache"))
+	})
 
-			v, err := value.NewValue(``, nil, "")
-			Expect(err).Should(Succeed())
-			err = prd.CollectLogsInPod(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
-			Expect(err.Error()).Should(ContainSubstring("var(path=cluster) not exist"))
+	It("Test generator pending endpoints of loadbalancer service", func() {
+		service := corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "provisioning", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Type: corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{
+					{Port: 80, Protocol: corev1.ProtocolTCP},
+					{Port: 81, Protocol: corev1.ProtocolTCP},
+				},
+			},
+		}
+		endpoints := generatorFromService(service)
+		Expect(len(endpoints)).Should(Equal(2))
+		for i, endpoint := range endpoints {
+			Expect(endpoint.Pending).Should(BeTrue())
+			Expect(endpoint.Endpoint.Host).Should(BeEmpty())
+			Expect(endpoint.Endpoint.Port).Should(Equal(service.Spec.Ports[i].Port))
+			Expect(endpoint.Ref.Name).Should(Equal("provisioning"))
+		}
 
-			v, err = value.NewValue(`cluster: "local"`, nil, "")
-			Expect(err).Should(Succeed())
-			err = prd.CollectLogsInPod(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
-			Expect(err.Error()).Should(ContainSubstring("var(path=namespace) not exist"))
+		service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.10.10.10"}}
+		endpoints = generatorFromService(service)
+		Expect(len(endpoints)).Should(Equal(2))
+		Expect(endpoints[0].Pending).Should(BeFalse())
+		Expect(endpoints[0].Endpoint.Host).Should(Equal("10.10.10.10"))
+	})
 
-			v, err = value.NewValue(`cluster: "local"
-namespace: "default"`, nil, "")
-			Expect(err).Should(Succeed())
-			err = prd.CollectLogsInPod(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
-			Expect(err.Error()).Should(ContainSubstring("var(path=pod) not exist"))
+	It("Test generator service endpoints with ip family", func() {
+		service := corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "dual-stack", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Type:       corev1.ServiceTypeLoadBalancer,
+				Ports:      []corev1.ServicePort{{Port: 80, NodePort: 30080, Protocol: corev1.ProtocolTCP}},
+				IPFamilies: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+			},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "10.10.10.10"}, {IP: "2001:db8::1"}, {Hostname: "lb.example.com"}},
+			}},
+		}
+		endpoints := generatorFromService(service)
+		Expect(len(endpoints)).Should(Equal(3))
+		Expect(endpoints[0].Endpoint.IPFamily).Should(Equal("IPv4"))
+		Expect(endpoints[1].Endpoint.IPFamily).Should(Equal("IPv6"))
+		Expect(endpoints[2].Endpoint.IPFamily).Should(Equal(IPFamilyHostname))
 
-			v, err = value.NewValue(`cluster: "local"
-namespace: "default"
-pod: "hello-world"`, nil, "")
-			Expect(err).Should(Succeed())
-			err = prd.CollectLogsInPod(nil, v, nil)
-			Expect(err).ShouldNot(BeNil())
-			Expect(err.Error()).Should(ContainSubstring("var(path=options) not exist"))
+		service.Spec.Type = corev1.ServiceTypeNodePort
+		endpoints = generatorFromService(service)
+		Expect(len(endpoints)).Should(Equal(1))
+		Expect(endpoints[0].Endpoint.Port).Should(Equal(int32(30080)))
+		Expect(endpoints[0].Endpoint.IPFamily).Should(Equal("IPv6"))
+	})
 
-			v, err = value.NewValue(`cluster: "local"
-namespace: "default"
-pod: "hello-world"
-options: {
-  container: 1
-}`, nil, "")
-			Expect(err).Should(Succeed())
-			err = prd.CollectLogsInPod(nil, v, nil)
+	It("Test generator service endpoints with app protocol", func() {
+		grpc, https := "grpc", "https"
+		service := corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-protocol", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Type: corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{
+					{Port: 9090, Protocol: corev1.ProtocolTCP, AppProtocol: &grpc},
+					{Port: 443, Protocol: corev1.ProtocolTCP, AppProtocol: &https},
+					{Port: 80, Protocol: corev1.ProtocolTCP},
+				},
+			},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "10.10.10.10"}},
+			}},
+		}
+		endpoints := generatorFromService(service)
+		Expect(len(endpoints)).Should(Equal(3))
+		Expect(endpoints[0].String()).Should(Equal("grpc://10.10.10.10:9090"))
+		Expect(endpoints[1].String()).Should(Equal("https://10.10.10.10"))
+		Expect(endpoints[2].String()).Should(Equal("tcp://10.10.10.10:80"))
+	})
+
+	It("Test generator ingress endpoints with the port annotations of ingress class", func() {
+		classPortAnnotations, err := ParseIngressClassPortAnnotations("traefik=traefik.example.io/http-port:traefik.example.io/https-port, nginx=nginx.example.io/http-port:nginx.example.io/https-port")
+		Expect(err).Should(BeNil())
+		Expect(classPortAnnotations).Should(Equal(map[string]IngressPortAnnotations{
+			"traefik": {HTTP: "traefik.example.io/http-port", HTTPS: "traefik.example.io/https-port"},
+			"nginx":   {HTTP: "nginx.example.io/http-port", HTTPS: "nginx.example.io/https-port"},
+		}))
+		for _, invalid := range []string{"traefik", "=http:https", "traefik=http", "traefik=http:", "traefik=a:b:c"} {
+			_, err = ParseIngressClassPortAnnotations(invalid)
 			Expect(err).ShouldNot(BeNil())
-			Expect(err.Error()).Should(ContainSubstring("invalid log options content"))
+		}
 
-			v, err = value.NewValue(`cluster: "local"
-namespace: "default"
-pod: "hello-world"
-options: {
-  container: "main"
-  previous: true
-  sinceSeconds: 100
-  tailLines: 50
-}`, nil, "")
-			Expect(err).Should(Succeed())
-			Expect(prd.CollectLogsInPod(nil, v, nil)).Should(Succeed())
-			_, err = v.GetString("outputs", "logs")
-			Expect(err).Should(Succeed())
-		})
+		prd := &provider{cli: k8sClient, ingressClassPortAnnotations: classPortAnnotations}
+		className := "traefik"
+		ingress := networkv1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "class-ports", Namespace: "default", Annotations: map[string]string{
+				"traefik.example.io/http-port": "8000",
+				"ingress.controller/http-port": "8080",
+			}},
+			Spec: networkv1beta1.IngressSpec{
+				IngressClassName: &className,
+				Rules: []networkv1beta1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkv1beta1.IngressRuleValue{HTTP: &networkv1beta1.HTTPIngressRuleValue{
+						Paths: []networkv1beta1.HTTPIngressPath{{Path: "/"}},
+					}},
+				}},
+			},
+		}
+		endpoints := generatorFromIngress(ingress, prd.ingressClassPortAnnotations)
+		Expect(len(endpoints)).Should(Equal(1))
+		Expect(endpoints[0].Endpoint.Port).Should(Equal(int32(8000)))
+
+		// the annotations of the other classes and the default annotations aren't affected
+		className = "nginx"
+		endpoints = generatorFromIngress(ingress, prd.ingressClassPortAnnotations)
+		Expect(endpoints[0].Endpoint.Port).Should(Equal(int32(8080)))
+		ingress.Spec.IngressClassName = nil
+		endpoints = generatorFromIngress(ingress, prd.ingressClassPortAnnotations)
+		Expect(endpoints[0].Endpoint.Port).Should(Equal(int32(8080)))
+		endpoints = generatorFromIngress(ingress, nil)
+		Expect(endpoints[0].Endpoint.Port).Should(Equal(int32(8080)))
 	})
 
-	It("Test install provider", func() {
-		p := providers.NewProviders()
-		Install(p, k8sClient, cfg)
-		h, ok := p.GetHandler("query", "listResourcesInApp")
-		Expect(h).ShouldNot(BeNil())
-		Expect(ok).Should(Equal(true))
-		h, ok = p.GetHandler("query", "collectPods")
-		Expect(h).ShouldNot(BeNil())
-		Expect(ok).Should(Equal(true))
-		h, ok = p.GetHandler("query", "searchEvents")
-		Expect(ok).Should(Equal(true))
-		Expect(h).ShouldNot(BeNil())
-		h, ok = p.GetHandler("query", "collectLogsInPod")
-		Expect(ok).Should(Equal(true))
-		Expect(h).ShouldNot(BeNil())
-		h, ok = p.GetHandler("query", "collectServiceEndpoints")
-		Expect(ok).Should(Equal(true))
-		Expect(h).ShouldNot(BeNil())
+	It("Test resolve the certificates of endpoints", func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).Should(BeNil())
+		notAfter := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "ingress.domain.tls"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).Should(BeNil())
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls-secret", Namespace: "default"},
+			Type:       corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				corev1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			},
+		}
+		Expect(k8sClient.Create(ctx, secret)).Should(BeNil())
+
+		endpoints := []ServiceEndpoint{
+			{Endpoint: Endpoint{Host: "ingress.domain.tls", TLS: &EndpointTLS{SecretName: "tls-secret"}}, Ref: corev1.ObjectReference{Namespace: "default"}},
+			{Endpoint: Endpoint{Host: "ingress.domain.tls", Path: "/v2", TLS: &EndpointTLS{SecretName: "tls-secret"}}, Ref: corev1.ObjectReference{Namespace: "default"}},
+			{Endpoint: Endpoint{Host: "ingress.domain.missing", TLS: &EndpointTLS{SecretName: "missing-secret"}}, Ref: corev1.ObjectReference{Namespace: "default"}},
+			{Endpoint: Endpoint{Host: "ingress.domain.http"}, Ref: corev1.ObjectReference{Namespace: "default"}},
+		}
+		resolveEndpointCertificates(context.Background(), k8sClient, endpoints, "")
+		for _, endpoint := range endpoints[:2] {
+			Expect(endpoint.Endpoint.TLS.CommonName).Should(Equal("ingress.domain.tls"))
+			Expect(endpoint.Endpoint.TLS.NotAfter.Time.Equal(notAfter)).Should(BeTrue())
+		}
+		Expect(endpoints[2].Endpoint.TLS).Should(Equal(&EndpointTLS{SecretName: "missing-secret"}))
+		Expect(endpoints[3].Endpoint.TLS).Should(BeNil())
 	})
 
 	It("Test generator service endpoints", func() {
@@ -705,6 +3337,9 @@ options: {
 		var endpoints []ServiceEndpoint
 		err = endValue.Decode(&endpoints)
 		Expect(err).Should(BeNil())
+		// the TLS secret is not created, so only the secret name is returned
+		Expect(endpoints[1].Endpoint.TLS).Should(Equal(&EndpointTLS{SecretName: "https-secret"}))
+		Expect(endpoints[0].Endpoint.TLS).Should(BeNil())
 		for i, endpoint := range endpoints {
 			Expect(endpoint.String()).Should(BeEquivalentTo(urls[i]))
 		}
@@ -787,3 +3422,13 @@ spec:
     - containerPort: 8000
       protocol: TCP
 `
+
+// queryContext is a workflow context carrying the context of the query
+type queryContext struct {
+	wfContext.Context
+	ctx context.Context
+}
+
+func (c *queryContext) GetContext() context.Context {
+	return c.ctx
+}
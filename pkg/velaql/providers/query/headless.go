@@ -0,0 +1,102 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// isHeadlessService checks whether the service is a headless ClusterIP service without a virtual IP
+func isHeadlessService(service corev1.Service) bool {
+	return (service.Spec.Type == "" || service.Spec.Type == corev1.ServiceTypeClusterIP) && service.Spec.ClusterIP == corev1.ClusterIPNone
+}
+
+// generatorFromHeadlessService emits an endpoint for each ready pod of the headless service, the host is the DNS name
+// of the pod such as <pod>.<service>.<namespace>.svc. The hostname of the endpoint is used as the pod name in the DNS
+// name since the pods of the StatefulSet are addressed by it, and the pods without both are skipped.
+func generatorFromHeadlessService(cli client.Client, service corev1.Service, cluster string) ([]ServiceEndpoint, error) {
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	slices := discoveryv1.EndpointSliceList{}
+	if err := cli.List(ctx, &slices, client.InNamespace(service.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: service.Name}); err != nil {
+		return nil, err
+	}
+	ref := corev1.ObjectReference{
+		Kind:            service.Kind,
+		Namespace:       service.ObjectMeta.Namespace,
+		Name:            service.ObjectMeta.Name,
+		UID:             service.UID,
+		APIVersion:      service.APIVersion,
+		ResourceVersion: service.ResourceVersion,
+	}
+	var serviceEndpoints []ServiceEndpoint
+	// the pods of the dual-stack service are in the slices of both the address types
+	emitted := map[string]bool{}
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			// a nil ready condition means the state is unknown and it should be interpreted as ready
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			var pod string
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+				pod = endpoint.TargetRef.Name
+			}
+			hostname := pod
+			if endpoint.Hostname != nil && *endpoint.Hostname != "" {
+				hostname = *endpoint.Hostname
+			}
+			if hostname == "" {
+				continue
+			}
+			host := fmt.Sprintf("%s.%s.%s.svc", hostname, service.Name, service.Namespace)
+			for _, port := range slice.Ports {
+				if port.Port == nil {
+					continue
+				}
+				key := fmt.Sprintf("%s:%d", host, *port.Port)
+				if emitted[key] {
+					continue
+				}
+				emitted[key] = true
+				protocol := corev1.ProtocolTCP
+				if port.Protocol != nil {
+					protocol = *port.Protocol
+				}
+				serviceEndpoints = append(serviceEndpoints, ServiceEndpoint{
+					Endpoint: Endpoint{
+						Protocol:    protocol,
+						AppProtocol: port.AppProtocol,
+						Host:        host,
+						Port:        *port.Port,
+						IPFamily:    IPFamilyHostname,
+					},
+					Ref:      ref,
+					Headless: true,
+					Pod:      pod,
+				})
+			}
+		}
+	}
+	return serviceEndpoints, nil
+}
@@ -0,0 +1,196 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/strvals"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	helmapi "github.com/oam-dev/kubevela/pkg/appfile/helm/flux2apis"
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+const (
+	// defaultHelmValuesKey is the key of the values in the ConfigMap or the Secret if valuesKey is not set
+	defaultHelmValuesKey = "values.yaml"
+	// redactedHelmValue replaces the values read from the Secrets
+	redactedHelmValue = "<redacted>"
+)
+
+// HelmValues is the values in effect for a HelmRelease, the values from valuesFrom are merged in order and
+// spec.values is merged last, like the helm-controller does
+type HelmValues struct {
+	Values  map[string]interface{} `json:"values"`
+	Sources []HelmValuesSource     `json:"sources"`
+}
+
+// HelmValuesSource is a ConfigMap or a Secret in the valuesFrom of the HelmRelease
+type HelmValuesSource struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	ValuesKey  string `json:"valuesKey"`
+	TargetPath string `json:"targetPath,omitempty"`
+	// Missing means the source is optional and not found, it is skipped
+	Missing bool `json:"missing,omitempty"`
+	// Redacted means the source is a Secret, the values read from it are hidden
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// helmReleaseValuesSpec is the part of the spec of the HelmRelease holding the values
+type helmReleaseValuesSpec struct {
+	ValuesFrom []helmapi.ValuesReference `json:"valuesFrom,omitempty"`
+	Values     map[string]interface{}    `json:"values,omitempty"`
+}
+
+// collectHelmValues merges the values of the HelmRelease with the ConfigMaps and the Secrets it refers to
+func collectHelmValues(cli client.Client, hr *unstructured.Unstructured, cluster string) (*HelmValues, error) {
+	specObj, _, err := unstructured.NestedMap(hr.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	spec := helmReleaseValuesSpec{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(specObj, &spec); err != nil {
+		return nil, err
+	}
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	result := &HelmValues{Values: map[string]interface{}{}, Sources: make([]HelmValuesSource, 0, len(spec.ValuesFrom))}
+	for _, ref := range spec.ValuesFrom {
+		source := HelmValuesSource{Kind: ref.Kind, Name: ref.Name, ValuesKey: ref.ValuesKey, TargetPath: ref.TargetPath}
+		if source.ValuesKey == "" {
+			source.ValuesKey = defaultHelmValuesKey
+		}
+		source.Redacted = ref.Kind == secretKind
+		data, found, err := getHelmValuesData(ctx, cli, hr.GetNamespace(), source)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			if !ref.Optional {
+				return nil, errors.Errorf("%s %s/%s referred by the HelmRelease is not found", ref.Kind, hr.GetNamespace(), ref.Name)
+			}
+			source.Missing = true
+			result.Sources = append(result.Sources, source)
+			continue
+		}
+		if source.TargetPath != "" {
+			if source.Redacted {
+				data = redactedHelmValue
+			}
+			if err = strvals.ParseInto(fmt.Sprintf("%s=%s", source.TargetPath, data), result.Values); err != nil {
+				return nil, errors.Wrapf(err, "invalid targetPath %s of %s %s", source.TargetPath, ref.Kind, ref.Name)
+			}
+		} else {
+			values := map[string]interface{}{}
+			if err = yaml.Unmarshal([]byte(data), &values); err != nil {
+				return nil, errors.Wrapf(err, "invalid values in key %s of %s %s", source.ValuesKey, ref.Kind, ref.Name)
+			}
+			if source.Redacted {
+				values = redactHelmValues(values)
+			}
+			result.Values = mergeHelmValues(result.Values, values)
+		}
+		result.Sources = append(result.Sources, source)
+	}
+	result.Values = mergeHelmValues(result.Values, spec.Values)
+	return result, nil
+}
+
+// getHelmValuesData reads the key of the ConfigMap or the Secret, it's an error if the object is found without the key
+func getHelmValuesData(ctx context.Context, cli client.Client, namespace string, source HelmValuesSource) (string, bool, error) {
+	key := client.ObjectKey{Namespace: namespace, Name: source.Name}
+	var data string
+	var ok bool
+	switch source.Kind {
+	case configMapKind:
+		cm := corev1.ConfigMap{}
+		if err := cli.Get(ctx, key, &cm); err != nil {
+			if kerrors.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		if data, ok = cm.Data[source.ValuesKey]; !ok {
+			var binary []byte
+			binary, ok = cm.BinaryData[source.ValuesKey]
+			data = string(binary)
+		}
+	case secretKind:
+		secret := corev1.Secret{}
+		if err := cli.Get(ctx, key, &secret); err != nil {
+			if kerrors.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		var binary []byte
+		binary, ok = secret.Data[source.ValuesKey]
+		data = string(binary)
+	default:
+		return "", false, errors.Errorf("unsupported kind %s of valuesFrom, it must be ConfigMap or Secret", source.Kind)
+	}
+	if !ok {
+		return "", false, errors.Errorf("missing key %s in %s %s", source.ValuesKey, source.Kind, source.Name)
+	}
+	return data, true, nil
+}
+
+// mergeHelmValues merges the values of src into dst recursively, the maps are merged and the other values are replaced
+func mergeHelmValues(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			if dstMap, ok := dst[k].(map[string]interface{}); ok {
+				dst[k] = mergeHelmValues(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// redactHelmValues replaces the leaves of the values, the structure is kept so that it's clear which keys are set
+func redactHelmValues(values map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		redacted[k] = redactHelmValue(v)
+	}
+	return redacted
+}
+
+func redactHelmValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return redactHelmValues(value)
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i := range value {
+			items[i] = redactHelmValue(value[i])
+		}
+		return items
+	default:
+		return redactedHelmValue
+	}
+}
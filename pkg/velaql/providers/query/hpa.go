@@ -0,0 +1,153 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// HPAStatus is the status of a HorizontalPodAutoscaler scaling the workload
+type HPAStatus struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	MinReplicas     *int32 `json:"minReplicas,omitempty"`
+	MaxReplicas     int32  `json:"maxReplicas"`
+	CurrentReplicas int32  `json:"currentReplicas"`
+	DesiredReplicas int32  `json:"desiredReplicas"`
+	// LastScaleTime is empty if the workload has never been scaled by the autoscaler
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+	Metrics       []HPAMetric  `json:"metrics"`
+}
+
+// HPAMetric is a metric of the autoscaler with its target and current value, such as 80% for the utilization.
+// Current is empty if the metric is not reported yet.
+type HPAMetric struct {
+	Type    autoscalingv2beta2.MetricSourceType `json:"type"`
+	Name    string                              `json:"name"`
+	Target  string                              `json:"target"`
+	Current string                              `json:"current,omitempty"`
+}
+
+// collectHPAs collects the status of the HorizontalPodAutoscalers whose scale target is the workload
+func collectHPAs(cli client.Client, obj *unstructured.Unstructured, cluster string) ([]HPAStatus, error) {
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	hpaList := autoscalingv2beta2.HorizontalPodAutoscalerList{}
+	if err := cli.List(ctx, &hpaList, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil, err
+	}
+	statuses := []HPAStatus{}
+	for _, hpa := range hpaList.Items {
+		if !isHPATargeting(hpa.Spec.ScaleTargetRef, obj) {
+			continue
+		}
+		status := HPAStatus{
+			Name:            hpa.Name,
+			Namespace:       hpa.Namespace,
+			MinReplicas:     hpa.Spec.MinReplicas,
+			MaxReplicas:     hpa.Spec.MaxReplicas,
+			CurrentReplicas: hpa.Status.CurrentReplicas,
+			DesiredReplicas: hpa.Status.DesiredReplicas,
+			LastScaleTime:   hpa.Status.LastScaleTime,
+			Metrics:         []HPAMetric{},
+		}
+		for _, spec := range hpa.Spec.Metrics {
+			name, target := getMetricSpecTarget(spec)
+			metric := HPAMetric{Type: spec.Type, Name: name, Target: formatMetricTarget(target)}
+			for _, current := range hpa.Status.CurrentMetrics {
+				if currentName, value := getMetricStatusValue(current); current.Type == spec.Type && currentName == name {
+					metric.Current = formatMetricValue(value)
+					break
+				}
+			}
+			status.Metrics = append(status.Metrics, metric)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// isHPATargeting checks if the scale target is the workload, any version of the group is matched
+func isHPATargeting(ref autoscalingv2beta2.CrossVersionObjectReference, obj *unstructured.Unstructured) bool {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return false
+	}
+	return gv.Group == obj.GroupVersionKind().Group && ref.Kind == obj.GetKind() && ref.Name == obj.GetName()
+}
+
+// getMetricSpecTarget returns the name and the target of the metric, the name of a resource metric is the resource
+func getMetricSpecTarget(spec autoscalingv2beta2.MetricSpec) (string, autoscalingv2beta2.MetricTarget) {
+	switch {
+	case spec.Resource != nil:
+		return string(spec.Resource.Name), spec.Resource.Target
+	case spec.ContainerResource != nil:
+		return spec.ContainerResource.Container + "/" + string(spec.ContainerResource.Name), spec.ContainerResource.Target
+	case spec.Pods != nil:
+		return spec.Pods.Metric.Name, spec.Pods.Target
+	case spec.Object != nil:
+		return spec.Object.Metric.Name, spec.Object.Target
+	case spec.External != nil:
+		return spec.External.Metric.Name, spec.External.Target
+	}
+	return "", autoscalingv2beta2.MetricTarget{}
+}
+
+func getMetricStatusValue(status autoscalingv2beta2.MetricStatus) (string, autoscalingv2beta2.MetricValueStatus) {
+	switch {
+	case status.Resource != nil:
+		return string(status.Resource.Name), status.Resource.Current
+	case status.ContainerResource != nil:
+		return status.ContainerResource.Container + "/" + string(status.ContainerResource.Name), status.ContainerResource.Current
+	case status.Pods != nil:
+		return status.Pods.Metric.Name, status.Pods.Current
+	case status.Object != nil:
+		return status.Object.Metric.Name, status.Object.Current
+	case status.External != nil:
+		return status.External.Metric.Name, status.External.Current
+	}
+	return "", autoscalingv2beta2.MetricValueStatus{}
+}
+
+func formatMetricTarget(target autoscalingv2beta2.MetricTarget) string {
+	return formatMetricValue(autoscalingv2beta2.MetricValueStatus{
+		Value:              target.Value,
+		AverageValue:       target.AverageValue,
+		AverageUtilization: target.AverageUtilization,
+	})
+}
+
+// formatMetricValue formats the utilization in percent, or the value in quantity like kubectl
+func formatMetricValue(value autoscalingv2beta2.MetricValueStatus) string {
+	switch {
+	case value.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *value.AverageUtilization)
+	case value.AverageValue != nil:
+		return value.AverageValue.String()
+	case value.Value != nil:
+		return value.Value.String()
+	}
+	return ""
+}
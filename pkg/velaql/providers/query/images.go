@@ -0,0 +1,136 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"reflect"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ContainerImage is a container image used by a component of the application
+type ContainerImage struct {
+	Image     string `json:"image"`
+	Component string `json:"component"`
+	Cluster   string `json:"cluster"`
+	// Digest is resolved from the imageID of the running pods, it is empty if no pod reports it
+	Digest string `json:"digest,omitempty"`
+}
+
+var podGVK = corev1.SchemeGroupVersion.WithKind(reflect.TypeOf(corev1.Pod{}).Name())
+
+// podSpecPaths records the path of the pod spec for the workloads which don't use spec.template.spec
+var podSpecPaths = map[schema.GroupVersionKind][]string{
+	podGVK: {"spec"},
+	batchv1.SchemeGroupVersion.WithKind(reflect.TypeOf(batchv1.CronJob{}).Name()):           {"spec", "jobTemplate", "spec", "template", "spec"},
+	batchv1beta1.SchemeGroupVersion.WithKind(reflect.TypeOf(batchv1beta1.CronJob{}).Name()): {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// collectImages collects the de-duplicated container images of the workloads in the resources
+func collectImages(cli client.Client, resources []Resource) ([]ContainerImage, error) {
+	images := []ContainerImage{}
+	seen := map[ContainerImage]bool{}
+	for _, res := range resources {
+		containers := getContainers(res.Object)
+		if len(containers) == 0 {
+			continue
+		}
+		digests, err := getImageDigests(cli, res)
+		if err != nil {
+			return nil, err
+		}
+		for _, container := range containers {
+			image := ContainerImage{Image: container.Image, Component: res.Component, Cluster: res.Cluster}
+			if seen[image] {
+				continue
+			}
+			seen[image] = true
+			image.Digest = digests[container.Name]
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// getContainers returns the init containers and containers in the pod spec of the workload
+func getContainers(obj *unstructured.Unstructured) []corev1.Container {
+	spec := getPodSpec(obj)
+	if spec == nil {
+		return nil
+	}
+	return append(spec.InitContainers, spec.Containers...)
+}
+
+// getPodSpec returns the pod spec of the workload, it is nil if the workload has no valid pod spec
+func getPodSpec(obj *unstructured.Unstructured) *corev1.PodSpec {
+	path, ok := podSpecPaths[obj.GroupVersionKind()]
+	if !ok {
+		path = []string{"spec", "template", "spec"}
+	}
+	podSpec, found, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil || !found {
+		return nil
+	}
+	spec := &corev1.PodSpec{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(podSpec, spec); err != nil {
+		return nil
+	}
+	return spec
+}
+
+// getImageDigests returns the digest of the image used by each container, reported by the pods of the workload
+func getImageDigests(cli client.Client, res Resource) (map[string]string, error) {
+	pods := []*unstructured.Unstructured{res.Object}
+	if res.Object.GroupVersionKind() != podGVK {
+		var err error
+		if pods, err = NewPodCollector(res.Object.GroupVersionKind())(cli, res.Object, res.Cluster); err != nil {
+			return nil, err
+		}
+	}
+	digests := map[string]string{}
+	for _, pod := range pods {
+		podStatus, found, err := unstructured.NestedMap(pod.Object, "status")
+		if err != nil || !found {
+			continue
+		}
+		status := corev1.PodStatus{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(podStatus, &status); err != nil {
+			continue
+		}
+		for _, cs := range append(status.InitContainerStatuses, status.ContainerStatuses...) {
+			if digest := imageIDToDigest(cs.ImageID); digest != "" && digests[cs.Name] == "" {
+				digests[cs.Name] = digest
+			}
+		}
+	}
+	return digests, nil
+}
+
+// imageIDToDigest extracts the digest from an imageID such as docker-pullable://nginx@sha256:abc
+func imageIDToDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	return imageID
+}
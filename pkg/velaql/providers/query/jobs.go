@@ -0,0 +1,136 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// the statuses of the jobs and the cronjobs
+const (
+	JobStatusComplete  = "Complete"
+	JobStatusFailed    = "Failed"
+	JobStatusRunning   = "Running"
+	JobStatusSuspended = "Suspended"
+	// JobStatusScheduled means the cronjob has no active job and waits for the next schedule
+	JobStatusScheduled = "Scheduled"
+)
+
+// JobSummary is the completion status of a Job or a CronJob, the CronJob of batch/v1beta1 is also supported
+type JobSummary struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	Component string `json:"component,omitempty"`
+	Status    string `json:"status"`
+	// Completions is the desired number of the succeeded pods of the Job, it is nil if any succeeded pod completes the Job
+	Completions    *int32       `json:"completions,omitempty"`
+	Active         int32        `json:"active"`
+	Succeeded      int32        `json:"succeeded"`
+	Failed         int32        `json:"failed"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Schedule, LastScheduleTime and LastSuccessfulTime are only set for the CronJob, and Active is the number of
+	// its running jobs
+	Schedule           string       `json:"schedule,omitempty"`
+	LastScheduleTime   *metav1.Time `json:"lastScheduleTime,omitempty"`
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+}
+
+// collectJobs summarizes the Jobs and the CronJobs in the resources
+func collectJobs(resources []Resource) ([]JobSummary, error) {
+	jobs := []JobSummary{}
+	for _, res := range resources {
+		gvk := res.Object.GroupVersionKind()
+		if gvk.Group != batchv1.GroupName {
+			continue
+		}
+		var summary JobSummary
+		switch gvk.Kind {
+		case "Job":
+			job := batchv1.Job{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(res.Object.Object, &job); err != nil {
+				return nil, err
+			}
+			summary = summarizeJob(job)
+		case "CronJob":
+			// the CronJob of batch/v1beta1 has the same fields as batch/v1
+			cronJob := batchv1.CronJob{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(res.Object.Object, &cronJob); err != nil {
+				return nil, err
+			}
+			summary = summarizeCronJob(cronJob)
+		default:
+			continue
+		}
+		summary.Kind, summary.Name, summary.Namespace = gvk.Kind, res.Object.GetName(), res.Object.GetNamespace()
+		summary.Cluster, summary.Component = normalizeClusterName(res.Cluster), res.Component
+		jobs = append(jobs, summary)
+	}
+	return jobs, nil
+}
+
+func summarizeJob(job batchv1.Job) JobSummary {
+	return JobSummary{
+		Status:         getJobStatus(job),
+		Completions:    job.Spec.Completions,
+		Active:         job.Status.Active,
+		Succeeded:      job.Status.Succeeded,
+		Failed:         job.Status.Failed,
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+	}
+}
+
+func getJobStatus(job batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return JobStatusComplete
+		case batchv1.JobFailed:
+			return JobStatusFailed
+		}
+	}
+	if job.Spec.Suspend != nil && *job.Spec.Suspend {
+		return JobStatusSuspended
+	}
+	return JobStatusRunning
+}
+
+func summarizeCronJob(cronJob batchv1.CronJob) JobSummary {
+	summary := JobSummary{
+		Status:             JobStatusScheduled,
+		Active:             int32(len(cronJob.Status.Active)),
+		Schedule:           cronJob.Spec.Schedule,
+		LastScheduleTime:   cronJob.Status.LastScheduleTime,
+		LastSuccessfulTime: cronJob.Status.LastSuccessfulTime,
+	}
+	switch {
+	case cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend:
+		summary.Status = JobStatusSuspended
+	case summary.Active > 0:
+		summary.Status = JobStatusRunning
+	}
+	return summary
+}
@@ -0,0 +1,118 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+)
+
+const (
+	// KustomizationKind is the kind of Kustomization
+	KustomizationKind = "Kustomization"
+
+	kustomizeGroup = "kustomize.toolkit.fluxcd.io"
+)
+
+// isKustomization checks if the resource is a Kustomization of fluxcd, any version in the group is matched
+func isKustomization(gvk schema.GroupVersionKind) bool {
+	return gvk.Group == kustomizeGroup && gvk.Kind == KustomizationKind
+}
+
+// collectKustomizationResources appends the resources recorded in the inventory of the Kustomizations,
+// they belong to the same component and cluster as the Kustomization
+func (c *AppCollector) collectKustomizationResources(resources []Resource) ([]Resource, error) {
+	var refs []common.ClusterObjectReference
+	var owners []Resource
+	for _, res := range resources {
+		if !isKustomization(res.Object.GroupVersionKind()) {
+			continue
+		}
+		for _, objRef := range getKustomizationInventory(res.Object) {
+			refs = append(refs, common.ClusterObjectReference{Cluster: res.Cluster, ObjectReference: objRef})
+			owners = append(owners, res)
+		}
+	}
+	if len(refs) == 0 {
+		return resources, nil
+	}
+	objs, err := c.getObjectsInClusters(refs)
+	if err != nil {
+		return nil, err
+	}
+	for i, obj := range objs {
+		if obj == nil || !isResourceMatchAnnotations(c.opt.Filter, obj) || !isResourceInAgeRange(c.opt.Filter, obj, time.Now()) {
+			continue
+		}
+		resources = append(resources, Resource{
+			Cluster:   owners[i].Cluster,
+			Component: owners[i].Component,
+			Revision:  owners[i].Revision,
+			Trait:     owners[i].Trait,
+			Object:    obj,
+		})
+	}
+	return resources, nil
+}
+
+// getKustomizationInventory reads the objects in status.inventory.entries of the Kustomization
+func getKustomizationInventory(obj *unstructured.Unstructured) []corev1.ObjectReference {
+	entries, _, err := unstructured.NestedSlice(obj.Object, "status", "inventory", "entries")
+	if err != nil {
+		klog.Warningf("invalid inventory of kustomization %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	var refs []corev1.ObjectReference
+	for _, entry := range entries {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := fields["id"].(string)
+		version, _ := fields["v"].(string)
+		ref, err := parseInventoryEntry(id, version)
+		if err != nil {
+			klog.Warningf("skip the inventory entry of kustomization %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// parseInventoryEntry parses the entry of the inventory, the id is in the format of <namespace>_<name>_<group>_<kind>,
+// the namespace is empty for the cluster scoped objects and the group is empty for the core objects
+func parseInventoryEntry(id, version string) (corev1.ObjectReference, error) {
+	parts := strings.Split(id, "_")
+	if len(parts) != 4 || parts[1] == "" || parts[3] == "" || version == "" {
+		return corev1.ObjectReference{}, errors.Errorf("invalid inventory entry %q with version %q", id, version)
+	}
+	return corev1.ObjectReference{
+		APIVersion: schema.GroupVersion{Group: parts[2], Version: version}.String(),
+		Kind:       parts[3],
+		Namespace:  parts[0],
+		Name:       parts[1],
+	}, nil
+}
@@ -0,0 +1,62 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// lastAppliedAnnotations are the annotations recording the last applied configuration, the one written by
+// KubeVela takes precedence over the one written by kubectl
+var lastAppliedAnnotations = []string{oam.AnnotationLastAppliedConfig, oam.AnnotationLastAppliedConfiguration}
+
+// LastAppliedConfig is the configuration last applied to a live object
+type LastAppliedConfig struct {
+	// Found is false if the object has no last applied configuration, the other fields are empty in this case
+	Found bool `json:"found"`
+	// Annotation is the annotation which the configuration is read from
+	Annotation string                 `json:"annotation,omitempty"`
+	Manifest   map[string]interface{} `json:"manifest,omitempty"`
+	YAML       string                 `json:"yaml,omitempty"`
+}
+
+// getLastAppliedConfig decodes the last applied configuration recorded in the annotations of the object
+func getLastAppliedConfig(obj *unstructured.Unstructured) (*LastAppliedConfig, error) {
+	annotations := obj.GetAnnotations()
+	for _, annotation := range lastAppliedAnnotations {
+		data := annotations[annotation]
+		if data == "" {
+			continue
+		}
+		manifest := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(data), &manifest); err != nil {
+			return nil, errors.Wrapf(err, "invalid last applied configuration in annotation %s", annotation)
+		}
+		yamlData, err := yaml.JSONToYAML([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		return &LastAppliedConfig{Found: true, Annotation: annotation, Manifest: manifest, YAML: string(yamlData)}, nil
+	}
+	return &LastAppliedConfig{Found: false}, nil
+}
@@ -0,0 +1,52 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	apis "github.com/oam-dev/kubevela/apis/types"
+)
+
+// ResourceManifest is the manifest of a live object without the noisy metadata
+type ResourceManifest struct {
+	YAML string `json:"yaml"`
+	JSON string `json:"json"`
+}
+
+// getResourceManifest renders the object in yaml and json, the managed fields and the annotations in
+// DefaultFilterAnnots are stripped
+func getResourceManifest(obj *unstructured.Unstructured) (*ResourceManifest, error) {
+	clean := obj.DeepCopy()
+	clean.SetManagedFields(nil)
+	if annotations := clean.GetAnnotations(); annotations != nil {
+		for _, key := range apis.DefaultFilterAnnots {
+			delete(annotations, key)
+		}
+		clean.SetAnnotations(annotations)
+	}
+	jsonData, err := clean.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	yamlData, err := yaml.JSONToYAML(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	return &ResourceManifest{YAML: string(yamlData), JSON: string(jsonData)}, nil
+}
@@ -0,0 +1,57 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import "sort"
+
+// ResourceNamespace is a namespace in a cluster holding the resources of the application
+type ResourceNamespace struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Resources int    `json:"resources"`
+	// External means the namespace is not the one of the application, such as the namespaces of the trait resources
+	External bool `json:"external,omitempty"`
+}
+
+// collectResourceNamespaces lists the distinct namespaces of the resources sorted by cluster and namespace,
+// the cluster scoped resources are left out
+func collectResourceNamespaces(resources []Resource, appNamespace string) []ResourceNamespace {
+	type key struct{ cluster, namespace string }
+	counts := map[key]int{}
+	for _, res := range resources {
+		if res.Object == nil || res.Object.GetNamespace() == "" {
+			continue
+		}
+		counts[key{cluster: normalizeClusterName(res.Cluster), namespace: res.Object.GetNamespace()}]++
+	}
+	namespaces := make([]ResourceNamespace, 0, len(counts))
+	for k, count := range counts {
+		namespaces = append(namespaces, ResourceNamespace{
+			Cluster:   k.cluster,
+			Namespace: k.namespace,
+			Resources: count,
+			External:  k.namespace != appNamespace,
+		})
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		if namespaces[i].Cluster != namespaces[j].Cluster {
+			return namespaces[i].Cluster < namespaces[j].Cluster
+		}
+		return namespaces[i].Namespace < namespaces[j].Namespace
+	})
+	return namespaces
+}
@@ -0,0 +1,90 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// nodeConditionTypes are the conditions affecting the scheduling of the pods
+var nodeConditionTypes = []corev1.NodeConditionType{
+	corev1.NodeReady, corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure,
+}
+
+// NodeConditions is the conditions and the resources of a node
+type NodeConditions struct {
+	Name string `json:"name"`
+	// Unschedulable means the node is cordoned
+	Unschedulable bool                `json:"unschedulable"`
+	Conditions    []NodeCondition     `json:"conditions"`
+	Capacity      corev1.ResourceList `json:"capacity,omitempty"`
+	Allocatable   corev1.ResourceList `json:"allocatable,omitempty"`
+}
+
+// NodeCondition is a condition of a node, the condition not reported by the kubelet has the status Unknown
+type NodeCondition struct {
+	Type               corev1.NodeConditionType `json:"type"`
+	Status             corev1.ConditionStatus   `json:"status"`
+	Reason             string                   `json:"reason,omitempty"`
+	Message            string                   `json:"message,omitempty"`
+	LastTransitionTime *metav1.Time             `json:"lastTransitionTime,omitempty"`
+}
+
+// collectNodeConditions lists the nodes of the cluster sorted by name with the conditions affecting the scheduling
+func collectNodeConditions(cli client.Client, cluster string) ([]NodeConditions, error) {
+	nodeList := corev1.NodeList{}
+	if err := cli.List(multicluster.ContextWithClusterName(context.Background(), cluster), &nodeList); err != nil {
+		return nil, err
+	}
+	nodes := make([]NodeConditions, 0, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		nodes = append(nodes, NodeConditions{
+			Name:          node.Name,
+			Unschedulable: node.Spec.Unschedulable,
+			Conditions:    getNodeConditions(node),
+			Capacity:      node.Status.Capacity,
+			Allocatable:   node.Status.Allocatable,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes, nil
+}
+
+func getNodeConditions(node corev1.Node) []NodeCondition {
+	conditions := make([]NodeCondition, 0, len(nodeConditionTypes))
+	for _, conditionType := range nodeConditionTypes {
+		condition := NodeCondition{Type: conditionType, Status: corev1.ConditionUnknown}
+		for i, c := range node.Status.Conditions {
+			if c.Type == conditionType {
+				condition.Status, condition.Reason, condition.Message = c.Status, c.Reason, c.Message
+				condition.LastTransitionTime = &node.Status.Conditions[i].LastTransitionTime
+				break
+			}
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
@@ -0,0 +1,161 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// ContainerEnv is the environment variables of a container resolved from the pod spec
+type ContainerEnv struct {
+	Name string   `json:"name"`
+	Env  []EnvVar `json:"env"`
+}
+
+// EnvVar is an environment variable of a container. The values read from the secrets are never returned,
+// only the keys are shown.
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+	// From is where the value is read from, such as configMap/<name>/<key>, secret/<name>/<key> or
+	// fieldRef/<path>, it is empty if the value is set in the spec
+	From string `json:"from,omitempty"`
+	// Redacted means the value is read from a secret and hidden
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// attachPodEnv attaches the env of the containers to each pod in the summary, the pods and the summary must be aligned.
+// The values from the ConfigMaps are resolved, only the keys of the Secrets are read.
+func attachPodEnv(cli client.Client, summary *PodsSummary, pods []*unstructured.Unstructured, cluster string) error {
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	configMaps := map[client.ObjectKey]map[string]string{}
+	getConfigMapData := func(namespace, name string) (map[string]string, error) {
+		key := client.ObjectKey{Namespace: namespace, Name: name}
+		if data, ok := configMaps[key]; ok {
+			return data, nil
+		}
+		cm := corev1.ConfigMap{}
+		if err := cli.Get(ctx, key, &cm); err != nil && !kerrors.IsNotFound(err) {
+			return nil, err
+		}
+		configMaps[key] = cm.Data
+		return cm.Data, nil
+	}
+	getSecretKeys := func(namespace, name string) ([]string, error) {
+		keys, err := getConfigKeys(ctx, cli, secretKind, namespace, name)
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return keys, err
+	}
+	for i, obj := range pods {
+		pod := corev1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pod); err != nil {
+			return err
+		}
+		containerEnv := make([]ContainerEnv, 0, len(pod.Spec.Containers))
+		for _, container := range pod.Spec.Containers {
+			env, err := resolveContainerEnv(container, func(name string) (map[string]string, error) {
+				return getConfigMapData(pod.Namespace, name)
+			}, func(name string) ([]string, error) {
+				return getSecretKeys(pod.Namespace, name)
+			})
+			if err != nil {
+				return err
+			}
+			containerEnv = append(containerEnv, ContainerEnv{Name: container.Name, Env: env})
+		}
+		summary.Pods[i].ContainerEnv = containerEnv
+	}
+	return nil
+}
+
+// resolveContainerEnv resolves the env of the container in the order of the container runtime, the variables in
+// envFrom come first and the ones in env override them
+func resolveContainerEnv(container corev1.Container, getConfigMapData func(name string) (map[string]string, error),
+	getSecretKeys func(name string) ([]string, error)) ([]EnvVar, error) {
+	env := []EnvVar{}
+	index := map[string]int{}
+	setEnv := func(v EnvVar) {
+		if i, ok := index[v.Name]; ok {
+			env[i] = v
+			return
+		}
+		index[v.Name] = len(env)
+		env = append(env, v)
+	}
+	for _, envFrom := range container.EnvFrom {
+		switch {
+		case envFrom.ConfigMapRef != nil:
+			data, err := getConfigMapData(envFrom.ConfigMapRef.Name)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range sortedStringKeys(data) {
+				setEnv(EnvVar{Name: envFrom.Prefix + key, Value: data[key], From: "configMap/" + envFrom.ConfigMapRef.Name + "/" + key})
+			}
+		case envFrom.SecretRef != nil:
+			keys, err := getSecretKeys(envFrom.SecretRef.Name)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				setEnv(EnvVar{Name: envFrom.Prefix + key, From: "secret/" + envFrom.SecretRef.Name + "/" + key, Redacted: true})
+			}
+		}
+	}
+	for _, e := range container.Env {
+		v := EnvVar{Name: e.Name, Value: e.Value}
+		switch {
+		case e.ValueFrom == nil:
+		case e.ValueFrom.ConfigMapKeyRef != nil:
+			ref := e.ValueFrom.ConfigMapKeyRef
+			data, err := getConfigMapData(ref.Name)
+			if err != nil {
+				return nil, err
+			}
+			v.Value, v.From = data[ref.Key], "configMap/"+ref.Name+"/"+ref.Key
+		case e.ValueFrom.SecretKeyRef != nil:
+			ref := e.ValueFrom.SecretKeyRef
+			v.From, v.Redacted = "secret/"+ref.Name+"/"+ref.Key, true
+		case e.ValueFrom.FieldRef != nil:
+			v.From = "fieldRef/" + e.ValueFrom.FieldRef.FieldPath
+		case e.ValueFrom.ResourceFieldRef != nil:
+			v.From = "resourceFieldRef/" + e.ValueFrom.ResourceFieldRef.Resource
+		}
+		setEnv(v)
+	}
+	return env, nil
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,389 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+	"github.com/oam-dev/kubevela/pkg/oam"
+)
+
+// DefaultPodEventsLimit is the number of the most recent events attached to each pod if includeEvents is set
+const DefaultPodEventsLimit = 5
+
+// PodsSummary summarizes the pods collected by CollectPods
+type PodsSummary struct {
+	Pods []PodSummary `json:"pods"`
+	// Total and Statuses count all the collected pods, even if some of them are filtered out by the phases
+	Total    int            `json:"total"`
+	Statuses map[string]int `json:"statuses"`
+}
+
+// PodSummary records where a pod is running
+type PodSummary struct {
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace"`
+	Phase     corev1.PodPhase `json:"phase"`
+	// Component and Revision are the component and the application revision which the pod belongs to
+	Component string `json:"component,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	// Status is computed like the STATUS column of kubectl, such as Running, Pending or CrashLoopBackOff
+	Status string `json:"status"`
+	// NodeName is empty if the pod is not scheduled yet
+	NodeName string `json:"nodeName,omitempty"`
+	// Zone and Region are read from the topology labels of the node
+	Zone   string `json:"zone,omitempty"`
+	Region string `json:"region,omitempty"`
+	// SchedulingPending means the pod is pending because no node is available for it
+	SchedulingPending bool `json:"schedulingPending"`
+	// Events are the most recent events of the pod, they are only attached if includeEvents is set
+	Events []ResourceEvent `json:"events,omitempty"`
+	// Containers are the cpu and memory configured for the containers, Requests and Limits are their sums.
+	// The init containers are not counted.
+	Containers []ContainerResources `json:"containers"`
+	Requests   corev1.ResourceList  `json:"requests,omitempty"`
+	Limits     corev1.ResourceList  `json:"limits,omitempty"`
+	// ContainerEnv is the env of the containers, it is only attached if includeEnv is set
+	ContainerEnv []ContainerEnv `json:"containerEnv,omitempty"`
+	// UnmetConditions are the conditions of the pod which are not true, such as PodScheduled with Unschedulable
+	UnmetConditions []PodConditionSummary `json:"unmetConditions,omitempty"`
+	// Terminations are the last terminations of the containers, including the init containers
+	Terminations []ContainerTermination `json:"terminations,omitempty"`
+}
+
+// PodConditionSummary is a condition of a pod with the reason and the message
+type PodConditionSummary struct {
+	Type    corev1.PodConditionType `json:"type"`
+	Reason  string                  `json:"reason,omitempty"`
+	Message string                  `json:"message,omitempty"`
+}
+
+// ContainerTermination is the last termination of a container, such as OOMKilled with the exit code 137.
+// Current is true if the container is still terminated, otherwise it is restarted after the termination.
+type ContainerTermination struct {
+	Name         string `json:"name"`
+	Reason       string `json:"reason,omitempty"`
+	Message      string `json:"message,omitempty"`
+	ExitCode     int32  `json:"exitCode"`
+	Signal       int32  `json:"signal,omitempty"`
+	RestartCount int32  `json:"restartCount"`
+	Current      bool   `json:"current"`
+}
+
+// ContainerResources is the cpu and memory requests and limits configured for a container
+type ContainerResources struct {
+	Name     string              `json:"name"`
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	Limits   corev1.ResourceList `json:"limits,omitempty"`
+}
+
+// ResourceEvent is an event of a resource
+type ResourceEvent struct {
+	Type          string      `json:"type"`
+	Reason        string      `json:"reason"`
+	Message       string      `json:"message"`
+	Count         int32       `json:"count"`
+	LastTimestamp metav1.Time `json:"lastTimestamp"`
+}
+
+// summarizePods summarizes the pods in the cluster, the nodes are listed once for all the pods
+func summarizePods(cli client.Client, objs []*unstructured.Unstructured, cluster string) (*PodsSummary, error) {
+	pods := make([]corev1.Pod, len(objs))
+	scheduled := false
+	for i, obj := range objs {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pods[i]); err != nil {
+			return nil, err
+		}
+		scheduled = scheduled || pods[i].Spec.NodeName != ""
+	}
+	nodeLabels := map[string]map[string]string{}
+	if scheduled {
+		nodes := corev1.NodeList{}
+		if err := cli.List(multicluster.ContextWithClusterName(context.Background(), cluster), &nodes); err != nil {
+			return nil, err
+		}
+		for _, node := range nodes.Items {
+			nodeLabels[node.Name] = node.Labels
+		}
+	}
+	summary := &PodsSummary{Pods: make([]PodSummary, 0, len(pods)), Total: len(pods), Statuses: map[string]int{}}
+	for _, pod := range pods {
+		status := getPodStatus(pod)
+		summary.Statuses[status]++
+		containers, requests, limits := getPodResources(pod)
+		summary.Pods = append(summary.Pods, PodSummary{
+			Name:              pod.Name,
+			Namespace:         pod.Namespace,
+			Phase:             pod.Status.Phase,
+			Component:         pod.Labels[oam.LabelAppComponent],
+			Revision:          pod.Labels[oam.LabelAppRevision],
+			Status:            status,
+			NodeName:          pod.Spec.NodeName,
+			Zone:              nodeLabels[pod.Spec.NodeName][corev1.LabelTopologyZone],
+			Region:            nodeLabels[pod.Spec.NodeName][corev1.LabelTopologyRegion],
+			SchedulingPending: isPodSchedulingPending(pod),
+			Containers:        containers,
+			Requests:          requests,
+			Limits:            limits,
+			UnmetConditions:   getPodUnmetConditions(pod),
+			Terminations:      getContainerTerminations(pod),
+		})
+	}
+	return summary, nil
+}
+
+// setPodsOwner sets the component and revision of the pods from the workload owning them,
+// the labels of the pod take precedence since the pod template may not carry the labels of the application
+func setPodsOwner(summary *PodsSummary, workload *unstructured.Unstructured) {
+	labels := workload.GetLabels()
+	for i := range summary.Pods {
+		if summary.Pods[i].Component == "" {
+			summary.Pods[i].Component = labels[oam.LabelAppComponent]
+		}
+		if summary.Pods[i].Revision == "" {
+			summary.Pods[i].Revision = labels[oam.LabelAppRevision]
+		}
+	}
+}
+
+// getPodResources reads the cpu and memory requests and limits of the containers from the pod spec and sums them
+func getPodResources(pod corev1.Pod) (containers []ContainerResources, requests, limits corev1.ResourceList) {
+	containers = make([]ContainerResources, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		res := ContainerResources{Name: container.Name}
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if quantity, ok := container.Resources.Requests[name]; ok {
+				res.Requests = addQuantity(res.Requests, name, quantity)
+				requests = addQuantity(requests, name, quantity)
+			}
+			if quantity, ok := container.Resources.Limits[name]; ok {
+				res.Limits = addQuantity(res.Limits, name, quantity)
+				limits = addQuantity(limits, name, quantity)
+			}
+		}
+		containers = append(containers, res)
+	}
+	return containers, requests, limits
+}
+
+// addQuantity adds the quantity to the resource in the list, the list is created if it is nil
+func addQuantity(list corev1.ResourceList, name corev1.ResourceName, quantity resource.Quantity) corev1.ResourceList {
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+	sum := list[name]
+	sum.Add(quantity)
+	list[name] = sum
+	return list
+}
+
+func getPodUnmetConditions(pod corev1.Pod) []PodConditionSummary {
+	var conditions []PodConditionSummary
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			conditions = append(conditions, PodConditionSummary{Type: condition.Type, Reason: condition.Reason, Message: condition.Message})
+		}
+	}
+	return conditions
+}
+
+// getContainerTerminations reads the current termination of the containers, or the last one if they are restarted
+func getContainerTerminations(pod corev1.Pod) []ContainerTermination {
+	var terminations []ContainerTermination
+	for _, cs := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		terminated, current := cs.State.Terminated, true
+		if terminated == nil {
+			terminated, current = cs.LastTerminationState.Terminated, false
+		}
+		if terminated == nil {
+			continue
+		}
+		terminations = append(terminations, ContainerTermination{
+			Name:         cs.Name,
+			Reason:       terminated.Reason,
+			Message:      terminated.Message,
+			ExitCode:     terminated.ExitCode,
+			Signal:       terminated.Signal,
+			RestartCount: cs.RestartCount,
+			Current:      current,
+		})
+	}
+	return terminations
+}
+
+func isPodSchedulingPending(pod corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodPending {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled {
+			return condition.Status == corev1.ConditionFalse
+		}
+	}
+	return false
+}
+
+// getPodStatus computes the status of the pod like the STATUS column of kubectl
+func getPodStatus(pod corev1.Pod) string {
+	if pod.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" && cs.State.Waiting.Reason != "PodInitializing" {
+			return "Init:" + cs.State.Waiting.Reason
+		}
+		if cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0 {
+			return "Init:Error"
+		}
+	}
+	status := string(pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		status = pod.Status.Reason
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		switch {
+		case cs.State.Waiting != nil && cs.State.Waiting.Reason != "":
+			status = cs.State.Waiting.Reason
+		case cs.State.Terminated != nil && cs.State.Terminated.Reason != "":
+			status = cs.State.Terminated.Reason
+		}
+	}
+	if status == "" {
+		return string(corev1.PodUnknown)
+	}
+	return status
+}
+
+// filterPodsByStatus keeps the pods whose status is one of the statuses, the counts in the summary are not changed
+func filterPodsByStatus(pods []*unstructured.Unstructured, summary *PodsSummary, statuses []string) []*unstructured.Unstructured {
+	if len(statuses) == 0 {
+		return pods
+	}
+	filtered := make([]*unstructured.Unstructured, 0, len(pods))
+	summaries := make([]PodSummary, 0, len(pods))
+	for i, pod := range summary.Pods {
+		for _, status := range statuses {
+			if strings.EqualFold(pod.Status, status) {
+				filtered = append(filtered, pods[i])
+				summaries = append(summaries, pod)
+				break
+			}
+		}
+	}
+	summary.Pods = summaries
+	return filtered
+}
+
+// attachPodEvents attaches the most recent events to each pod in the summary, the events are listed once per namespace
+func attachPodEvents(cli client.Client, summary *PodsSummary, cluster string, limit int) error {
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	events := map[string]map[string][]ResourceEvent{}
+	for i, pod := range summary.Pods {
+		if _, ok := events[pod.Namespace]; !ok {
+			eventList := corev1.EventList{}
+			if err := cli.List(ctx, &eventList, client.InNamespace(pod.Namespace), client.MatchingFieldsSelector{
+				Selector: fields.OneTermEqualSelector("involvedObject.kind", "Pod"),
+			}); err != nil {
+				return err
+			}
+			events[pod.Namespace] = groupPodEvents(eventList.Items)
+		}
+		podEvents := events[pod.Namespace][pod.Name]
+		if len(podEvents) > limit {
+			podEvents = podEvents[:limit]
+		}
+		summary.Pods[i].Events = podEvents
+	}
+	return nil
+}
+
+// groupPodEvents groups the events by the name of the pod, the most recent events come first
+func groupPodEvents(items []corev1.Event) map[string][]ResourceEvent {
+	sortEventsByTime(items)
+	grouped := map[string][]ResourceEvent{}
+	for _, event := range items {
+		grouped[event.InvolvedObject.Name] = append(grouped[event.InvolvedObject.Name], newResourceEvent(event))
+	}
+	return grouped
+}
+
+// sortEventsByTime sorts the events so that the most recent events come first
+func sortEventsByTime(items []corev1.Event) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return getEventTime(items[j]).Before(getEventTime(items[i]))
+	})
+}
+
+// filterEventsByReason keeps the events of the reasons, all the events are kept if the reasons are empty
+func filterEventsByReason(items []corev1.Event, reasons []string) []corev1.Event {
+	if len(reasons) == 0 {
+		return items
+	}
+	filtered := make([]corev1.Event, 0, len(items))
+	for _, item := range items {
+		for _, reason := range reasons {
+			if item.Reason == reason {
+				filtered = append(filtered, item)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// limitRecentEvents keeps the most recent limit events, the most recent events come first
+func limitRecentEvents(items []corev1.Event, limit int) []corev1.Event {
+	sortEventsByTime(items)
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+func newResourceEvent(event corev1.Event) ResourceEvent {
+	return ResourceEvent{
+		Type:          event.Type,
+		Reason:        event.Reason,
+		Message:       event.Message,
+		Count:         event.Count,
+		LastTimestamp: metav1.NewTime(getEventTime(event)),
+	}
+}
+
+// getEventTime returns the last time the event happened, the events reported by the new events API only set the eventTime
+func getEventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
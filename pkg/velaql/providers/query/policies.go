@@ -0,0 +1,97 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/oam/util"
+	"github.com/oam-dev/kubevela/pkg/policy/envbinding"
+)
+
+// AppPolicy is a policy of the application with its decoded properties
+type AppPolicy struct {
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	// Envs are the environments of an env-binding policy, they are empty for the other policies
+	Envs []EnvPolicy `json:"envs,omitempty"`
+}
+
+// EnvPolicy is an environment of an env-binding policy and what it resolves to
+type EnvPolicy struct {
+	Name      string                `json:"name"`
+	Placement v1alpha1.EnvPlacement `json:"placement"`
+	// Decisions are where the environment is placed, they are empty until the environment is deployed
+	Decisions []v1alpha1.PlacementDecision `json:"decisions,omitempty"`
+	// Components are the components deployed to the environment, merged with the patch of the environment
+	Components []common.ApplicationComponent `json:"components"`
+}
+
+// collectPolicies decodes the policies of the application, and resolves the components of each environment
+// for the env-binding policies
+func collectPolicies(app *v1beta1.Application) ([]AppPolicy, error) {
+	policies := make([]AppPolicy, 0, len(app.Spec.Policies))
+	for _, policy := range app.Spec.Policies {
+		properties, err := util.RawExtension2Map(policy.Properties)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid properties of policy %s", policy.Name)
+		}
+		p := AppPolicy{Name: policy.Name, Type: policy.Type, Properties: properties}
+		if policy.Type == v1alpha1.EnvBindingPolicyType {
+			if p.Envs, err = collectEnvPolicies(app, policy.Name); err != nil {
+				return nil, err
+			}
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func collectEnvPolicies(app *v1beta1.Application, policyName string) ([]EnvPolicy, error) {
+	spec, err := envbinding.GetEnvBindingPolicy(app, policyName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid env-binding policy %s", policyName)
+	}
+	status, err := envbinding.GetEnvBindingPolicyStatus(app, policyName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid status of env-binding policy %s", policyName)
+	}
+	decisions := map[string][]v1alpha1.PlacementDecision{}
+	if status != nil {
+		for _, env := range status.Envs {
+			decisions[env.Env] = env.Placements
+		}
+	}
+	envs := make([]EnvPolicy, 0, len(spec.Envs))
+	for _, env := range spec.Envs {
+		patched, err := envbinding.PatchApplication(app, &env.Patch, env.Selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to patch env %s of policy %s", env.Name, policyName)
+		}
+		envs = append(envs, EnvPolicy{
+			Name:       env.Name,
+			Placement:  env.Placement,
+			Decisions:  decisions[env.Name],
+			Components: patched.Spec.Components,
+		})
+	}
+	return envs, nil
+}
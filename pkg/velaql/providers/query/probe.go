@@ -0,0 +1,99 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// endpointProbeTimeout is the time to wait for an endpoint to respond, the endpoints are probed in parallel
+var endpointProbeTimeout = 5 * time.Second
+
+// EndpointProbe is the result of probing an HTTP(S) endpoint
+type EndpointProbe struct {
+	// Reachable means the endpoint responds, whatever the status code is
+	Reachable  bool   `json:"reachable"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// probeEndpoints sends a HEAD request to each HTTP(S) endpoint which is not pending, a GET request is sent if
+// the HEAD method is not allowed. The endpoints of the other protocols are skipped and have no probe.
+func probeEndpoints(ctx context.Context, endpoints []ServiceEndpoint) {
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			// the probe checks whether the endpoint responds, the self-signed certificates are accepted
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		},
+		// the redirects are not followed, a redirect response means the endpoint is reachable
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	defer httpClient.CloseIdleConnections()
+	var wg sync.WaitGroup
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if endpoint.Pending || !isHTTPEndpoint(endpoint) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			endpoint.Probe = probeEndpoint(ctx, httpClient, endpoint.String())
+		}()
+	}
+	wg.Wait()
+}
+
+func isHTTPEndpoint(endpoint *ServiceEndpoint) bool {
+	if endpoint.Endpoint.AppProtocol == nil {
+		return false
+	}
+	protocol := *endpoint.Endpoint.AppProtocol
+	return protocol == "http" || protocol == "https"
+}
+
+func probeEndpoint(ctx context.Context, httpClient *http.Client, url string) *EndpointProbe {
+	ctx, cancel := context.WithTimeout(ctx, endpointProbeTimeout)
+	defer cancel()
+	statusCode, err := sendProbeRequest(ctx, httpClient, http.MethodHead, url)
+	if err == nil && (statusCode == http.StatusMethodNotAllowed || statusCode == http.StatusNotImplemented) {
+		statusCode, err = sendProbeRequest(ctx, httpClient, http.MethodGet, url)
+	}
+	if err != nil {
+		return &EndpointProbe{Reachable: false, Message: err.Error()}
+	}
+	return &EndpointProbe{Reachable: true, StatusCode: statusCode}
+}
+
+func sendProbeRequest(ctx context.Context, httpClient *http.Client, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,133 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+	"github.com/oam-dev/kubevela/pkg/resourcetracker"
+)
+
+// applyComponentStepType is the type of the workflow step which applies a single component
+const applyComponentStepType = "apply-component"
+
+// ResourceProvenance records where a resource of the application comes from
+type ResourceProvenance struct {
+	Component         string `json:"component"`
+	ComponentRevision string `json:"componentRevision,omitempty"`
+	AppRevision       string `json:"appRevision,omitempty"`
+	Env               string `json:"env,omitempty"`
+	// ResourceType is WORKLOAD or TRAIT, Trait is the type of the trait if the resource is created by a trait
+	ResourceType string `json:"resourceType,omitempty"`
+	Trait        string `json:"trait,omitempty"`
+	// WorkflowStep is the apply-component step which applies the component, it is empty if the component is
+	// applied by the default workflow or by a step applying all the components
+	WorkflowStep string `json:"workflowStep,omitempty"`
+	// PublishVersion and ResourceTracker are read from the latest resource tracker which records the resource
+	PublishVersion  string `json:"publishVersion,omitempty"`
+	ResourceTracker string `json:"resourceTracker,omitempty"`
+}
+
+// collectResourceProvenance reads the provenance of the resource from its labels and the resource trackers of the application
+func collectResourceProvenance(cli client.Client, app *v1beta1.Application, obj *unstructured.Unstructured, cluster string) (*ResourceProvenance, error) {
+	labels := obj.GetLabels()
+	provenance := &ResourceProvenance{
+		Component:         labels[oam.LabelAppComponent],
+		ComponentRevision: labels[oam.LabelAppComponentRevision],
+		AppRevision:       labels[oam.LabelAppRevision],
+		Env:               labels[oam.LabelAppEnv],
+		ResourceType:      labels[oam.LabelOAMResourceType],
+		Trait:             labels[oam.TraitTypeLabel],
+	}
+	rootRT, currentRT, historyRTs, _, err := resourcetracker.ListApplicationResourceTrackers(context.Background(), cli, app)
+	if err != nil {
+		return nil, err
+	}
+	// the latest resource tracker which records the resource comes first, the history ones are sorted by version
+	rts := []*v1beta1.ResourceTracker{currentRT}
+	for i := len(historyRTs) - 1; i >= 0; i-- {
+		rts = append(rts, historyRTs[i])
+	}
+	rts = append(rts, rootRT)
+	target := v1beta1.ManagedResource{}
+	target.Cluster = normalizeClusterName(cluster)
+	target.APIVersion, target.Kind = obj.GetAPIVersion(), obj.GetKind()
+	target.Namespace, target.Name = obj.GetNamespace(), obj.GetName()
+	for _, rt := range rts {
+		if rt == nil {
+			continue
+		}
+		mr, found := findManagedResource(rt, target)
+		if !found {
+			continue
+		}
+		provenance.ResourceTracker = rt.Name
+		provenance.PublishVersion = rt.GetAnnotations()[oam.AnnotationPublishVersion]
+		if provenance.Component == "" {
+			provenance.Component = mr.Component
+		}
+		if provenance.Trait == "" {
+			provenance.Trait = mr.Trait
+		}
+		if provenance.Env == "" {
+			provenance.Env = mr.Env
+		}
+		break
+	}
+	provenance.WorkflowStep = findApplyComponentStep(app, provenance.Component)
+	return provenance, nil
+}
+
+// findManagedResource finds the target in the resource tracker, the cluster of the target must be normalized
+func findManagedResource(rt *v1beta1.ResourceTracker, target v1beta1.ManagedResource) (v1beta1.ManagedResource, bool) {
+	for _, mr := range rt.Spec.ManagedResources {
+		key := mr
+		key.Cluster = normalizeClusterName(mr.Cluster)
+		if key.ResourceKey() == target.ResourceKey() {
+			return mr, true
+		}
+	}
+	return v1beta1.ManagedResource{}, false
+}
+
+// findApplyComponentStep finds the apply-component step of the component in the workflow of the application
+func findApplyComponentStep(app *v1beta1.Application, component string) string {
+	if app.Spec.Workflow == nil || component == "" {
+		return ""
+	}
+	for _, step := range app.Spec.Workflow.Steps {
+		if step.Type != applyComponentStepType || step.Properties == nil {
+			continue
+		}
+		properties := struct {
+			Component string `json:"component"`
+		}{}
+		if err := json.Unmarshal(step.Properties.Raw, &properties); err != nil {
+			continue
+		}
+		if properties.Component == component {
+			return step.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// PVCStatus is the status of a PersistentVolumeClaim used by the workload
+type PVCStatus struct {
+	Name  string                            `json:"name"`
+	Phase corev1.PersistentVolumeClaimPhase `json:"phase"`
+	// Capacity is the actual storage of the bound volume, it is empty before the claim is bound
+	Capacity     string `json:"capacity,omitempty"`
+	VolumeName   string `json:"volumeName,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// collectPVCs collects the PersistentVolumeClaims referred by the volumes of the pods of the workload,
+// the claims of the volumeClaimTemplates are included for a StatefulSet even if its pods are not created yet
+func collectPVCs(cli client.Client, obj *unstructured.Unstructured, cluster string) ([]PVCStatus, error) {
+	collector := NewPodCollector(obj.GroupVersionKind())
+	if isHelmRelease(obj.GroupVersionKind()) {
+		collector = helmReleasePodCollector
+	}
+	pods, err := collector(cli, obj, cluster)
+	if err != nil {
+		return nil, err
+	}
+	claims := map[string]bool{}
+	for _, item := range pods {
+		pod := corev1.Pod{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &pod); err != nil {
+			return nil, err
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				claims[volume.PersistentVolumeClaim.ClaimName] = true
+			}
+		}
+	}
+	if obj.GroupVersionKind() == appsv1.SchemeGroupVersion.WithKind(reflect.TypeOf(appsv1.StatefulSet{}).Name()) {
+		sts := appsv1.StatefulSet{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &sts); err != nil {
+			return nil, err
+		}
+		for _, name := range getStatefulSetClaimNames(sts) {
+			claims[name] = true
+		}
+	}
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	statuses := []PVCStatus{}
+	for _, name := range names {
+		pvc := corev1.PersistentVolumeClaim{}
+		if err = cli.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: name}, &pvc); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		statuses = append(statuses, newPVCStatus(pvc))
+	}
+	return statuses, nil
+}
+
+// getStatefulSetClaimNames returns the names of the claims created from the volumeClaimTemplates for each replica,
+// they are in the format of <template>-<statefulset>-<ordinal>
+func getStatefulSetClaimNames(sts appsv1.StatefulSet) []string {
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	var names []string
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		for i := int32(0); i < replicas; i++ {
+			names = append(names, fmt.Sprintf("%s-%s-%d", template.Name, sts.Name, i))
+		}
+	}
+	return names
+}
+
+func newPVCStatus(pvc corev1.PersistentVolumeClaim) PVCStatus {
+	status := PVCStatus{Name: pvc.Name, Phase: pvc.Status.Phase, VolumeName: pvc.Spec.VolumeName}
+	if storage, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+		status.Capacity = storage.String()
+	}
+	if pvc.Spec.StorageClassName != nil {
+		status.StorageClass = *pvc.Spec.StorageClassName
+	}
+	return status
+}
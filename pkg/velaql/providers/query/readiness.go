@@ -0,0 +1,89 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+)
+
+// AppReadiness is the readiness of the application computed from the health of its components.
+// Score is the percentage of the ready services from 0 to 100, it is 0 if no service is reported yet.
+type AppReadiness struct {
+	Score      int                  `json:"score"`
+	Ready      int                  `json:"ready"`
+	Total      int                  `json:"total"`
+	Components []ComponentReadiness `json:"components"`
+}
+
+// ComponentReadiness is the readiness of a component, a component deployed to several envs has a service in each env
+type ComponentReadiness struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	Ready int    `json:"ready"`
+	Total int    `json:"total"`
+	// Message is the message of the first service which is not ready
+	Message string `json:"message,omitempty"`
+}
+
+// collectAppReadiness computes the readiness of the application from the services in the status,
+// a service is ready if both the workload and all its traits are healthy
+func collectAppReadiness(status common.AppStatus) *AppReadiness {
+	readiness := &AppReadiness{Components: []ComponentReadiness{}}
+	index := map[string]int{}
+	for _, svc := range status.Services {
+		i, ok := index[svc.Name]
+		if !ok {
+			i = len(readiness.Components)
+			index[svc.Name] = i
+			readiness.Components = append(readiness.Components, ComponentReadiness{Name: svc.Name})
+		}
+		comp := &readiness.Components[i]
+		comp.Total++
+		readiness.Total++
+		if ready, message := isServiceReady(svc); ready {
+			comp.Ready++
+			readiness.Ready++
+		} else if comp.Message == "" {
+			comp.Message = message
+		}
+	}
+	for i := range readiness.Components {
+		readiness.Components[i].Score = readinessScore(readiness.Components[i].Ready, readiness.Components[i].Total)
+	}
+	readiness.Score = readinessScore(readiness.Ready, readiness.Total)
+	return readiness
+}
+
+func isServiceReady(svc common.ApplicationComponentStatus) (bool, string) {
+	if !svc.Healthy {
+		return false, svc.Message
+	}
+	for _, trait := range svc.Traits {
+		if !trait.Healthy {
+			return false, trait.Message
+		}
+	}
+	return true, ""
+}
+
+// readinessScore is the percentage rounded down, so the score is 100 only if all are ready
+func readinessScore(ready, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return ready * 100 / total
+}
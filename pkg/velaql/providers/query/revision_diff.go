@@ -0,0 +1,221 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+	"github.com/oam-dev/kubevela/pkg/oam/util"
+)
+
+// RevisionDiff is the difference between the latest revision of the application and the previous one
+type RevisionDiff struct {
+	Current string `json:"current"`
+	// Previous is empty if the application has only one revision, all the components are added in this case
+	Previous           string          `json:"previous,omitempty"`
+	AddedComponents    []string        `json:"addedComponents"`
+	RemovedComponents  []string        `json:"removedComponents"`
+	ModifiedComponents []ComponentDiff `json:"modifiedComponents"`
+}
+
+// ComponentDiff is the difference of a component between two revisions
+type ComponentDiff struct {
+	Name string `json:"name"`
+	// PreviousType is set only if the type of the component is changed
+	PreviousType string           `json:"previousType,omitempty"`
+	Type         string           `json:"type"`
+	Properties   []PropertyChange `json:"properties"`
+	AddedTraits  []string         `json:"addedTraits"`
+	// RemovedTraits and ModifiedTraits are the types of the traits
+	RemovedTraits  []string `json:"removedTraits"`
+	ModifiedTraits []string `json:"modifiedTraits"`
+}
+
+// PropertyChange is a changed property of the component, the path is joined by dots such as resources.cpu.
+// Old is nil if the property is added and New is nil if the property is removed.
+type PropertyChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// collectRevisionDiff diffs the latest revision of the application with the previous one
+func collectRevisionDiff(cli client.Client, app *v1beta1.Application) (*RevisionDiff, error) {
+	if app.Status.LatestRevision == nil {
+		return nil, errors.Errorf("application %s/%s has no revision yet", app.Namespace, app.Name)
+	}
+	ctx := context.Background()
+	current := &v1beta1.ApplicationRevision{}
+	if err := cli.Get(ctx, client.ObjectKey{Name: app.Status.LatestRevision.Name, Namespace: app.Namespace}, current); err != nil {
+		return nil, err
+	}
+	revisions := &v1beta1.ApplicationRevisionList{}
+	if err := cli.List(ctx, revisions, client.InNamespace(app.Namespace), client.MatchingLabels{oam.LabelAppName: app.Name}); err != nil {
+		return nil, err
+	}
+	previous := findPreviousRevision(revisions.Items, current.Name)
+	var previousComponents []common.ApplicationComponent
+	diff := &RevisionDiff{Current: current.Name}
+	if previous != nil {
+		diff.Previous = previous.Name
+		previousComponents = previous.Spec.Application.Spec.Components
+	}
+	if err := diffComponents(diff, previousComponents, current.Spec.Application.Spec.Components); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// findPreviousRevision finds the revision with the largest number before the current one
+func findPreviousRevision(revisions []v1beta1.ApplicationRevision, current string) *v1beta1.ApplicationRevision {
+	currentNum, err := util.ExtractRevisionNum(current, "-")
+	if err != nil {
+		return nil
+	}
+	var previous *v1beta1.ApplicationRevision
+	previousNum := 0
+	for i, rev := range revisions {
+		num, err := util.ExtractRevisionNum(rev.Name, "-")
+		if err != nil || num >= currentNum || num <= previousNum {
+			continue
+		}
+		previous, previousNum = &revisions[i], num
+	}
+	return previous
+}
+
+func diffComponents(diff *RevisionDiff, previous, current []common.ApplicationComponent) error {
+	diff.AddedComponents, diff.RemovedComponents, diff.ModifiedComponents = []string{}, []string{}, []ComponentDiff{}
+	previousComps := map[string]common.ApplicationComponent{}
+	for _, comp := range previous {
+		previousComps[comp.Name] = comp
+	}
+	currentComps := map[string]bool{}
+	for _, comp := range current {
+		currentComps[comp.Name] = true
+		prev, ok := previousComps[comp.Name]
+		if !ok {
+			diff.AddedComponents = append(diff.AddedComponents, comp.Name)
+			continue
+		}
+		compDiff, err := diffComponent(prev, comp)
+		if err != nil {
+			return errors.Wrapf(err, "failed to diff component %s", comp.Name)
+		}
+		if compDiff != nil {
+			diff.ModifiedComponents = append(diff.ModifiedComponents, *compDiff)
+		}
+	}
+	for _, comp := range previous {
+		if !currentComps[comp.Name] {
+			diff.RemovedComponents = append(diff.RemovedComponents, comp.Name)
+		}
+	}
+	return nil
+}
+
+// diffComponent returns nil if the type, properties and traits of the component are not changed
+func diffComponent(previous, current common.ApplicationComponent) (*ComponentDiff, error) {
+	compDiff := &ComponentDiff{Name: current.Name, Type: current.Type, AddedTraits: []string{}, RemovedTraits: []string{}, ModifiedTraits: []string{}}
+	if previous.Type != current.Type {
+		compDiff.PreviousType = previous.Type
+	}
+	previousProps, err := util.RawExtension2Map(previous.Properties)
+	if err != nil {
+		return nil, err
+	}
+	currentProps, err := util.RawExtension2Map(current.Properties)
+	if err != nil {
+		return nil, err
+	}
+	compDiff.Properties = diffProperties("", previousProps, currentProps)
+
+	previousTraits := map[string]*runtime.RawExtension{}
+	for _, trait := range previous.Traits {
+		previousTraits[trait.Type] = trait.Properties
+	}
+	currentTraits := map[string]bool{}
+	for _, trait := range current.Traits {
+		currentTraits[trait.Type] = true
+		prevProps, ok := previousTraits[trait.Type]
+		if !ok {
+			compDiff.AddedTraits = append(compDiff.AddedTraits, trait.Type)
+			continue
+		}
+		prev, err := util.RawExtension2Map(prevProps)
+		if err != nil {
+			return nil, err
+		}
+		cur, err := util.RawExtension2Map(trait.Properties)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(prev, cur) {
+			compDiff.ModifiedTraits = append(compDiff.ModifiedTraits, trait.Type)
+		}
+	}
+	for _, trait := range previous.Traits {
+		if !currentTraits[trait.Type] {
+			compDiff.RemovedTraits = append(compDiff.RemovedTraits, trait.Type)
+		}
+	}
+	if compDiff.PreviousType == "" && len(compDiff.Properties) == 0 && len(compDiff.AddedTraits) == 0 &&
+		len(compDiff.RemovedTraits) == 0 && len(compDiff.ModifiedTraits) == 0 {
+		return nil, nil
+	}
+	return compDiff, nil
+}
+
+// diffProperties compares the properties recursively, the lists are compared as a whole
+func diffProperties(prefix string, previous, current map[string]interface{}) []PropertyChange {
+	changes := []PropertyChange{}
+	var keys []string
+	for k := range previous {
+		keys = append(keys, k)
+	}
+	for k := range current {
+		if _, ok := previous[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		prev, cur := previous[k], current[k]
+		prevMap, prevIsMap := prev.(map[string]interface{})
+		curMap, curIsMap := cur.(map[string]interface{})
+		switch {
+		case prevIsMap && curIsMap:
+			changes = append(changes, diffProperties(path, prevMap, curMap)...)
+		case !reflect.DeepEqual(prev, cur):
+			changes = append(changes, PropertyChange{Path: path, Old: prev, New: cur})
+		}
+	}
+	return changes
+}
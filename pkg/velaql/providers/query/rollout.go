@@ -0,0 +1,122 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// deploymentRevisionAnnotation is the revision of the latest ReplicaSet recorded by the deployment controller
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// WorkloadRollout is the rollout progress of a Deployment, a StatefulSet or a DaemonSet read from its status.
+// For a DaemonSet the replicas are the nodes which should run the daemon pod.
+type WorkloadRollout struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Desired     int32  `json:"desired"`
+	Updated     int32  `json:"updated"`
+	Ready       int32  `json:"ready"`
+	Available   int32  `json:"available"`
+	Unavailable int32  `json:"unavailable"`
+	// CurrentRevision and UpdateRevision are the revisions of the old and the new pods. The Deployment only records
+	// the revision of its latest ReplicaSet in UpdateRevision, and the DaemonSet records none.
+	CurrentRevision string `json:"currentRevision,omitempty"`
+	UpdateRevision  string `json:"updateRevision,omitempty"`
+	// Complete means the latest spec is observed and all the desired replicas are updated and available
+	Complete bool `json:"complete"`
+}
+
+// getWorkloadRollout reads the rollout progress of the workload from the live object in the cluster,
+// nil is returned if the workload is not a Deployment, a StatefulSet or a DaemonSet, or it doesn't exist
+func getWorkloadRollout(cli client.Client, workload *unstructured.Unstructured, cluster string) (*WorkloadRollout, error) {
+	gvk := workload.GroupVersionKind()
+	if gvk.Group != appsv1.GroupName {
+		return nil, nil
+	}
+	switch gvk.Kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+	default:
+		return nil, nil
+	}
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(gvk)
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	if err := cli.Get(ctx, client.ObjectKeyFromObject(workload), live); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	rollout := &WorkloadRollout{Kind: gvk.Kind, Name: live.GetName()}
+	var observed bool
+	switch gvk.Kind {
+	case "Deployment":
+		deploy := appsv1.Deployment{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(live.Object, &deploy); err != nil {
+			return nil, err
+		}
+		rollout.Desired = 1
+		if deploy.Spec.Replicas != nil {
+			rollout.Desired = *deploy.Spec.Replicas
+		}
+		rollout.Updated, rollout.Ready = deploy.Status.UpdatedReplicas, deploy.Status.ReadyReplicas
+		rollout.Available, rollout.Unavailable = deploy.Status.AvailableReplicas, deploy.Status.UnavailableReplicas
+		rollout.UpdateRevision = deploy.Annotations[deploymentRevisionAnnotation]
+		observed = deploy.Status.ObservedGeneration >= deploy.Generation
+	case "StatefulSet":
+		sts := appsv1.StatefulSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(live.Object, &sts); err != nil {
+			return nil, err
+		}
+		rollout.Desired = 1
+		if sts.Spec.Replicas != nil {
+			rollout.Desired = *sts.Spec.Replicas
+		}
+		rollout.Updated, rollout.Ready = sts.Status.UpdatedReplicas, sts.Status.ReadyReplicas
+		rollout.Available = sts.Status.AvailableReplicas
+		rollout.Unavailable = nonNegative(rollout.Desired - rollout.Available)
+		rollout.CurrentRevision, rollout.UpdateRevision = sts.Status.CurrentRevision, sts.Status.UpdateRevision
+		observed = sts.Status.ObservedGeneration >= sts.Generation
+	case "DaemonSet":
+		ds := appsv1.DaemonSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(live.Object, &ds); err != nil {
+			return nil, err
+		}
+		rollout.Desired, rollout.Updated = ds.Status.DesiredNumberScheduled, ds.Status.UpdatedNumberScheduled
+		rollout.Ready, rollout.Available = ds.Status.NumberReady, ds.Status.NumberAvailable
+		rollout.Unavailable = ds.Status.NumberUnavailable
+		observed = ds.Status.ObservedGeneration >= ds.Generation
+	}
+	rollout.Complete = observed && rollout.Updated >= rollout.Desired && rollout.Available >= rollout.Desired
+	return rollout, nil
+}
+
+func nonNegative(n int32) int32 {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
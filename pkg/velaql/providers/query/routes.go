@@ -0,0 +1,202 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"strconv"
+
+	networkv1 "k8s.io/api/networking/v1"
+	networkv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// gatewayAPIGroup is the group of the HTTPRoute of the Gateway API
+	gatewayAPIGroup = "gateway.networking.k8s.io"
+	// defaultHTTPRoutePathType is the path type of the HTTPRoute rules without path match
+	defaultHTTPRoutePathType = "PathPrefix"
+)
+
+// RoutingRule is a flattened rule of an Ingress or an HTTPRoute routing a host and a path to a backend service.
+// The empty host matches all the hosts, and the rule of the default backend of an Ingress has neither host nor path.
+type RoutingRule struct {
+	Kind           string `json:"kind"`
+	Name           string `json:"name"`
+	Namespace      string `json:"namespace"`
+	Cluster        string `json:"cluster"`
+	Component      string `json:"component,omitempty"`
+	Host           string `json:"host"`
+	Path           string `json:"path"`
+	PathType       string `json:"pathType,omitempty"`
+	BackendService string `json:"backendService"`
+	// BackendPort is the number or the name of the port of the backend service
+	BackendPort string `json:"backendPort,omitempty"`
+}
+
+// collectRoutingRules flattens the rules of the Ingresses and the HTTPRoutes in the resources, one row for each
+// host, path and backend
+func collectRoutingRules(resources []Resource) ([]RoutingRule, error) {
+	rules := []RoutingRule{}
+	for _, res := range resources {
+		gvk := res.Object.GroupVersionKind()
+		var rows []RoutingRule
+		var err error
+		switch {
+		case gvk.Kind == "Ingress" && gvk.Group == networkv1.GroupName && gvk.Version == "v1":
+			rows, err = getIngressV1Rules(res.Object)
+		case gvk.Kind == "Ingress" && (gvk.Group == networkv1beta1.GroupName || gvk.Group == "extensions"):
+			rows, err = getIngressV1beta1Rules(res.Object)
+		case gvk.Kind == "HTTPRoute" && gvk.Group == gatewayAPIGroup:
+			rows = getHTTPRouteRules(res.Object)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			row.Kind, row.Name, row.Namespace = gvk.Kind, res.Object.GetName(), res.Object.GetNamespace()
+			row.Cluster, row.Component = res.Cluster, res.Component
+			rules = append(rules, row)
+		}
+	}
+	return rules, nil
+}
+
+func getIngressV1Rules(obj *unstructured.Unstructured) ([]RoutingRule, error) {
+	ingress := networkv1.Ingress{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &ingress); err != nil {
+		return nil, err
+	}
+	backend := func(b networkv1.IngressBackend) (string, string) {
+		if b.Service == nil {
+			return "", ""
+		}
+		if b.Service.Port.Name != "" {
+			return b.Service.Name, b.Service.Port.Name
+		}
+		return b.Service.Name, strconv.Itoa(int(b.Service.Port.Number))
+	}
+	var rows []RoutingRule
+	if ingress.Spec.DefaultBackend != nil {
+		service, port := backend(*ingress.Spec.DefaultBackend)
+		rows = append(rows, RoutingRule{BackendService: service, BackendPort: port})
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			row := RoutingRule{Host: rule.Host, Path: path.Path}
+			if path.PathType != nil {
+				row.PathType = string(*path.PathType)
+			}
+			row.BackendService, row.BackendPort = backend(path.Backend)
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func getIngressV1beta1Rules(obj *unstructured.Unstructured) ([]RoutingRule, error) {
+	ingress := networkv1beta1.Ingress{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &ingress); err != nil {
+		return nil, err
+	}
+	var rows []RoutingRule
+	if b := ingress.Spec.Backend; b != nil {
+		rows = append(rows, RoutingRule{BackendService: b.ServiceName, BackendPort: b.ServicePort.String()})
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			row := RoutingRule{
+				Host:           rule.Host,
+				Path:           path.Path,
+				BackendService: path.Backend.ServiceName,
+				BackendPort:    path.Backend.ServicePort.String(),
+			}
+			if path.PathType != nil {
+				row.PathType = string(*path.PathType)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// getHTTPRouteRules reads the rules of the HTTPRoute from the unstructured object, since the Gateway API types are
+// not vendored. Only the path matches and the backend refs of the Service kind are read.
+func getHTTPRouteRules(obj *unstructured.Unstructured) []RoutingRule {
+	hostnames, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "hostnames")
+	if len(hostnames) == 0 {
+		hostnames = []string{""}
+	}
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	var rows []RoutingRule
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paths := []RoutingRule{{Path: "/", PathType: defaultHTTPRoutePathType}}
+		if matches, _, _ := unstructured.NestedSlice(rule, "matches"); len(matches) > 0 {
+			paths = nil
+			for _, m := range matches {
+				match, ok := m.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				value, _, _ := unstructured.NestedString(match, "path", "value")
+				pathType, _, _ := unstructured.NestedString(match, "path", "type")
+				if value == "" {
+					value = "/"
+				}
+				if pathType == "" {
+					pathType = defaultHTTPRoutePathType
+				}
+				paths = append(paths, RoutingRule{Path: value, PathType: pathType})
+			}
+		}
+		backendRefs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		for _, b := range backendRefs {
+			ref, ok := b.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if kind, _, _ := unstructured.NestedString(ref, "kind"); kind != "" && kind != "Service" {
+				continue
+			}
+			service, _, _ := unstructured.NestedString(ref, "name")
+			var port string
+			if number, found, _ := unstructured.NestedInt64(ref, "port"); found {
+				port = strconv.FormatInt(number, 10)
+			}
+			for _, host := range hostnames {
+				for _, path := range paths {
+					rows = append(rows, RoutingRule{
+						Host: host, Path: path.Path, PathType: path.PathType, BackendService: service, BackendPort: port,
+					})
+				}
+			}
+		}
+	}
+	return rows
+}
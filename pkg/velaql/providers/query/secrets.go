@@ -0,0 +1,131 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// AppSecret is a Secret applied or referenced by the application. It has no field for the data on purpose,
+// only the names of the keys are read from the secret.
+type AppSecret struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Cluster   string            `json:"cluster"`
+	Type      corev1.SecretType `json:"type,omitempty"`
+	Keys      []string          `json:"keys,omitempty"`
+	// Managed means the Secret is applied by the application and managed by KubeVela
+	Managed bool `json:"managed"`
+	// Exists is false if the referenced Secret is not found
+	Exists bool `json:"exists"`
+	// ReferencedBy are the workloads whose pods reference the Secret, such as Deployment/web
+	ReferencedBy []string `json:"referencedBy,omitempty"`
+}
+
+var secretGVK = corev1.SchemeGroupVersion.WithKind(secretKind)
+
+// collectSecrets lists the Secrets applied by the application and the ones referenced by the pod templates of its
+// workloads by env, envFrom, volumes or imagePullSecrets, sorted by the cluster, the namespace and the name
+func collectSecrets(cli client.Client, resources []Resource) ([]AppSecret, error) {
+	secrets := []AppSecret{}
+	index := map[string]int{}
+	getIndex := func(cluster, namespace, name string) int {
+		key := cluster + "/" + namespace + "/" + name
+		if i, ok := index[key]; ok {
+			return i
+		}
+		index[key] = len(secrets)
+		secrets = append(secrets, AppSecret{Name: name, Namespace: namespace, Cluster: cluster})
+		return len(secrets) - 1
+	}
+	for _, res := range resources {
+		if res.Object.GroupVersionKind() != secretGVK {
+			continue
+		}
+		secret := corev1.Secret{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(res.Object.Object, &secret); err != nil {
+			return nil, err
+		}
+		i := getIndex(normalizeClusterName(res.Cluster), secret.Namespace, secret.Name)
+		secrets[i].Type, secrets[i].Keys = secret.Type, listSecretKeys(secret)
+		secrets[i].Managed, secrets[i].Exists = true, true
+	}
+	for _, res := range resources {
+		cluster, namespace := normalizeClusterName(res.Cluster), res.Object.GetNamespace()
+		workload := res.Object.GetKind() + "/" + res.Object.GetName()
+		for _, ref := range getPodConfigRefs(res.Object) {
+			if ref.Kind == secretKind {
+				i := getIndex(cluster, namespace, ref.Name)
+				secrets[i].ReferencedBy = appendUnique(secrets[i].ReferencedBy, workload)
+			}
+		}
+		if spec := getPodSpec(res.Object); spec != nil {
+			for _, ref := range spec.ImagePullSecrets {
+				i := getIndex(cluster, namespace, ref.Name)
+				secrets[i].ReferencedBy = appendUnique(secrets[i].ReferencedBy, workload)
+			}
+		}
+	}
+	for i := range secrets {
+		if secrets[i].Managed {
+			continue
+		}
+		ctx := multicluster.ContextWithClusterName(context.Background(), secrets[i].Cluster)
+		secret := corev1.Secret{}
+		if err := cli.Get(ctx, client.ObjectKey{Namespace: secrets[i].Namespace, Name: secrets[i].Name}, &secret); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		secrets[i].Type, secrets[i].Keys, secrets[i].Exists = secret.Type, listSecretKeys(secret), true
+	}
+	sort.SliceStable(secrets, func(i, j int) bool {
+		a, b := secrets[i], secrets[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return secrets, nil
+}
+
+// listSecretKeys returns the sorted keys of the data and the string data of the secret
+func listSecretKeys(secret corev1.Secret) []string {
+	keys := make([]string, 0, len(secret.Data)+len(secret.StringData))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	for k := range secret.StringData {
+		if _, ok := secret.Data[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,161 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// defaultServiceAccountName is the service account used by the pods which don't set one
+const defaultServiceAccountName = "default"
+
+var (
+	roleBindingKind        = reflect.TypeOf(rbacv1.RoleBinding{}).Name()
+	clusterRoleBindingKind = reflect.TypeOf(rbacv1.ClusterRoleBinding{}).Name()
+)
+
+// ServiceAccountInfo is the service account used by the pods of the workload and the bindings granting it roles
+type ServiceAccountInfo struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Exists is false if the service account is not found, the pods can't be created in this case
+	Exists                       bool                    `json:"exists"`
+	AutomountServiceAccountToken *bool                   `json:"automountServiceAccountToken,omitempty"`
+	Bindings                     []ServiceAccountBinding `json:"bindings"`
+}
+
+// ServiceAccountBinding is a RoleBinding or a ClusterRoleBinding referencing the service account, directly or by the
+// service account groups. Rules are the rules of the role, they are only read if includeRules is set.
+type ServiceAccountBinding struct {
+	Kind      string              `json:"kind"`
+	Name      string              `json:"name"`
+	Namespace string              `json:"namespace,omitempty"`
+	RoleKind  string              `json:"roleKind"`
+	RoleName  string              `json:"roleName"`
+	Rules     []rbacv1.PolicyRule `json:"rules,omitempty"`
+}
+
+// collectServiceAccount collects the service account in the pod template of the workload and the bindings of it
+func collectServiceAccount(cli client.Client, obj *unstructured.Unstructured, cluster string, includeRules bool) (*ServiceAccountInfo, error) {
+	ctx := multicluster.ContextWithClusterName(context.Background(), cluster)
+	info := &ServiceAccountInfo{Name: defaultServiceAccountName, Namespace: obj.GetNamespace(), Bindings: []ServiceAccountBinding{}}
+	if spec := getPodSpec(obj); spec != nil {
+		switch {
+		case spec.ServiceAccountName != "":
+			info.Name = spec.ServiceAccountName
+		case spec.DeprecatedServiceAccount != "":
+			info.Name = spec.DeprecatedServiceAccount
+		}
+		info.AutomountServiceAccountToken = spec.AutomountServiceAccountToken
+	}
+	sa := corev1.ServiceAccount{}
+	err := cli.Get(ctx, client.ObjectKey{Namespace: info.Namespace, Name: info.Name}, &sa)
+	switch {
+	case err == nil:
+		info.Exists = true
+		// the pod spec takes precedence over the service account
+		if info.AutomountServiceAccountToken == nil {
+			info.AutomountServiceAccountToken = sa.AutomountServiceAccountToken
+		}
+	case !kerrors.IsNotFound(err):
+		return nil, err
+	}
+
+	roleBindings := rbacv1.RoleBindingList{}
+	if err = cli.List(ctx, &roleBindings, client.InNamespace(info.Namespace)); err != nil {
+		return nil, err
+	}
+	for _, rb := range roleBindings.Items {
+		if isServiceAccountSubject(rb.Subjects, rb.Namespace, info.Name, info.Namespace) {
+			info.Bindings = append(info.Bindings, ServiceAccountBinding{
+				Kind: roleBindingKind, Name: rb.Name, Namespace: rb.Namespace, RoleKind: rb.RoleRef.Kind, RoleName: rb.RoleRef.Name,
+			})
+		}
+	}
+	clusterRoleBindings := rbacv1.ClusterRoleBindingList{}
+	if err = cli.List(ctx, &clusterRoleBindings); err != nil {
+		return nil, err
+	}
+	for _, crb := range clusterRoleBindings.Items {
+		if isServiceAccountSubject(crb.Subjects, "", info.Name, info.Namespace) {
+			info.Bindings = append(info.Bindings, ServiceAccountBinding{
+				Kind: clusterRoleBindingKind, Name: crb.Name, RoleKind: crb.RoleRef.Kind, RoleName: crb.RoleRef.Name,
+			})
+		}
+	}
+	if includeRules {
+		for i, binding := range info.Bindings {
+			if info.Bindings[i].Rules, err = getRoleRules(ctx, cli, binding); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return info, nil
+}
+
+// isServiceAccountSubject checks if the service account is in the subjects, the namespace of a service account
+// subject in a RoleBinding defaults to the namespace of the binding
+func isServiceAccountSubject(subjects []rbacv1.Subject, bindingNamespace, name, namespace string) bool {
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.ServiceAccountKind:
+			subjectNamespace := subject.Namespace
+			if subjectNamespace == "" {
+				subjectNamespace = bindingNamespace
+			}
+			if subject.Name == name && subjectNamespace == namespace {
+				return true
+			}
+		case rbacv1.GroupKind:
+			if subject.Name == "system:serviceaccounts" || subject.Name == "system:serviceaccounts:"+namespace {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getRoleRules reads the rules of the role referenced by the binding, the rules are empty if the role is not found
+func getRoleRules(ctx context.Context, cli client.Client, binding ServiceAccountBinding) ([]rbacv1.PolicyRule, error) {
+	var err error
+	var rules []rbacv1.PolicyRule
+	switch binding.RoleKind {
+	case "Role":
+		role := rbacv1.Role{}
+		if err = cli.Get(ctx, client.ObjectKey{Namespace: binding.Namespace, Name: binding.RoleName}, &role); err == nil {
+			rules = role.Rules
+		}
+	case "ClusterRole":
+		role := rbacv1.ClusterRole{}
+		if err = cli.Get(ctx, client.ObjectKey{Name: binding.RoleName}, &role); err == nil {
+			rules = role.Rules
+		}
+	}
+	if err != nil && !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,94 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+)
+
+// DefaultAppWarningsLimit is the number of the resources returned by collectAppWarnings if the limit is not set
+const DefaultAppWarningsLimit = 10
+
+// ResourceWarning is the warning events of a resource in the application
+type ResourceWarning struct {
+	Cluster   string `json:"cluster"`
+	Component string `json:"component"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Count is the sum of the counts of the warning events, LastTimestamp is the time of the most recent one
+	Count         int32       `json:"count"`
+	LastTimestamp metav1.Time `json:"lastTimestamp"`
+	// Events are sorted so that the most recent events come first
+	Events []ResourceEvent `json:"events"`
+}
+
+// collectAppWarnings collects the warning events of the resources, the resources with the most warnings come first
+// and only the top limit resources are returned
+func collectAppWarnings(cli client.Client, resources []Resource, limit int) ([]ResourceWarning, error) {
+	warnings := []ResourceWarning{}
+	for _, res := range resources {
+		obj := res.Object
+		ctx := multicluster.ContextWithClusterName(context.Background(), res.Cluster)
+		eventList := corev1.EventList{}
+		if err := cli.List(ctx, &eventList, client.InNamespace(obj.GetNamespace()), client.MatchingFieldsSelector{
+			Selector: fields.AndSelectors(getEventFieldSelector(obj), fields.OneTermEqualSelector("type", corev1.EventTypeWarning)),
+		}); err != nil {
+			return nil, err
+		}
+		if len(eventList.Items) == 0 {
+			continue
+		}
+		sortEventsByTime(eventList.Items)
+		warning := ResourceWarning{
+			Cluster:       res.Cluster,
+			Component:     res.Component,
+			Kind:          obj.GetKind(),
+			Namespace:     obj.GetNamespace(),
+			Name:          obj.GetName(),
+			LastTimestamp: metav1.NewTime(getEventTime(eventList.Items[0])),
+		}
+		for _, event := range eventList.Items {
+			warning.Count += event.Count
+			warning.Events = append(warning.Events, newResourceEvent(event))
+		}
+		warnings = append(warnings, warning)
+	}
+	sortResourceWarnings(warnings)
+	if limit > 0 && len(warnings) > limit {
+		warnings = warnings[:limit]
+	}
+	return warnings, nil
+}
+
+// sortResourceWarnings sorts the warnings by the count, the most recent ones come first if the counts are equal
+func sortResourceWarnings(warnings []ResourceWarning) {
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Count != warnings[j].Count {
+			return warnings[i].Count > warnings[j].Count
+		}
+		return warnings[j].LastTimestamp.Before(&warnings[i].LastTimestamp)
+	})
+}
@@ -0,0 +1,128 @@
+/*
+ Copyright 2021. The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package query
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+)
+
+const (
+	// WorkflowPhaseInitializing means the workflow of the application is not started yet
+	WorkflowPhaseInitializing = "initializing"
+	// WorkflowPhaseExecuting means the workflow is running the steps
+	WorkflowPhaseExecuting = "executing"
+	// WorkflowPhaseSuspended means the workflow is suspended and waits to be resumed
+	WorkflowPhaseSuspended = "suspended"
+	// WorkflowPhaseTerminated means the workflow is terminated
+	WorkflowPhaseTerminated = "terminated"
+	// WorkflowPhaseFailed means the workflow is finished with failed steps
+	WorkflowPhaseFailed = "failed"
+	// WorkflowPhaseSucceeded means all the steps of the workflow are succeeded
+	WorkflowPhaseSucceeded = "succeeded"
+)
+
+// WorkflowStatus is the normalized status of the workflow of the application
+type WorkflowStatus struct {
+	Phase       string              `json:"phase"`
+	Mode        common.WorkflowMode `json:"mode,omitempty"`
+	AppRevision string              `json:"appRevision,omitempty"`
+	Message     string              `json:"message,omitempty"`
+	Suspend     bool                `json:"suspend"`
+	Terminated  bool                `json:"terminated"`
+	Finished    bool                `json:"finished"`
+	StartTime   *metav1.Time        `json:"startTime,omitempty"`
+	Steps       []WorkflowStep      `json:"steps"`
+}
+
+// WorkflowStep is the status of a step or a sub step of the workflow, the execute times are not recorded for the sub steps
+type WorkflowStep struct {
+	Name             string                   `json:"name"`
+	Type             string                   `json:"type,omitempty"`
+	Phase            common.WorkflowStepPhase `json:"phase,omitempty"`
+	Message          string                   `json:"message,omitempty"`
+	Reason           string                   `json:"reason,omitempty"`
+	FirstExecuteTime *metav1.Time             `json:"firstExecuteTime,omitempty"`
+	LastExecuteTime  *metav1.Time             `json:"lastExecuteTime,omitempty"`
+	SubSteps         []WorkflowStep           `json:"subSteps,omitempty"`
+}
+
+// collectWorkflowStatus normalizes the workflow status of the application,
+// the application without workflow status is initializing
+func collectWorkflowStatus(status common.AppStatus) *WorkflowStatus {
+	workflow := status.Workflow
+	if workflow == nil {
+		return &WorkflowStatus{Phase: WorkflowPhaseInitializing, Steps: []WorkflowStep{}}
+	}
+	result := &WorkflowStatus{
+		Mode:        workflow.Mode,
+		AppRevision: workflow.AppRevision,
+		Message:     workflow.Message,
+		Suspend:     workflow.Suspend,
+		Terminated:  workflow.Terminated,
+		Finished:    workflow.Finished,
+		StartTime:   optionalTime(workflow.StartTime),
+		Steps:       []WorkflowStep{},
+	}
+	failed := false
+	for _, step := range workflow.Steps {
+		s := WorkflowStep{
+			Name:             step.Name,
+			Type:             step.Type,
+			Phase:            step.Phase,
+			Message:          step.Message,
+			Reason:           step.Reason,
+			FirstExecuteTime: optionalTime(step.FirstExecuteTime),
+			LastExecuteTime:  optionalTime(step.LastExecuteTime),
+		}
+		if step.SubSteps != nil {
+			for _, sub := range step.SubSteps.Steps {
+				s.SubSteps = append(s.SubSteps, WorkflowStep{
+					Name:    sub.Name,
+					Type:    sub.Type,
+					Phase:   sub.Phase,
+					Message: sub.Message,
+					Reason:  sub.Reason,
+				})
+			}
+		}
+		failed = failed || step.Phase == common.WorkflowStepPhaseFailed
+		result.Steps = append(result.Steps, s)
+	}
+	switch {
+	case workflow.Terminated:
+		result.Phase = WorkflowPhaseTerminated
+	case workflow.Suspend:
+		result.Phase = WorkflowPhaseSuspended
+	case workflow.Finished && failed:
+		result.Phase = WorkflowPhaseFailed
+	case workflow.Finished:
+		result.Phase = WorkflowPhaseSucceeded
+	default:
+		result.Phase = WorkflowPhaseExecuting
+	}
+	return result
+}
+
+// optionalTime returns nil for the zero time so that it's omitted
+func optionalTime(t metav1.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -93,7 +93,7 @@ func (handler *ViewHandler) QueryView(ctx context.Context, qv QueryView) (*value
 		return nil, err
 	}
 
-	viewCtx, err := NewViewContext()
+	viewCtx, err := NewViewContext(ctx)
 	if err != nil {
 		return nil, err
 	}
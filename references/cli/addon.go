@@ -35,7 +35,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	common2 "github.com/oam-dev/kubevela/apis/core.oam.dev/common"
-	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/types"
 	pkgaddon "github.com/oam-dev/kubevela/pkg/addon"
 	"github.com/oam-dev/kubevela/pkg/utils/apply"
@@ -56,11 +55,6 @@ const (
 	AddonTerraformProviderNameArgument = "providerName"
 )
 
-const (
-	statusEnabled  = "enabled"
-	statusDisabled = "disabled"
-)
-
 var clt client.Client
 var clientArgs common.Args
 
@@ -288,7 +282,7 @@ func enableAddon(ctx context.Context, k8sClient client.Client, config *rest.Conf
 	}
 
 	for _, registry := range registries {
-		err = pkgaddon.EnableAddon(ctx, name, k8sClient, apply.NewAPIApplicator(k8sClient), config, registry, args, nil)
+		err = pkgaddon.EnableAddon(ctx, name, k8sClient, apply.NewAPIApplicator(k8sClient), config, registry, args, nil, "", false, "")
 		if errors.Is(err, pkgaddon.ErrNotExist) {
 			continue
 		}
@@ -316,9 +310,28 @@ func statusAddon(name string, ioStreams cmdutil.IOStreams, cmd *cobra.Command, c
 		return err
 	}
 	fmt.Printf("addon %s status is %s \n", name, status.AddonPhase)
-	if status.AddonPhase != statusEnabled && status.AddonPhase != statusDisabled {
-		fmt.Printf("diagnose addon info from application %s", pkgaddon.Convert2AppName(name))
-		err := printAppStatus(context.Background(), clt, ioStreams, pkgaddon.Convert2AppName(name), types.DefaultKubeVelaNS, cmd, c)
+	if status.InstalledVersion != "" {
+		fmt.Printf("installed version: %s\n", status.InstalledVersion)
+	}
+	if status.RegistryName != "" {
+		fmt.Printf("installed from registry: %s\n", status.RegistryName)
+	}
+	if status.AddonPhase == pkgaddon.AddonPhaseFailed && status.Message != "" {
+		fmt.Printf("addon %s won't progress: %s \n", name, status.Message)
+	}
+	if len(status.Conditions) != 0 {
+		fmt.Println("conditions:")
+		for _, cond := range status.Conditions {
+			fmt.Printf("  %s\t%s\t%s\t%s\t%s\n", cond.Type, cond.Status, cond.Reason, cond.LastTransitionTime.Format(time.RFC3339), cond.Message)
+		}
+	}
+	if status.AddonPhase != pkgaddon.AddonPhaseEnabled && status.AddonPhase != pkgaddon.AddonPhaseDisabled {
+		app, err := pkgaddon.FetchAddonRelatedApp(context.Background(), clt, name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("diagnose addon info from application %s", app.Name)
+		err = printAppStatus(context.Background(), clt, ioStreams, app.Name, app.Namespace, cmd, c)
 		if err != nil {
 			return err
 		}
@@ -370,13 +383,12 @@ func waitApplicationRunning(addonName string) error {
 	timeout := 600 * time.Second
 	start := time.Now()
 	ctx := context.Background()
-	var app v1beta1.Application
 	spinner := newTrackingSpinnerWithDelay("Waiting addon running ...", 1*time.Second)
 	spinner.Start()
 	defer spinner.Stop()
 
 	for {
-		err := clt.Get(ctx, types2.NamespacedName{Name: pkgaddon.Convert2AppName(addonName), Namespace: types.DefaultKubeVelaNS}, &app)
+		app, err := pkgaddon.FetchAddonRelatedApp(ctx, clt, addonName)
 		if err != nil {
 			return client.IgnoreNotFound(err)
 		}